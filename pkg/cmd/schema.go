@@ -0,0 +1,19 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewSchemaCmd groups the commands that work with data values schemas
+func NewSchemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Work with data values schemas",
+	}
+	cmd.AddCommand(NewSchemaImportCmd(NewSchemaImportOptions()))
+	cmd.AddCommand(NewSchemaDiffCmd(NewSchemaDiffOptions()))
+	return cmd
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/cmd/ui"
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/schema"
+	"github.com/k14s/ytt/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+// SchemaDiffOptions holds the configuration of the `schema diff` command
+type SchemaDiffOptions struct {
+	Files []string
+	Debug bool
+}
+
+// NewSchemaDiffOptions creates the default configuration of the `schema diff` command
+func NewSchemaDiffOptions() *SchemaDiffOptions {
+	return &SchemaDiffOptions{}
+}
+
+// NewSchemaDiffCmd creates the `schema diff` command
+func NewSchemaDiffCmd(o *SchemaDiffOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare the data values schema of two versions of a package (fails if there are breaking changes)",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	cmd.Flags().StringArrayVarP(&o.Files, "file", "f", nil, "Old, then new version of the package (ie local path, HTTP URL) (must be specified twice)")
+	cmd.Flags().BoolVar(&o.Debug, "debug", false, "Enable debug output")
+	return cmd
+}
+
+// Run prints the changes between the schemas of the given versions of a package
+func (o *SchemaDiffOptions) Run() error {
+	return o.RunWithWriter(ui.NewTTY(o.Debug), os.Stdout)
+}
+
+// RunWithWriter writes the changes between the schemas of the given versions of a package to `out`.
+// Breaking changes are reported as an error.
+func (o *SchemaDiffOptions) RunWithWriter(ui ui.UI, out io.Writer) error {
+	if len(o.Files) != 2 {
+		return fmt.Errorf("Expected old and new version of package to be specified via -f flag (twice), but found %d", len(o.Files))
+	}
+
+	oldDocType, err := o.schemaOf(o.Files[0], ui)
+	if err != nil {
+		return err
+	}
+	newDocType, err := o.schemaOf(o.Files[1], ui)
+	if err != nil {
+		return err
+	}
+
+	var breaking, nonBreaking []string
+	for _, change := range schema.Diff(oldDocType, newDocType) {
+		line := fmt.Sprintf("- %s: %s (%s)\n", change.Path, change.Description, o.positions(change, o.Files[0], o.Files[1]))
+		if change.Breaking {
+			breaking = append(breaking, line)
+		} else {
+			nonBreaking = append(nonBreaking, line)
+		}
+	}
+
+	var sections []string
+	if len(breaking) > 0 {
+		sections = append(sections, "Breaking changes\n"+strings.Join(breaking, ""))
+	}
+	if len(nonBreaking) > 0 {
+		sections = append(sections, "Non-breaking changes\n"+strings.Join(nonBreaking, ""))
+	}
+	if len(sections) == 0 {
+		sections = append(sections, "No changes to data values schema\n")
+	}
+	_, err = fmt.Fprint(out, strings.Join(sections, "\n"))
+	if err != nil {
+		return err
+	}
+
+	if len(breaking) > 0 {
+		return fmt.Errorf("Found %d breaking change(s) to data values schema", len(breaking))
+	}
+	return nil
+}
+
+func (o *SchemaDiffOptions) schemaOf(path string, ui ui.UI) (*schema.DocumentType, error) {
+	filesToProcess, err := files.NewSortedFilesFromPaths([]string{path}, files.SymlinkAllowOpts{})
+	if err != nil {
+		return nil, err
+	}
+
+	rootLibrary := workspace.NewRootLibrary(filesToProcess)
+	libraryExecutionFactory := workspace.NewLibraryExecutionFactory(ui, workspace.TemplateLoaderOpts{})
+	libraryCtx := workspace.LibraryExecutionContext{Current: rootLibrary, Root: rootLibrary}
+
+	dataValuesSchema, _, err := libraryExecutionFactory.New(libraryCtx).Schemas(nil)
+	if err != nil {
+		return nil, fmt.Errorf("Determining data values schema of '%s': %s", path, err)
+	}
+	return dataValuesSchema.GetDocumentType(), nil
+}
+
+func (o *SchemaDiffOptions) positions(change schema.Change, oldRoot, newRoot string) string {
+	var positions []string
+	if change.OldPosition.IsKnown() {
+		positions = append(positions, "old: "+o.positionWithin(oldRoot, change.OldPosition))
+	}
+	if change.NewPosition.IsKnown() {
+		positions = append(positions, "new: "+o.positionWithin(newRoot, change.NewPosition))
+	}
+	return strings.Join(positions, ", ")
+}
+
+// positionWithin formats `pos` with its file path qualified by `root` (as given via -f),
+// so that positions in the old and new versions of a package can be told apart.
+func (o *SchemaDiffOptions) positionWithin(root string, pos *filepos.Position) string {
+	path := root
+	if info, err := os.Stat(root); err == nil && info.IsDir() {
+		path = filepath.Join(root, pos.GetFile())
+	}
+	return fmt.Sprintf("%s:%d", path, pos.LineNum())
+}
@@ -0,0 +1,188 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/cmd"
+	"github.com/k14s/ytt/pkg/cmd/ui"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaDiff_Reports_breaking_and_non_breaking_changes(t *testing.T) {
+	oldSchemaYAML := `#@data/values-schema
+---
+port: 8080
+ratio: 1
+replicas: 1
+#@schema/nullable
+nickname: ""
+image:
+  name: nginx
+  tag: latest
+hosts:
+- ""
+#@schema/type any=True
+extra: {}
+`
+	newSchemaYAML := `#@data/values-schema
+---
+port: 8080
+ratio: 1.0
+replicas: "1"
+nickname: ""
+image:
+  name: nginx
+  tag: stable
+  #@schema/nullable
+  digest: ""
+hosts:
+- name: ""
+#@schema/type any=True
+extra: {}
+#@schema/validation min_len=1
+cluster: ""
+`
+	expectedOut := `Breaking changes
+- replicas: type narrowed from integer to string (old: old/schema.yml:5, new: new/schema.yml:5)
+- replicas: default changed from 1 to "1" (old: old/schema.yml:5, new: new/schema.yml:5)
+- nickname: is no longer nullable (old: old/schema.yml:7, new: new/schema.yml:6)
+- nickname: default changed from null to "" (old: old/schema.yml:7, new: new/schema.yml:6)
+- image.tag: default changed from "latest" to "stable" (old: old/schema.yml:10, new: new/schema.yml:9)
+- hosts: type narrowed from array of string to array of map (old: old/schema.yml:11, new: new/schema.yml:12)
+- cluster: was added, without a valid default (i.e. must be set) (new: new/schema.yml:17)
+
+Non-breaking changes
+- ratio: type widened from integer to float (old: old/schema.yml:4, new: new/schema.yml:4)
+- image.digest: was added (new: new/schema.yml:11)
+`
+
+	stdout, err := runSchemaDiff(t, oldSchemaYAML, newSchemaYAML)
+	require.EqualError(t, err, "Found 7 breaking change(s) to data values schema")
+	require.Equal(t, expectedOut, stdout)
+}
+
+func TestSchemaDiff_Reports_removed_keys(t *testing.T) {
+	oldSchemaYAML := `#@data/values-schema
+---
+image:
+  name: nginx
+  tag: latest
+`
+	newSchemaYAML := `#@data/values-schema
+---
+image:
+  name: nginx
+`
+	expectedOut := `Breaking changes
+- image.tag: was removed (old: old/schema.yml:5)
+`
+
+	stdout, err := runSchemaDiff(t, oldSchemaYAML, newSchemaYAML)
+	require.EqualError(t, err, "Found 1 breaking change(s) to data values schema")
+	require.Equal(t, expectedOut, stdout)
+}
+
+func TestSchemaDiff_Reports_positions_within_given_files(t *testing.T) {
+	oldSchemaPath := writeTempFile(t, "schema.yml", `#@data/values-schema
+---
+port: 8080
+`)
+	newSchemaPath := writeTempFile(t, "schema.yml", `#@data/values-schema
+---
+port: "8080"
+`)
+	expectedOut := `Breaking changes
+- port: type narrowed from integer to string (old: ` + oldSchemaPath + `:3, new: ` + newSchemaPath + `:3)
+- port: default changed from 8080 to "8080" (old: ` + oldSchemaPath + `:3, new: ` + newSchemaPath + `:3)
+`
+
+	opts := cmd.NewSchemaDiffOptions()
+	opts.Files = []string{oldSchemaPath, newSchemaPath}
+
+	stdout := &bytes.Buffer{}
+	err := opts.RunWithWriter(ui.NewTTY(false), stdout)
+	require.EqualError(t, err, "Found 2 breaking change(s) to data values schema")
+	require.Equal(t, expectedOut, stdout.String())
+}
+
+func TestSchemaDiff_Succeeds_without_breaking_changes(t *testing.T) {
+	t.Run("when there are none", func(t *testing.T) {
+		schemaYAML := `#@data/values-schema
+---
+port: 8080
+`
+
+		stdout, err := runSchemaDiff(t, schemaYAML, schemaYAML)
+		require.NoError(t, err)
+		require.Equal(t, "No changes to data values schema\n", stdout)
+	})
+	t.Run("when types are widened", func(t *testing.T) {
+		oldSchemaYAML := `#@data/values-schema
+---
+port: 8080
+labels:
+  app: ""
+image: ""
+#@schema/nullable
+tag: ""
+`
+		newSchemaYAML := `#@data/values-schema
+---
+#@schema/type one_of=[0, ""]
+port: 8080
+#@schema/type map_of=""
+labels:
+  app: ""
+#@schema/type any=True
+image: ""
+#@schema/type any=True
+tag: null
+`
+		expectedOut := `Non-breaking changes
+- port: type widened from integer to one of (integer, string) (old: old/schema.yml:3, new: new/schema.yml:4)
+- labels: type widened from map to map of string (old: old/schema.yml:4, new: new/schema.yml:6)
+- image: type widened from string to any (old: old/schema.yml:6, new: new/schema.yml:9)
+- tag: type widened from string to any (old: old/schema.yml:8, new: new/schema.yml:11)
+`
+
+		stdout, err := runSchemaDiff(t, oldSchemaYAML, newSchemaYAML)
+		require.NoError(t, err)
+		require.Equal(t, expectedOut, stdout)
+	})
+}
+
+func TestSchemaDiff_Errors(t *testing.T) {
+	t.Run("when not given exactly two versions", func(t *testing.T) {
+		opts := cmd.NewSchemaDiffOptions()
+		opts.Files = []string{"old/"}
+
+		err := opts.RunWithWriter(ui.NewTTY(false), &bytes.Buffer{})
+		require.EqualError(t, err, "Expected old and new version of package to be specified via -f flag (twice), but found 1")
+	})
+}
+
+// runSchemaDiff compares packages in directories "old/" and "new/" (reported relative to a temporary directory)
+func runSchemaDiff(t *testing.T, oldSchemaYAML, newSchemaYAML string) (string, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for version, schemaYAML := range map[string]string{"old": oldSchemaYAML, "new": newSchemaYAML} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, version), 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, version, "schema.yml"), []byte(schemaYAML), 0600))
+	}
+
+	opts := cmd.NewSchemaDiffOptions()
+	opts.Files = []string{filepath.Join(dir, "old"), filepath.Join(dir, "new")}
+
+	stdout := &bytes.Buffer{}
+	err := opts.RunWithWriter(ui.NewTTY(false), stdout)
+	return strings.ReplaceAll(stdout.String(), dir+string(filepath.Separator), ""), err
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/cmd/ui"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/schema"
+	"github.com/k14s/ytt/pkg/yamlfmt"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	"github.com/spf13/cobra"
+)
+
+// SchemaImportOptions holds the configuration of the `schema import` command
+type SchemaImportOptions struct {
+	From  string
+	Debug bool
+}
+
+// NewSchemaImportOptions creates the default configuration of the `schema import` command
+func NewSchemaImportOptions() *SchemaImportOptions {
+	return &SchemaImportOptions{}
+}
+
+// NewSchemaImportCmd creates the `schema import` command
+func NewSchemaImportCmd(o *SchemaImportOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Generate a data values schema from a JSON Schema (or OpenAPI v3) document",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	cmd.Flags().StringVar(&o.From, "from", "", "JSON Schema or OpenAPI v3 document (ie local path, HTTP URL, -)")
+	cmd.Flags().BoolVar(&o.Debug, "debug", false, "Enable debug output")
+	return cmd
+}
+
+// Run prints the data values schema generated from the given JSON Schema document
+func (o *SchemaImportOptions) Run() error {
+	return o.RunWithWriter(ui.NewTTY(o.Debug), os.Stdout)
+}
+
+// RunWithWriter writes the generated data values schema to `out`, reporting anything not imported exactly to `ui`
+func (o *SchemaImportOptions) RunWithWriter(ui ui.UI, out io.Writer) error {
+	if len(o.From) == 0 {
+		return fmt.Errorf("Expected JSON Schema document to be specified via --from flag")
+	}
+
+	filesToImport, err := files.NewSortedFilesFromPaths([]string{o.From}, files.SymlinkAllowOpts{})
+	if err != nil {
+		return err
+	}
+	if len(filesToImport) != 1 {
+		return fmt.Errorf("Expected --from to be a single file, but found %d files", len(filesToImport))
+	}
+
+	data, err := filesToImport[0].Bytes()
+	if err != nil {
+		return err
+	}
+
+	docSet, err := yamlmeta.NewDocumentSetFromBytes(data, yamlmeta.DocSetOpts{AssociatedName: filesToImport[0].RelativePath()})
+	if err != nil {
+		return fmt.Errorf("Unmarshaling JSON Schema document: %s", err)
+	}
+	var docs []*yamlmeta.Document
+	for _, doc := range docSet.Items {
+		if doc.Value != nil {
+			docs = append(docs, doc)
+		}
+	}
+	if len(docs) != 1 {
+		return fmt.Errorf("Expected '%s' to contain exactly one document, but found %d", o.From, len(docs))
+	}
+
+	importer, err := schema.NewJSONSchemaImporter(docs[0])
+	if err != nil {
+		return err
+	}
+	schemaDocSet, err := importer.AsDocumentSet()
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range importer.Warnings() {
+		ui.Warnf("Warning: %s\n", warning)
+	}
+
+	// the schema document is preceded by (empty) spacing
+	_, err = fmt.Fprint(out, strings.TrimLeft(yamlfmt.NewPrinter(nil).PrintStr(schemaDocSet), "\n"))
+	return err
+}
@@ -0,0 +1,255 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/cmd"
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/cmd/ui"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaImport_From_JSONSchema(t *testing.T) {
+	jsonSchema := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "description": "Configuration of the app",
+  "properties": {
+    "name": {"type": "string", "description": "Name of the app", "minLength": 1},
+    "port": {"type": "integer", "default": 8080, "minimum": 1, "maximum": 65535},
+    "ratio": {"type": "number", "default": 1},
+    "debug": {"type": "boolean"},
+    "level": {"type": "string", "enum": ["debug", "info"], "default": "info"},
+    "nickname": {"type": ["string", "null"]},
+    "owner": {"anyOf": [{"type": "string"}, {"type": "null"}], "default": "ops"},
+    "hosts": {"type": "array", "items": {"type": "string", "pattern": "^[a-z.]+$"}, "default": ["example.com"]},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}, "default": {"app": "web"}},
+    "tls": {"$ref": "#/$defs/tls"},
+    "extras": {"description": "Anything else"}
+  },
+  "$defs": {
+    "tls": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "enabled": {"type": "boolean", "default": true},
+        "ports": {"type": "array", "items": {"type": "integer", "exclusiveMinimum": 0}}
+      }
+    }
+  }
+}`
+	expected := `#@data/values-schema
+#@schema/desc "Configuration of the app"
+---
+#@schema/desc "Name of the app"
+#@schema/validation min_len=1
+name: ""
+
+#@schema/validation min=1, max=65535
+port: 8080
+
+ratio: #@ 1.0
+debug: false
+
+#@schema/validation one_of=["debug", "info"]
+level: info
+
+#@schema/nullable
+nickname: ""
+
+#@schema/nullable
+#@schema/default "ops"
+owner: ops
+
+#@schema/default ["example.com"]
+hosts:
+  #@schema/validation regex="^[a-z.]+$"
+  - ""
+
+#@schema/type map_of=""
+labels:
+  app: web
+
+tls:
+  enabled: true
+  ports:
+    #@schema/validation ("a value greater than 0", lambda v: v > 0)
+    - 0
+
+#@schema/desc "Anything else"
+#@schema/type any=True
+extras: null
+`
+
+	stdout, stderr := runSchemaImport(t, "values.schema.json", jsonSchema)
+
+	require.Equal(t, expected, stdout)
+	require.Empty(t, stderr)
+}
+
+func TestSchemaImport_From_OpenAPI_document(t *testing.T) {
+	openAPI := `openapi: 3.0.0
+info:
+  version: 0.1.0
+  title: Schema for data values, generated by ytt
+paths: {}
+components:
+  schemas:
+    dataValues:
+      type: object
+      additionalProperties: false
+      properties:
+        port:
+          type: integer
+          description: The port
+          default: 80
+        ratio:
+          type: number
+          format: float
+          default: 0.5
+        nickname:
+          type: string
+          nullable: true
+          default: null
+        anything:
+          nullable: true
+          default: {}
+`
+	expected := `#@data/values-schema
+---
+#@schema/desc "The port"
+port: 80
+
+ratio: 0.5
+
+#@schema/nullable
+nickname: ""
+
+#@schema/type any=True
+anything: {}
+`
+
+	stdout, stderr := runSchemaImport(t, "openapi.yml", openAPI)
+
+	require.Equal(t, expected, stdout)
+	require.Empty(t, stderr)
+}
+
+func TestSchemaImport_Warns_of_constructs_not_imported_exactly(t *testing.T) {
+	jsonSchema := `{
+  "type": "object",
+  "properties": {
+    "id": {"type": ["string", "integer"]},
+    "email": {"type": "string", "format": "email"},
+    "labels": {"type": "object", "additionalProperties": {"type": "string", "minLength": 1}},
+    "ports": {"type": "array", "items": {"type": "integer"}, "uniqueItems": true},
+    "source": {"oneOf": [{"type": "string"}, {"type": "object", "properties": {"url": {"type": "string"}}}]},
+    "parent": {"$ref": "https://example.com/schemas/parent.json"}
+  }
+}`
+	expectedWarnings := `Warning: id: a value of more than one type (string, integer) is not supported; any value is permitted instead
+Warning: email: 'format' is not supported; ignored
+Warning: labels.*: annotations (e.g. descriptions, validations) cannot be expressed on the values of a map; ignored
+Warning: ports: 'uniqueItems' is not supported; ignored
+Warning: source: 'oneOf' with more than one (non-null) alternative is not supported; any value is permitted instead
+Warning: parent: could not resolve reference 'https://example.com/schemas/parent.json' (only references within the document are supported); any value is permitted instead
+`
+
+	_, stderr := runSchemaImport(t, "values.schema.json", jsonSchema)
+
+	require.Equal(t, expectedWarnings, stderr)
+}
+
+func TestSchemaImport_Produces_a_schema_usable_by_ytt(t *testing.T) {
+	jsonSchema := `{
+  "type": "object",
+  "properties": {
+    "port": {"type": "integer", "default": 8080, "minimum": 1},
+    "ratio": {"type": "number", "default": 1},
+    "nickname": {"type": ["string", "null"]},
+    "hosts": {"type": "array", "items": {"type": "string"}, "default": ["example.com"]}
+  }
+}`
+	schemaYAML, _ := runSchemaImport(t, "values.schema.json", jsonSchema)
+
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+port: #@ data.values.port
+ratio: #@ data.values.ratio
+nickname: #@ data.values.nickname
+hosts: #@ data.values.hosts
+`
+	expected := `port: 8080
+ratio: 1.5
+nickname: null
+hosts:
+- example.com
+`
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+	})
+
+	// a float is only accepted when the number's type was retained (rather than inferred as an integer)
+	opts := cmdtpl.NewOptions()
+	opts.DataValuesFlags.KVsFromYAML = []string{"ratio=1.5"}
+	out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewTTY(false))
+	require.NoError(t, out.Err)
+	require.Len(t, out.Files, 1)
+	require.Equal(t, expected, string(out.Files[0].Bytes()))
+}
+
+func TestSchemaImport_Errors(t *testing.T) {
+	t.Run("when root schema is not an object", func(t *testing.T) {
+		opts := cmd.NewSchemaImportOptions()
+		opts.From = writeTempFile(t, "values.schema.json", `{"type": "string"}`)
+
+		err := opts.RunWithWriter(ui.NewTTY(false), &bytes.Buffer{})
+		require.EqualError(t, err, "Expected the root schema to describe an object, but it does not; data values are always a map")
+	})
+	t.Run("when OpenAPI document has no schema for data values", func(t *testing.T) {
+		opts := cmd.NewSchemaImportOptions()
+		opts.From = writeTempFile(t, "openapi.yml", "openapi: 3.0.0\ncomponents:\n  schemas: {}\n")
+
+		err := opts.RunWithWriter(ui.NewTTY(false), &bytes.Buffer{})
+		require.EqualError(t, err, "Expected OpenAPI document to contain a schema in 'components.schemas', but found none")
+	})
+	t.Run("when --from is not given", func(t *testing.T) {
+		err := cmd.NewSchemaImportOptions().RunWithWriter(ui.NewTTY(false), &bytes.Buffer{})
+		require.EqualError(t, err, "Expected JSON Schema document to be specified via --from flag")
+	})
+}
+
+func runSchemaImport(t *testing.T, name, contents string) (string, string) {
+	t.Helper()
+
+	opts := cmd.NewSchemaImportOptions()
+	opts.From = writeTempFile(t, name, contents)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err := opts.RunWithWriter(ui.NewCustomWriterTTY(false, stdout, stderr), stdout)
+	require.NoError(t, err)
+
+	return stdout.String(), stderr.String()
+}
+
+func writeTempFile(t *testing.T, name, contents string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "ytt-schema-import")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+	return path
+}
@@ -5,6 +5,8 @@ package template
 
 import (
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	"github.com/k14s/ytt/pkg/cmd/ui"
@@ -15,6 +17,15 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Formats in which the overlay trace (i.e. --overlay-trace) can be reported (see --overlay-trace-format).
+const (
+	OverlayTraceFormatText = "text"
+	OverlayTraceFormatYAML = "yaml"
+	OverlayTraceFormatJSON = "json"
+)
+
+var overlayTraceFormats = []string{OverlayTraceFormatText, OverlayTraceFormatYAML, OverlayTraceFormatJSON}
+
 type Options struct {
 	IgnoreUnknownComments   bool
 	ImplicitMapKeyOverrides bool
@@ -23,6 +34,9 @@ type Options struct {
 	Debug        bool
 	InspectFiles bool
 
+	OverlayTrace       bool
+	OverlayTraceFormat string
+
 	BulkFilesSourceOpts    BulkFilesSourceOpts
 	RegularFilesSourceOpts RegularFilesSourceOpts
 	FileMarksOpts          FileMarksOpts
@@ -68,6 +82,10 @@ func NewCmd(o *Options) *cobra.Command {
 	cmd.Flags().BoolVarP(&o.StrictYAML, "strict", "s", false, "Configure to use _strict_ YAML subset")
 	cmd.Flags().BoolVar(&o.Debug, "debug", false, "Enable debug output")
 	cmd.Flags().BoolVar(&o.InspectFiles, "files-inspect", false, "Inspect files")
+	cmd.Flags().BoolVar(&o.OverlayTrace, "overlay-trace", false,
+		"Report (to stderr), for each overlay in the order applied, the documents it matched and the operations applied to them")
+	cmd.Flags().StringVar(&o.OverlayTraceFormat, "overlay-trace-format", OverlayTraceFormatText,
+		fmt.Sprintf("Configure format of overlay trace (%s)", strings.Join(overlayTraceFormats, ", ")))
 
 	o.BulkFilesSourceOpts.Set(cmd)
 	o.RegularFilesSourceOpts.Set(cmd)
@@ -109,19 +127,38 @@ func (o *Options) RunWithFiles(in Input, ui ui.UI) Output {
 	rootLibrary := workspace.NewRootLibrary(in.Files)
 	rootLibrary.Print(ui.DebugWriter())
 
+	if len(o.DataValuesFlags.Library) > 0 && !o.DataValuesFlags.Inspect && !o.DataValuesFlags.InspectProvenance && !o.DataValuesFlags.InspectSchema {
+		return Output{Err: fmt.Errorf("Expected --library to be used to inspect data values or schema (i.e. with --data-values-inspect or --data-values-schema-inspect)")}
+	}
+
 	if o.InspectFiles {
 		return o.inspectFiles(rootLibrary)
 	}
 
+	if o.OverlayTrace && !o.isOverlayTraceFormat(o.OverlayTraceFormat) {
+		return Output{Err: fmt.Errorf("Expected --overlay-trace-format to be one of: %s, but was '%s'",
+			strings.Join(overlayTraceFormats, ", "), o.OverlayTraceFormat)}
+	}
+
 	valuesOverlays, libraryValuesOverlays, err := o.DataValuesFlags.AsOverlays(o.StrictYAML)
 	if err != nil {
 		return Output{Err: err}
 	}
 
+	valueResolvers, err := workspace.NewValueResolvers(o.DataValuesFlags.ResolveRefs)
+	if err != nil {
+		return Output{Err: err}
+	}
+
 	libraryExecutionFactory := workspace.NewLibraryExecutionFactory(ui, workspace.TemplateLoaderOpts{
 		IgnoreUnknownComments:   o.IgnoreUnknownComments,
 		ImplicitMapKeyOverrides: o.ImplicitMapKeyOverrides,
 		StrictYAML:              o.StrictYAML,
+
+		DeprecatedDataValuesAsErrors: o.DataValuesFlags.DeprecatedAsErrors,
+		DataValueResolvers:           valueResolvers,
+		DataValuesProfiles:           o.DataValuesFlags.Profiles,
+		OverlayTrace:                 o.OverlayTrace,
 	})
 
 	libraryCtx := workspace.LibraryExecutionContext{Current: rootLibrary, Root: rootLibrary}
@@ -133,14 +170,21 @@ func (o *Options) RunWithFiles(in Input, ui ui.UI) Output {
 	}
 
 	if o.DataValuesFlags.InspectSchema {
-		return o.inspectSchema(schema)
+		if len(o.DataValuesFlags.Library) > 0 {
+			lib, err := rootLibraryExecution.PrivateLibrarySchema(o.DataValuesFlags.Library, librarySchemas)
+			if err != nil {
+				return Output{Err: err}
+			}
+			return o.inspectSchema(lib.Schema, lib.Execution, lib.LibrarySchemas)
+		}
+		return o.inspectSchema(schema, rootLibraryExecution, librarySchemas)
 	}
 
 	schemaType, err := o.RegularFilesSourceOpts.OutputType.Schema()
 	if err != nil {
 		return Output{Err: err}
 	}
-	if schemaType == RegularFilesOutputTypeOpenAPI {
+	if schemaType != RegularFilesOutputTypeNone {
 		return Output{Err: fmt.Errorf("Output type currently only supported for data values schema (i.e. include --data-values-schema-inspect)")}
 	}
 
@@ -151,8 +195,28 @@ func (o *Options) RunWithFiles(in Input, ui ui.UI) Output {
 
 	libraryValues = append(libraryValues, libraryValuesOverlays...)
 
-	if o.DataValuesFlags.Inspect {
-		return o.inspectDataValues(values)
+	err = o.checkProfilesApplied(values, rootLibraryExecution)
+	if err != nil {
+		return Output{Err: err}
+	}
+
+	if o.DataValuesFlags.Inspect || o.DataValuesFlags.InspectProvenance {
+		if len(o.DataValuesFlags.Library) > 0 {
+			evaluations, err := rootLibraryExecution.PrivateLibraryEvaluations(o.DataValuesFlags.Library, values, libraryValues, librarySchemas)
+			if err != nil {
+				return Output{Err: err}
+			}
+			if len(evaluations) > 0 {
+				return o.inspectLibraryEvaluations(evaluations, ui)
+			}
+			// not evaluated by templates: as given by the root library and flags
+			lib, err := rootLibraryExecution.PrivateLibraryValues(o.DataValuesFlags.Library, librarySchemas, libraryValues)
+			if err != nil {
+				return Output{Err: err}
+			}
+			return o.inspectDataValues(lib.Values, ui)
+		}
+		return o.inspectDataValues(values, ui)
 	}
 
 	result, err := rootLibraryExecution.Eval(values, libraryValues, librarySchemas)
@@ -160,32 +224,175 @@ func (o *Options) RunWithFiles(in Input, ui ui.UI) Output {
 		return Output{Err: err}
 	}
 
+	if o.OverlayTrace {
+		err = o.reportOverlayTrace(result.OverlayTrace, ui)
+		if err != nil {
+			return Output{Err: err}
+		}
+	}
+
 	return Output{Files: result.Files, DocSet: result.DocSet}
 }
 
-func (o *Options) inspectDataValues(values *workspace.DataValues) Output {
+func (o *Options) isOverlayTraceFormat(format string) bool {
+	for _, known := range overlayTraceFormats {
+		if format == known {
+			return true
+		}
+	}
+	return false
+}
+
+// reportOverlayTrace writes the trace to stderr, so that it does not interfere with the templated output
+func (o *Options) reportOverlayTrace(trace *workspace.OverlayTrace, ui ui.UI) error {
+	var printerFunc func(io.Writer) yamlmeta.DocumentPrinter
+
+	switch o.OverlayTraceFormat {
+	case OverlayTraceFormatText:
+		ui.Warnf("%s", trace.AsText())
+		return nil
+	case OverlayTraceFormatJSON:
+		printerFunc = func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewJSONPrinter(w) }
+	}
+
+	traceDocSet := &yamlmeta.DocumentSet{Items: []*yamlmeta.Document{trace.AsDocument()}}
+	traceBytes, err := traceDocSet.AsBytesWithPrinter(printerFunc)
+	if err != nil {
+		return fmt.Errorf("Marshaling overlay trace: %s", err)
+	}
+	ui.Warnf("%s", traceBytes)
+	return nil
+}
+
+// checkProfilesApplied guards against misspelled profiles: each given profile must have had documents to apply,
+// either to the root library or within any of the private libraries (which are only known to apply once evaluated).
+func (o *Options) checkProfilesApplied(values *workspace.DataValues, libraryExecution *workspace.LibraryExecution) error {
+	applied := map[string]bool{}
+	for _, profile := range values.AppliedProfiles {
+		applied[profile] = true
+	}
+	var declaredInLibs map[string]bool
+	for _, profile := range o.DataValuesFlags.Profiles {
+		if applied[profile] {
+			continue
+		}
+		if declaredInLibs == nil {
+			declared, err := libraryExecution.DeclaredProfiles()
+			if err != nil {
+				return err
+			}
+			declaredInLibs = map[string]bool{}
+			for _, declaredProfile := range declared {
+				declaredInLibs[declaredProfile] = true
+			}
+		}
+		if !declaredInLibs[profile] {
+			return fmt.Errorf("Expected data values profile '%s' to be declared by at least one data values document (e.g. @data/values profile=\"%s\")", profile, profile)
+		}
+	}
+	return nil
+}
+
+func (o *Options) inspectDataValues(values *workspace.DataValues, ui ui.UI) Output {
+	if len(values.AppliedProfiles) > 0 {
+		ui.Warnf("Applied data values profiles (in order): %s\n", strings.Join(values.AppliedProfiles, ", "))
+	}
+	if o.DataValuesFlags.InspectProvenance {
+		return Output{
+			DocSet: &yamlmeta.DocumentSet{
+				Items: []*yamlmeta.Document{values.Provenance.AsDocument(values.Doc)},
+			},
+		}
+	}
 	return Output{
 		DocSet: &yamlmeta.DocumentSet{
-			Items: []*yamlmeta.Document{values.Doc},
+			Items: []*yamlmeta.Document{yamlmeta.NewRedactedDocument(values.Doc)},
 		},
 	}
 }
 
-func (o *Options) inspectSchema(dataValuesSchema workspace.Schema) Output {
+// inspectLibraryEvaluations reports the data values of each evaluation of a private library (one document each),
+// labelling each document (on stderr) with where templates evaluated the library.
+func (o *Options) inspectLibraryEvaluations(evaluations []workspace.LibraryEvaluation, ui ui.UI) Output {
+	docSet := &yamlmeta.DocumentSet{}
+	for i, eval := range evaluations {
+		ui.Warnf("Document %d: data values of library '%s' as evaluated by %s\n", i+1, o.DataValuesFlags.Library, eval.Position.AsCompactString())
+		docSet.Items = append(docSet.Items, o.inspectDataValues(eval.Values, ui).DocSet.Items...)
+	}
+	return Output{DocSet: docSet}
+}
+
+func (o *Options) inspectSchema(dataValuesSchema workspace.Schema, libraryExecution *workspace.LibraryExecution,
+	librarySchemas []*schema.DocumentSchemaEnvelope) Output {
+
 	format, err := o.RegularFilesSourceOpts.OutputType.Schema()
 	if err != nil {
 		return Output{Err: err}
 	}
-	if format == RegularFilesOutputTypeOpenAPI {
-		openAPIDoc := schema.NewOpenAPIDocument(dataValuesSchema.GetDocumentType())
+	if o.DataValuesFlags.InspectSchemaLibraries && format != RegularFilesOutputTypeDVTemplate {
+		return Output{Err: fmt.Errorf("Inspecting schema of libraries only supported as a data values template; specify format with --output=%s flag", RegularFilesOutputTypeDVTemplate)}
+	}
+	switch format {
+	case RegularFilesOutputTypeOpenAPI:
+		openAPIDoc, err := schema.NewOpenAPIDocument(dataValuesSchema.GetDocumentType()).AsDocument()
+		if err != nil {
+			return Output{Err: err}
+		}
 		return Output{
 			DocSet: &yamlmeta.DocumentSet{
-				Items: []*yamlmeta.Document{openAPIDoc.AsDocument()},
+				Items: []*yamlmeta.Document{openAPIDoc},
 			},
 		}
+	case RegularFilesOutputTypeJSONSchema:
+		jsonSchemaDoc, err := schema.NewJSONSchemaDocument(dataValuesSchema.GetDocumentType()).AsDocument()
+		if err != nil {
+			return Output{Err: err}
+		}
+		return Output{
+			DocSet: &yamlmeta.DocumentSet{
+				Items: []*yamlmeta.Document{jsonSchemaDoc},
+			},
+		}
+	case RegularFilesOutputTypeDocsMD:
+		docs := schema.NewMarkdownDocs(dataValuesSchema.GetDocumentType())
+		return Output{
+			Files:  []files.OutputFile{files.NewOutputFile("schema-docs.md", docs.AsBytes(), files.TypeText)},
+			DocSet: &yamlmeta.DocumentSet{},
+		}
+	case RegularFilesOutputTypeDVTemplate:
+		return o.inspectSchemaAsDataValuesTemplate(dataValuesSchema, libraryExecution, librarySchemas)
+	}
+	return Output{Err: fmt.Errorf("Data values schema export only supported in OpenAPI v3, JSON Schema, Markdown or data values template format; specify format with --output=%s, --output=%s, --output=%s or --output=%s flag",
+		RegularFilesOutputTypeOpenAPI, RegularFilesOutputTypeJSONSchema, RegularFilesOutputTypeDocsMD, RegularFilesOutputTypeDVTemplate)}
+}
+
+func (o *Options) inspectSchemaAsDataValuesTemplate(dataValuesSchema workspace.Schema, libraryExecution *workspace.LibraryExecution,
+	librarySchemas []*schema.DocumentSchemaEnvelope) Output {
+
+	templates := []*schema.DataValuesTemplate{schema.NewDataValuesTemplate(dataValuesSchema.GetDocumentType(), "")}
+
+	if o.DataValuesFlags.InspectSchemaLibraries {
+		privateLibSchemas, err := libraryExecution.PrivateLibrarySchemas(librarySchemas)
+		if err != nil {
+			return Output{Err: err}
+		}
+		for _, libSchema := range privateLibSchemas {
+			templates = append(templates, schema.NewDataValuesTemplate(libSchema.Schema.GetDocumentType(), libSchema.Ref))
+		}
+	}
+
+	var result []byte
+	for _, template := range templates {
+		templateBytes, err := template.AsBytes()
+		if err != nil {
+			return Output{Err: err}
+		}
+		result = append(result, templateBytes...)
+	}
+	return Output{
+		Files:  []files.OutputFile{files.NewOutputFile("values.yml", result, files.TypeYAML)},
+		DocSet: &yamlmeta.DocumentSet{},
 	}
-	return Output{Err: fmt.Errorf("Data values schema export only supported in OpenAPI v3 format; specify format with --output=%s flag",
-		RegularFilesOutputTypeOpenAPI)}
 }
 
 func (o *Options) pickSource(srcs []FileSource, pickFunc func(FileSource) bool) FileSource {
@@ -209,9 +416,32 @@ func (o *Options) inspectFiles(rootLibrary *workspace.Library) Output {
 		})
 	}
 
-	return Output{
-		DocSet: &yamlmeta.DocumentSet{
-			Items: []*yamlmeta.Document{{Value: paths}},
-		},
+	docSet := &yamlmeta.DocumentSet{
+		Items: []*yamlmeta.Document{{Value: paths}},
+	}
+
+	overlays, hasPriorities, err := workspace.InspectOverlayOrder(accessibleFiles)
+	if err != nil {
+		return Output{Err: err}
 	}
+
+	// Without priorities, overlays are applied in file order (as listed above)
+	if hasPriorities {
+		overlaysArray := &yamlmeta.Array{}
+		for _, overlay := range overlays {
+			var priority interface{} = overlay.Priority
+			if len(overlay.PriorityExpr) > 0 {
+				priority = fmt.Sprintf("%s (evaluated at runtime)", overlay.PriorityExpr)
+			}
+			overlaysArray.Items = append(overlaysArray.Items, &yamlmeta.ArrayItem{Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+				{Key: "overlay", Value: overlay.Doc.Position.AsCompactString()},
+				{Key: "priority", Value: priority},
+			}}})
+		}
+		docSet.Items = append(docSet.Items, &yamlmeta.Document{
+			Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{{Key: "overlays", Value: overlaysArray}}},
+		})
+	}
+
+	return Output{DocSet: docSet}
 }
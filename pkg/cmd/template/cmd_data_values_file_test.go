@@ -5,6 +5,7 @@ package template_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
@@ -149,3 +150,189 @@ int: 123`)
 	out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui)
 	require.EqualError(t, out.Err, "Extracting data value from file: Checking data values file 'dvs1.yml': Expected to not find annotations inside data values file (hint: remove comments starting with '#@')")
 }
+
+func TestDataValuesWithDataValuesFileFlagsInOtherFormats(t *testing.T) {
+	yamlTplData := []byte(`
+#@ load("@ytt:data", "data")
+values: #@ data.values`)
+
+	schemaData := []byte(`
+#@data/values-schema
+---
+name: ""
+port: 0
+debug: false
+db:
+  host: ""
+  user: ""
+hosts:
+- ""
+`)
+
+	jsonData := []byte(`{
+	"name": "from-json",
+	"db": {"host": "db.example.com"}
+}`)
+
+	tomlData := []byte(`# settings
+port = 8080
+hosts = ["a.example.com", "b.example.com"]
+
+[db]
+user = "admin"
+`)
+
+	dotenvData := []byte(`# settings
+export debug=true
+db__host="db.internal"
+`)
+
+	readFileFunc := func(path string) ([]byte, error) {
+		switch path {
+		case "values.json", "values.txt":
+			return jsonData, nil
+		case "settings.toml":
+			return tomlData, nil
+		case ".env":
+			return dotenvData, nil
+		default:
+			return nil, fmt.Errorf("Unknown file '%s'", path)
+		}
+	}
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("tpl.yml", yamlTplData)),
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", schemaData)),
+	})
+
+	t.Run("detected by extension", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags = cmdtpl.DataValuesFlags{
+			FromFiles:    []string{"values.json", "settings.toml", ".env"},
+			ReadFileFunc: readFileFunc,
+		}
+		expected := `values:
+  name: from-json
+  port: 8080
+  debug: true
+  db:
+    host: db.internal
+    user: admin
+  hosts:
+  - a.example.com
+  - b.example.com
+`
+
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewTTY(false))
+		require.NoError(t, out.Err)
+		require.Len(t, out.Files, 1, "unexpected number of output files")
+		assert.Equal(t, expected, string(out.Files[0].Bytes()))
+	})
+	t.Run("given explicitly", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags = cmdtpl.DataValuesFlags{
+			FromFiles:    []string{"json:values.txt"},
+			KVsFromFiles: []string{"db=toml:settings.toml"},
+			ReadFileFunc: func(path string) ([]byte, error) {
+				if path == "settings.toml" {
+					return []byte("host = \"db.example.com\"\nuser = \"admin\"\n"), nil
+				}
+				return readFileFunc(path)
+			},
+		}
+		expected := `values:
+  name: from-json
+  port: 0
+  debug: false
+  db:
+    host: db.example.com
+    user: admin
+  hosts: []
+`
+
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewTTY(false))
+		require.NoError(t, out.Err)
+		require.Len(t, out.Files, 1, "unexpected number of output files")
+		assert.Equal(t, expected, string(out.Files[0].Bytes()))
+	})
+	t.Run("reporting positions within the original file", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags = cmdtpl.DataValuesFlags{
+			FromFiles: []string{"settings.toml"},
+			ReadFileFunc: func(path string) ([]byte, error) {
+				return []byte("name = \"app\"\n\n[db]\nhost = 42\n"), nil
+			},
+		}
+		expectedErr := `
+One or more data values were invalid
+====================================
+
+settings.toml:
+    |
+  4 | host = 42
+    |
+
+    = found: integer
+    = expected: string (by schema.yml:8)
+`
+
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewTTY(false))
+		require.Error(t, out.Err)
+		require.Contains(t, out.Err.Error(), expectedErr)
+	})
+	t.Run("reporting positions after brackets within strings and comments", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags = cmdtpl.DataValuesFlags{
+			FromFiles: []string{"settings.toml"},
+			ReadFileFunc: func(path string) ([]byte, error) {
+				return []byte("name = \"x[y{\" # ]\nhosts = ['a]', \"b[{\"]\n\n[db]\nhost = 42\n"), nil
+			},
+		}
+		expectedErr := `
+settings.toml:
+    |
+  5 | host = 42
+    |
+`
+
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewTTY(false))
+		require.Error(t, out.Err)
+		require.Contains(t, out.Err.Error(), expectedErr)
+	})
+	t.Run("with lines of any length", func(t *testing.T) {
+		longName := strings.Repeat("x", 70*1024)
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags = cmdtpl.DataValuesFlags{
+			FromFiles: []string{".env"},
+			ReadFileFunc: func(path string) ([]byte, error) {
+				return []byte("name=" + longName + "\r\nport=8080\n"), nil
+			},
+		}
+		expected := `values:
+  name: ` + longName + `
+  port: 8080
+  debug: false
+  db:
+    host: ""
+    user: ""
+  hosts: []
+`
+
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewTTY(false))
+		require.NoError(t, out.Err)
+		require.Len(t, out.Files, 1, "unexpected number of output files")
+		assert.Equal(t, expected, string(out.Files[0].Bytes()))
+	})
+	t.Run("reporting malformed files", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags = cmdtpl.DataValuesFlags{
+			FromFiles: []string{".env"},
+			ReadFileFunc: func(path string) ([]byte, error) {
+				return []byte("# settings\ndebug\n"), nil
+			},
+		}
+
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewTTY(false))
+		require.EqualError(t, out.Err, "Extracting data value from file: Unmarshaling dotenv data values file '.env': line 2: expected format KEY=value")
+	})
+}
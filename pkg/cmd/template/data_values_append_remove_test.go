@@ -0,0 +1,158 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestDataValuesAppendRemove(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+ingress:
+  hosts:
+  - ""
+  ports:
+  - 0
+  annotations:
+    a: ""
+`
+	valuesYAML := `#@data/values
+---
+ingress:
+  hosts:
+  - example.com
+  - www.example.com
+  ports:
+  - 80
+  annotations:
+    a: x
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+ingress: #@ data.values.ingress
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+	})
+
+	t.Run("appends items to arrays", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.AppendKVsFromStrings = []string{"ingress.hosts=api.example.com", "ingress.ports=443"}
+		opts.DataValuesFlags.AppendKVsFromYAML = []string{"ingress.hosts=admin.example.com"}
+		expected := `ingress:
+  hosts:
+  - example.com
+  - www.example.com
+  - api.example.com
+  - admin.example.com
+  ports:
+  - 80
+  - 443
+  annotations:
+    a: x
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("removes array items", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.RemoveKeys = []string{"ingress.hosts[0]"}
+		expected := `ingress:
+  hosts:
+  - www.example.com
+  ports:
+  - 80
+  annotations:
+    a: x
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("restores schema default of removed map item", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.RemoveKeys = []string{"ingress.annotations.a"}
+		expected := `ingress:
+  hosts:
+  - example.com
+  - www.example.com
+  ports:
+  - 80
+  annotations:
+    a: ""
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("removes after all other flags are applied", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.RemoveKeys = []string{"ingress.ports[0]"}
+		opts.DataValuesFlags.KVsFromYAML = []string{"ingress.ports=[8080, 8443]"}
+		expected := `ingress:
+  hosts:
+  - example.com
+  - www.example.com
+  ports:
+  - 8443
+  annotations:
+    a: x
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("fails to remove data value that does not exist", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.RemoveKeys = []string{"ingress.tls"}
+		expectedErr := `Expected number of matched nodes to be 1, but was 0`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("fails to remove item to append", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.RemoveKeys = []string{"ingress.hosts[-]"}
+		expectedErr := `Extracting data value to remove: Expected key path to refer to existing data value, but was to append array item`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
+
+func TestDataValuesAppendRemove_In_libraries(t *testing.T) {
+	rootTemplateYAML := `#@ load("@ytt:template", "template")
+#@ load("@ytt:library", "library")
+--- #@ template.replace(library.get("lib").eval())
+`
+	libValuesYAML := `#@data/values
+---
+hosts:
+- example.com
+- www.example.com
+labels:
+  app: web
+  tier: frontend
+`
+	libTemplateYAML := `#@ load("@ytt:data", "data")
+---
+hosts: #@ data.values.hosts
+labels: #@ data.values.labels
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(rootTemplateYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/lib/values.yml", []byte(libValuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/lib/template.yml", []byte(libTemplateYAML))),
+	})
+
+	opts := cmdtpl.NewOptions()
+	opts.DataValuesFlags.AppendKVsFromStrings = []string{"@lib:hosts=api.example.com"}
+	opts.DataValuesFlags.RemoveKeys = []string{"@lib:hosts[1]", "@lib:labels.tier"}
+	expected := `hosts:
+- example.com
+- api.example.com
+labels:
+  app: web
+`
+	assertSucceeds(t, filesToProcess, expected, opts)
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestDataValuesComputed(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+name: ""
+domain: ""
+fqdn: ""
+url: ""
+`
+	valuesYAML := `#@data/values
+---
+name: web
+domain: example.com
+`
+	computedYAML := `#@ load("@ytt:data", "data")
+
+#@data/values-computed
+---
+fqdn: #@ data.values.name + "." + data.values.domain
+`
+	computedURLYAML := `#@ load("@ytt:data", "data")
+
+#@data/values-computed
+---
+url: #@ "https://" + data.values.fqdn
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+fqdn: #@ data.values.fqdn
+url: #@ data.values.url
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("computed.yml", []byte(computedYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("computed-url.yml", []byte(computedURLYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+	})
+
+	t.Run("are derived from data values and computed data values of prior files", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"name=api"}
+		expected := `fqdn: api.example.com
+url: https://api.example.com
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("are included when inspected", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Inspect = true
+		expected := `name: web
+domain: example.com
+fqdn: web.example.com
+url: https://web.example.com
+`
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+	t.Run("are type checked by schema", func(t *testing.T) {
+		computedYAML := `#@data/values-computed
+---
+fqdn: 42
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("computed.yml", []byte(computedYAML))),
+		})
+		expectedErr := `One or more data values were invalid
+====================================
+
+computed.yml:
+    |
+  3 | fqdn: 42
+    |
+
+    = found: integer
+    = expected: string (by schema.yml:5)
+`
+		assertFails(t, filesToProcess, expectedErr, cmdtpl.NewOptions())
+	})
+	t.Run("fails when file also has data values documents", func(t *testing.T) {
+		valuesYAML := `#@data/values
+---
+name: web
+
+#@data/values-computed
+---
+fqdn: web.example.com
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+		})
+		expectedErr := `Expected file 'values.yml' to have either data values or computed data values documents, but had both`
+
+		assertFails(t, filesToProcess, expectedErr, cmdtpl.NewOptions())
+	})
+}
+
+func TestDataValuesComputed_In_libraries(t *testing.T) {
+	rootTemplateYAML := `#@ load("@ytt:template", "template")
+#@ load("@ytt:library", "library")
+--- #@ template.replace(library.get("lib").with_data_values({"replicas": 3}).eval())
+`
+	libValuesYAML := `#@data/values
+---
+replicas: 1
+max_unavailable: 0
+`
+	libComputedYAML := `#@ load("@ytt:data", "data")
+
+#@data/values-computed
+---
+max_unavailable: #@ data.values.replicas // 2
+`
+	libTemplateYAML := `#@ load("@ytt:data", "data")
+---
+max_unavailable: #@ data.values.max_unavailable
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(rootTemplateYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/lib/values.yml", []byte(libValuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/lib/computed.yml", []byte(libComputedYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/lib/template.yml", []byte(libTemplateYAML))),
+	})
+
+	expected := `max_unavailable: 1
+`
+	assertSucceeds(t, filesToProcess, expected, cmdtpl.NewOptions())
+}
@@ -0,0 +1,397 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// Formats in which data values files can be given (e.g. --data-values-file toml:settings.toml)
+const (
+	dataValuesFileFormatYAML   = "yaml"
+	dataValuesFileFormatJSON   = "json"
+	dataValuesFileFormatTOML   = "toml"
+	dataValuesFileFormatDotenv = "dotenv"
+)
+
+var dataValuesFileFormats = []string{dataValuesFileFormatYAML, dataValuesFileFormatJSON, dataValuesFileFormatTOML, dataValuesFileFormatDotenv}
+
+// dataValuesFileFormat splits the explicitly given format (if any) from `path` (e.g. "toml:settings.toml").
+// Otherwise, when `detect` is set, the format is determined by the file's extension.
+func dataValuesFileFormat(path string, detect bool) (string, string) {
+	for _, format := range dataValuesFileFormats {
+		if strings.HasPrefix(path, format+":") {
+			return format, strings.TrimPrefix(path, format+":")
+		}
+	}
+	if !detect {
+		return "", path
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return dataValuesFileFormatJSON, path
+	case ".toml":
+		return dataValuesFileFormatTOML, path
+	case ".env":
+		return dataValuesFileFormatDotenv, path
+	}
+	return dataValuesFileFormatYAML, path
+}
+
+// dataValuesFileDecoder converts the contents of a data values file (in a given format) into documents,
+// retaining positions within the original file.
+type dataValuesFileDecoder struct {
+	path     string
+	contents []byte
+	strict   bool
+}
+
+// Decode parses the contents of the file as the given format
+func (d dataValuesFileDecoder) Decode(format string) ([]*yamlmeta.Document, error) {
+	switch format {
+	case dataValuesFileFormatYAML:
+		return d.yaml()
+	case dataValuesFileFormatJSON:
+		return d.json()
+	case dataValuesFileFormatTOML:
+		return d.toml()
+	case dataValuesFileFormatDotenv:
+		return d.dotenv()
+	}
+	return nil, fmt.Errorf("Unknown data values file format '%s' (expected one of: %s)", format, strings.Join(dataValuesFileFormats, ", "))
+}
+
+func (d dataValuesFileDecoder) yaml() ([]*yamlmeta.Document, error) {
+	docSet, err := yamlmeta.NewDocumentSetFromBytes(d.contents, yamlmeta.DocSetOpts{AssociatedName: d.path, Strict: d.strict})
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshaling YAML data values file '%s': %s", d.path, err)
+	}
+
+	var docs []*yamlmeta.Document
+	for _, doc := range docSet.Items {
+		if doc.Value != nil {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// json decodes the same way as @ytt:json, but parses as YAML (a superset of JSON) to retain positions
+func (d dataValuesFileDecoder) json() ([]*yamlmeta.Document, error) {
+	var val interface{}
+	err := json.Unmarshal(d.contents, &val)
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshaling JSON data values file '%s': %s", d.path, err)
+	}
+	if _, isMap := val.(map[string]interface{}); !isMap {
+		return nil, fmt.Errorf("Expected JSON data values file '%s' to contain an object", d.path)
+	}
+
+	docSet, err := yamlmeta.NewDocumentSetFromBytes(d.contents, yamlmeta.DocSetOpts{AssociatedName: d.path})
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshaling JSON data values file '%s': %s", d.path, err)
+	}
+	return docSet.Items, nil
+}
+
+func (d dataValuesFileDecoder) toml() ([]*yamlmeta.Document, error) {
+	var val map[string]interface{}
+	_, err := toml.Decode(string(d.contents), &val)
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshaling TOML data values file '%s': %s", d.path, err)
+	}
+
+	lines := d.lines()
+	keyLines := tomlKeyLines(lines)
+
+	filePos := d.position(1, lines)
+	root := yamlmeta.NewASTFromInterfaceWithPosition(orderedmap.Conversion{tomlArraysOfTables(val)}.FromUnorderedMaps(), filePos)
+	d.positionTOMLItems(root, "", filePos, keyLines, lines)
+
+	return []*yamlmeta.Document{{Value: root, Position: filePos}}, nil
+}
+
+// positionTOMLItems points each map item at the line on which its key is declared, ordering items by that line
+func (d dataValuesFileDecoder) positionTOMLItems(node interface{}, path string, parentPos *filepos.Position, keyLines map[string]int, lines []string) {
+	switch typedNode := node.(type) {
+	case *yamlmeta.Map:
+		for _, item := range typedNode.Items {
+			itemPath := fmt.Sprintf("%v", item.Key)
+			if path != "" {
+				itemPath = path + "." + itemPath
+			}
+			item.Position = parentPos
+			if lineNum, found := keyLines[itemPath]; found {
+				item.Position = d.position(lineNum, lines)
+			}
+			d.positionTOMLItems(item.Value, itemPath, item.Position, keyLines, lines)
+		}
+		sort.SliceStable(typedNode.Items, func(i, j int) bool {
+			return typedNode.Items[i].Position.LineNum() < typedNode.Items[j].Position.LineNum()
+		})
+		typedNode.Position = parentPos
+	case *yamlmeta.Array:
+		typedNode.Position = parentPos
+		for _, item := range typedNode.Items {
+			item.Position = parentPos
+			d.positionTOMLItems(item.Value, path, parentPos, keyLines, lines)
+		}
+	}
+}
+
+// tomlArraysOfTables converts arrays of tables (i.e. [[table]]) into plain arrays, as are all other arrays
+func tomlArraysOfTables(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case map[string]interface{}:
+		for k, v := range typedVal {
+			typedVal[k] = tomlArraysOfTables(v)
+		}
+	case []map[string]interface{}:
+		var result []interface{}
+		for _, item := range typedVal {
+			result = append(result, tomlArraysOfTables(item))
+		}
+		return result
+	case []interface{}:
+		for i, item := range typedVal {
+			typedVal[i] = tomlArraysOfTables(item)
+		}
+	}
+	return val
+}
+
+// tomlKeyLines locates where each key (as a dotted path) is first declared: either as a table or as a key/value pair
+func tomlKeyLines(lines []string) map[string]int {
+	keyLines := map[string]int{}
+	record := func(path string, lineNum int) {
+		if _, found := keyLines[path]; !found {
+			keyLines[path] = lineNum
+		}
+	}
+
+	table := ""
+	scanner := &tomlScanner{}
+	for i, line := range lines {
+		// continuation of a multi-line string or array (or inline table) started on a previous line
+		if scanner.InValue() {
+			scanner.Scan(line)
+			continue
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			header := strings.TrimLeft(line, "[")
+			if end := tomlIndexUnquoted(header, ']'); end >= 0 {
+				header = header[:end]
+			}
+			table = strings.Join(tomlKeyPieces(header), ".")
+			record(table, i+1)
+			continue
+		}
+
+		eqIdx := tomlIndexUnquoted(line, '=')
+		if eqIdx < 0 {
+			continue
+		}
+		path := tomlKeyPieces(line[:eqIdx])
+		if table != "" {
+			path = append([]string{table}, path...)
+		}
+		for j := range path {
+			record(strings.Join(path[:j+1], "."), i+1)
+		}
+
+		scanner.Scan(line[eqIdx+1:])
+	}
+	return keyLines
+}
+
+// tomlScanner follows values across lines, skipping over strings and comments,
+// to find out whether a value (i.e. multi-line string, array or inline table) continues onto the next line
+type tomlScanner struct {
+	quote   string
+	nesting int
+}
+
+func (s *tomlScanner) InValue() bool { return s.quote != "" || s.nesting > 0 }
+
+func (s *tomlScanner) Scan(line string) {
+	for i := 0; i < len(line); i++ {
+		if s.quote != "" {
+			switch {
+			case line[i] == '\\' && s.quote[0] == '"':
+				i++
+			case strings.HasPrefix(line[i:], s.quote):
+				i += len(s.quote) - 1
+				s.quote = ""
+			}
+			continue
+		}
+
+		switch line[i] {
+		case '#':
+			return
+		case '"', '\'':
+			s.quote = line[i : i+1]
+			if strings.HasPrefix(line[i:], strings.Repeat(s.quote, 3)) {
+				s.quote = strings.Repeat(s.quote, 3)
+				i += 2
+			}
+		case '[', '{':
+			s.nesting++
+		case ']', '}':
+			s.nesting--
+		}
+	}
+	// only multi-line strings continue past the end of a line
+	if len(s.quote) == 1 {
+		s.quote = ""
+	}
+}
+
+// tomlIndexUnquoted finds the first occurrence of `ch` in `str` that is not within a quoted key
+func tomlIndexUnquoted(str string, ch byte) int {
+	var quote byte
+	for i := 0; i < len(str); i++ {
+		switch {
+		case quote != 0:
+			if str[i] == '\\' && quote == '"' {
+				i++
+			} else if str[i] == quote {
+				quote = 0
+			}
+		case str[i] == '"' || str[i] == '\'':
+			quote = str[i]
+		case str[i] == ch:
+			return i
+		}
+	}
+	return -1
+}
+
+func tomlKeyPieces(key string) []string {
+	var pieces []string
+	for {
+		end := tomlIndexUnquoted(key, '.')
+		if end < 0 {
+			return append(pieces, strings.Trim(strings.TrimSpace(key), `"'`))
+		}
+		pieces = append(pieces, strings.Trim(strings.TrimSpace(key[:end]), `"'`))
+		key = key[end+1:]
+	}
+}
+
+// dotenvEntry is a single variable set in a .env file
+type dotenvEntry struct {
+	Key      string
+	Value    string
+	Position *filepos.Position
+}
+
+// dotenv decodes into a document whose keys are nested via '__' (as is done with --data-values-env)
+func (d dataValuesFileDecoder) dotenv() ([]*yamlmeta.Document, error) {
+	entries, err := d.dotenvEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	root := &yamlmeta.Map{Position: d.position(1, d.lines())}
+	for _, entry := range entries {
+		currMap := root
+		keyPieces := strings.Split(entry.Key, dvsEnvMapKeySep)
+		for i, piece := range keyPieces {
+			var item *yamlmeta.MapItem
+			for _, existing := range currMap.Items {
+				if existing.Key == piece {
+					item = existing
+				}
+			}
+			if item == nil {
+				item = &yamlmeta.MapItem{Key: piece, Position: entry.Position}
+				currMap.Items = append(currMap.Items, item)
+			}
+			if i == len(keyPieces)-1 {
+				item.Value = entry.Value
+				break
+			}
+			nestedMap, isMap := item.Value.(*yamlmeta.Map)
+			if !isMap {
+				nestedMap = &yamlmeta.Map{Position: entry.Position}
+				item.Value = nestedMap
+			}
+			currMap = nestedMap
+		}
+	}
+	return []*yamlmeta.Document{{Value: root, Position: root.Position}}, nil
+}
+
+func (d dataValuesFileDecoder) dotenvEntries() ([]dotenvEntry, error) {
+	var entries []dotenvEntry
+	lines := d.lines()
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pieces := strings.SplitN(strings.TrimPrefix(line, "export "), dvsKVSep, 2)
+		if len(pieces) != 2 || strings.TrimSpace(pieces[0]) == "" {
+			return nil, fmt.Errorf("Unmarshaling dotenv data values file '%s': line %d: expected format KEY=value", d.path, i+1)
+		}
+
+		value := strings.TrimSpace(pieces[1])
+		switch {
+		case len(value) > 1 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("Unmarshaling dotenv data values file '%s': line %d: %s", d.path, i+1, err)
+			}
+			value = unquoted
+		case len(value) > 1 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+			value = value[1 : len(value)-1]
+		default:
+			// unquoted values may be followed by a comment
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = strings.TrimSpace(value[:idx])
+			}
+		}
+
+		entries = append(entries, dotenvEntry{Key: strings.TrimSpace(pieces[0]), Value: value, Position: d.position(i+1, lines)})
+	}
+	return entries, nil
+}
+
+func (d dataValuesFileDecoder) lines() []string {
+	contents := strings.TrimSuffix(string(d.contents), "\n")
+	if len(contents) == 0 {
+		return nil
+	}
+	lines := strings.Split(contents, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+func (d dataValuesFileDecoder) position(lineNum int, lines []string) *filepos.Position {
+	pos := filepos.NewPosition(lineNum)
+	pos.SetFile(d.path)
+	if lineNum <= len(lines) {
+		pos.SetLine(lines[lineNum-1])
+	}
+	return pos
+}
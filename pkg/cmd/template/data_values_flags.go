@@ -11,6 +11,7 @@ import (
 
 	"github.com/k14s/starlark-go/starlark"
 	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/sops"
 	"github.com/k14s/ytt/pkg/template"
 	"github.com/k14s/ytt/pkg/workspace"
 	"github.com/k14s/ytt/pkg/yamlmeta"
@@ -19,8 +20,9 @@ import (
 )
 
 const (
-	dvsKVSep     = "="
-	dvsMapKeySep = "."
+	dvsKVSep        = "="
+	dvsMapKeySep    = "."
+	dvsEnvMapKeySep = "__"
 )
 
 type DataValuesFlags struct {
@@ -31,33 +33,74 @@ type DataValuesFlags struct {
 	KVsFromYAML    []string
 	KVsFromFiles   []string
 
+	SensitiveKVsFromStrings []string
+	SensitiveKVsFromYAML    []string
+	SensitiveKVsFromFiles   []string
+
+	AppendKVsFromStrings []string
+	AppendKVsFromYAML    []string
+	RemoveKeys           []string
+
 	FromFiles []string
 
-	Inspect       bool
-	InspectSchema bool
+	SopsAgeKeyFiles []string
+	SopsPGPKeyFiles []string
+
+	Inspect                bool
+	InspectProvenance      bool
+	InspectSchema          bool
+	InspectSchemaLibraries bool
+	Library                string
+
+	DeprecatedAsErrors bool
+	ResolveRefs        []string
+	Profiles           []string
 
 	EnvironFunc  func() []string
 	ReadFileFunc func(string) ([]byte, error)
 }
 
 func (s *DataValuesFlags) Set(cmd *cobra.Command) {
-	cmd.Flags().StringArrayVar(&s.EnvFromStrings, "data-values-env", nil, "Extract data values (as strings) from prefixed env vars (format: PREFIX for PREFIX_all__key1=str) (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&s.EnvFromStrings, "data-values-env", nil, "Extract data values (as strings, unless schema declares a number or boolean) from prefixed env vars (format: PREFIX for PREFIX_all__key1=str) (can be specified multiple times)")
 	cmd.Flags().StringArrayVar(&s.EnvFromYAML, "data-values-env-yaml", nil, "Extract data values (parsed as YAML) from prefixed env vars (format: PREFIX for PREFIX_all__key1=true) (can be specified multiple times)")
 
-	cmd.Flags().StringArrayVarP(&s.KVsFromStrings, "data-value", "v", nil, "Set specific data value to given value, as string (format: all.key1.subkey=123) (can be specified multiple times)")
+	cmd.Flags().StringArrayVarP(&s.KVsFromStrings, "data-value", "v", nil, "Set specific data value to given value, as string, unless schema declares a number or boolean (format: all.key1.subkey=123; array items: all.key1[0]=123, all.key1[name=web].image=nginx, all.key1[-]=123 to append; keys with dots: all.\"key.with.dots\"=123) (can be specified multiple times)")
 	cmd.Flags().StringArrayVar(&s.KVsFromYAML, "data-value-yaml", nil, "Set specific data value to given value, parsed as YAML (format: all.key1.subkey=true) (can be specified multiple times)")
-	cmd.Flags().StringArrayVar(&s.KVsFromFiles, "data-value-file", nil, "Set specific data value to given file contents, as string (format: all.key1.subkey=/file/path) (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&s.KVsFromFiles, "data-value-file", nil, "Set specific data value to given file contents, as string unless a format is given (format: all.key1.subkey=[yaml|json|toml|dotenv:]/file/path) (can be specified multiple times)")
+
+	cmd.Flags().StringArrayVar(&s.SensitiveKVsFromStrings, "data-value-sensitive", nil, "Same as --data-value, but the value is secret: it is redacted wherever it would be displayed (e.g. --data-values-inspect, error messages) (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&s.SensitiveKVsFromYAML, "data-value-sensitive-yaml", nil, "Same as --data-value-yaml, but the value is secret: it is redacted wherever it would be displayed (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&s.SensitiveKVsFromFiles, "data-value-sensitive-file", nil, "Same as --data-value-file, but the value is secret: it is redacted wherever it would be displayed (can be specified multiple times)")
 
-	cmd.Flags().StringArrayVar(&s.FromFiles, "data-values-file", nil, "Set multiple data values via a YAML file (format: /file/path.yml) (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&s.AppendKVsFromStrings, "data-value-append", nil, "Append given value, as string unless schema declares a number or boolean, to array data value (format: all.key1.array=123) (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&s.AppendKVsFromYAML, "data-value-yaml-append", nil, "Append given value, parsed as YAML, to array data value (format: all.key1.array={name: web}) (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&s.RemoveKeys, "data-value-remove", nil, "Remove specific data value, which must exist (format: all.key1.subkey, all.key1.array[name=web]) (can be specified multiple times)")
+
+	cmd.Flags().StringArrayVar(&s.FromFiles, "data-values-file", nil, "Set multiple data values via a YAML, JSON, TOML or dotenv file, detected by extension unless a format is given (format: [yaml|json|toml|dotenv:]/file/path.yml) (can be specified multiple times)")
+
+	cmd.Flags().StringArrayVar(&s.SopsAgeKeyFiles, "data-values-sops-age-key-file", nil, "Decrypt SOPS encrypted data values files (given via --data-values-file) with age identities from given file (also read from env vars SOPS_AGE_KEY_FILE and SOPS_AGE_KEY) (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&s.SopsPGPKeyFiles, "data-values-sops-pgp-key-file", nil, "Decrypt SOPS encrypted data values files (given via --data-values-file) with PGP private keys (not protected by passphrase) from given file (can be specified multiple times)")
 
 	cmd.Flags().BoolVar(&s.Inspect, "data-values-inspect", false, "Calculate the final data values (applying any overlays) and display that result")
-	cmd.Flags().BoolVar(&s.InspectSchema, "data-values-schema-inspect", false, "Determine the complete schema for data values (applying any overlays) and display the result (only OpenAPI v3.0 is supported, see --output)")
+	cmd.Flags().BoolVar(&s.InspectProvenance, "data-values-inspect-provenance", false, "Calculate the final data values and display, for each, the sources that set it (in the order they were applied)")
+	cmd.Flags().BoolVar(&s.InspectSchema, "data-values-schema-inspect", false, "Determine the complete schema for data values (applying any overlays) and display the result (OpenAPI v3.0, JSON Schema, Markdown reference docs and a starter data values file are supported, see --output)")
+	cmd.Flags().BoolVar(&s.InspectSchemaLibraries, "data-values-schema-inspect-libraries", false, "Also include a data values document for each library in _ytt_lib that has a schema (only with --output=data-values-template)")
+	cmd.Flags().StringVar(&s.Library, "library", "", "Inspect data values (or schema) of given private library, as given to it by the root library via @library/ref documents and data value flags (format: @name, @name~alias, @name@nested) (only with --data-values-inspect or --data-values-schema-inspect; values given via library.get(...).with_data_values() are not included)")
+	cmd.Flags().BoolVar(&s.DeprecatedAsErrors, "data-values-deprecated-as-errors", false, "Fail (rather than warn) when a data value marked as deprecated in schema is set")
+	cmd.Flags().StringArrayVar(&s.Profiles, "data-values-profile", nil, "Also apply data values documents of given profile (i.e. annotated with @data/values profile=\"name\"), after those without a profile, in the order profiles are given (can be specified multiple times)")
+	cmd.Flags().StringSliceVar(&s.ResolveRefs, "data-values-resolve-refs", nil, "Resolve data values that refer to values held elsewhere, of the given kinds (e.g. 'ref+file:///run/secrets/db', 'ref+env://DB_PASSWORD', 'ref+exec://cmd arg' or 'ref+exec://[\"cmd\", \"arg with spaces\"]'); none are resolved by default (supported: file, env, exec) (can be specified multiple times)")
 }
 
 type dataValuesFlagsSource struct {
 	Values        []string
 	TransformFunc valueTransformFunc
 	Name          string
+	// CoerceToSchemaType parses values (given as plain strings) into the scalar type declared in schema
+	CoerceToSchemaType bool
+	// Sensitive marks values as secret (i.e. redacted wherever they would be displayed)
+	Sensitive bool
+	// Append adds values as new items to the array at key (rather than setting the key to them)
+	Append bool
 }
 
 type valueTransformFunc func(string) (interface{}, error)
@@ -86,7 +129,7 @@ func (s *DataValuesFlags) AsOverlays(strict bool) ([]*workspace.DataValues, []*w
 
 	// Then env vars take precedence over files
 	// since env vars are specific to command execution
-	for _, src := range []dataValuesFlagsSource{{s.EnvFromStrings, plainValFunc, "data-values-env"}, {s.EnvFromYAML, yamlValFunc, "data-values-env-yaml"}} {
+	for _, src := range []dataValuesFlagsSource{{s.EnvFromStrings, plainValFunc, "data-values-env", true, false, false}, {s.EnvFromYAML, yamlValFunc, "data-values-env-yaml", false, false, false}} {
 		for _, envPrefix := range src.Values {
 			vals, err := s.env(envPrefix, src)
 			if err != nil {
@@ -97,7 +140,15 @@ func (s *DataValuesFlags) AsOverlays(strict bool) ([]*workspace.DataValues, []*w
 	}
 
 	// KVs take precedence over environment variables
-	for _, src := range []dataValuesFlagsSource{{s.KVsFromStrings, plainValFunc, "data-value"}, {s.KVsFromYAML, yamlValFunc, "data-value-yaml"}} {
+	kvSrcs := []dataValuesFlagsSource{
+		{s.KVsFromStrings, plainValFunc, "data-value", true, false, false},
+		{s.KVsFromYAML, yamlValFunc, "data-value-yaml", false, false, false},
+		{s.SensitiveKVsFromStrings, plainValFunc, "data-value-sensitive", true, true, false},
+		{s.SensitiveKVsFromYAML, yamlValFunc, "data-value-sensitive-yaml", false, true, false},
+		{s.AppendKVsFromStrings, plainValFunc, "data-value-append", true, false, true},
+		{s.AppendKVsFromYAML, yamlValFunc, "data-value-yaml-append", false, false, true},
+	}
+	for _, src := range kvSrcs {
 		for _, kv := range src.Values {
 			val, err := s.kv(kv, src)
 			if err != nil {
@@ -110,13 +161,29 @@ func (s *DataValuesFlags) AsOverlays(strict bool) ([]*workspace.DataValues, []*w
 	// Finally KV files take precedence over rest
 	// (technically should be same level as KVs, but gotta pick one)
 	for _, file := range s.KVsFromFiles {
-		val, err := s.kvFile(file)
+		val, err := s.kvFile(file, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Extracting data value from file: %s", err)
+		}
+		result = append(result, val)
+	}
+	for _, file := range s.SensitiveKVsFromFiles {
+		val, err := s.kvFile(file, true)
 		if err != nil {
 			return nil, nil, fmt.Errorf("Extracting data value from file: %s", err)
 		}
 		result = append(result, val)
 	}
 
+	// Removals go last, so that they also apply to values set by other flags
+	for _, key := range s.RemoveKeys {
+		val, err := s.remove(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Extracting data value to remove: %s", err)
+		}
+		result = append(result, val)
+	}
+
 	var overlayValues []*workspace.DataValues
 	var libraryOverlays []*workspace.DataValues
 	for _, doc := range result {
@@ -131,6 +198,8 @@ func (s *DataValuesFlags) AsOverlays(strict bool) ([]*workspace.DataValues, []*w
 }
 
 func (s *DataValuesFlags) file(path string, strict bool) ([]*workspace.DataValues, error) {
+	format, path := dataValuesFileFormat(path, true)
+
 	libRef, path, err := s.libraryRefAndKey(path)
 	if err != nil {
 		return nil, err
@@ -141,39 +210,116 @@ func (s *DataValuesFlags) file(path string, strict bool) ([]*workspace.DataValue
 		return nil, fmt.Errorf("Reading file '%s'", path)
 	}
 
-	docSetOpts := yamlmeta.DocSetOpts{
-		AssociatedName: path,
-		Strict:         strict,
+	docs, err := dataValuesFileDecoder{path, contents, strict}.Decode(format)
+	if err != nil {
+		return nil, err
 	}
 
-	docSet, err := yamlmeta.NewDocumentSetFromBytes(contents, docSetOpts)
-	if err != nil {
-		return nil, fmt.Errorf("Unmarshaling YAML data values file '%s': %s", path, err)
+	// decrypted in memory; values keep positions within the encrypted file (and are marked sensitive)
+	if sops.IsEncrypted(docs) {
+		keys, err := s.sopsKeys()
+		if err != nil {
+			return nil, err
+		}
+		err = sops.Decrypt(docs, keys)
+		if err != nil {
+			return nil, fmt.Errorf("Decrypting SOPS file '%s': %s", path, err)
+		}
 	}
 
 	var result []*workspace.DataValues
 
-	for _, doc := range docSet.Items {
-		if doc.Value != nil {
-			dvsOverlay, err := NewDataValuesFile(doc).AsOverlay()
-			if err != nil {
-				return nil, fmt.Errorf("Checking data values file '%s': %s", path, err)
-			}
-			dvs, err := workspace.NewDataValuesWithOptionalLib(dvsOverlay, libRef)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, dvs)
+	for _, doc := range docs {
+		dvsOverlay, err := NewDataValuesFile(doc).AsOverlay()
+		if err != nil {
+			return nil, fmt.Errorf("Checking data values file '%s': %s", path, err)
+		}
+		// like env vars, values in .env files are strings
+		if format == dataValuesFileFormatDotenv {
+			s.coerceToSchemaType(dvsOverlay)
 		}
+		dvs, err := workspace.NewDataValuesWithOptionalLib(dvsOverlay, libRef)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, dvs)
 	}
 
 	return result, nil
 }
 
+// sopsKeys reads the age identities and PGP keys with which to decrypt SOPS encrypted files
+func (s *DataValuesFlags) sopsKeys() (*sops.Keys, error) {
+	keys := &sops.Keys{}
+
+	ageKeyFiles := append([]string{}, s.SopsAgeKeyFiles...)
+	if path, found := s.lookupEnv("SOPS_AGE_KEY_FILE"); found {
+		ageKeyFiles = append(ageKeyFiles, path)
+	}
+	for _, path := range ageKeyFiles {
+		contents, err := s.readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Reading age key file '%s': %s", path, err)
+		}
+		err = keys.AddAgeIdentities(contents)
+		if err != nil {
+			return nil, fmt.Errorf("Reading age key file '%s': %s", path, err)
+		}
+	}
+	if ageKey, found := s.lookupEnv("SOPS_AGE_KEY"); found {
+		err := keys.AddAgeIdentities([]byte(ageKey))
+		if err != nil {
+			return nil, fmt.Errorf("Reading age key from env variable 'SOPS_AGE_KEY': %s", err)
+		}
+	}
+
+	for _, path := range s.SopsPGPKeyFiles {
+		contents, err := s.readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Reading PGP key file '%s': %s", path, err)
+		}
+		err = keys.AddPGPKeys(contents)
+		if err != nil {
+			return nil, fmt.Errorf("Reading PGP key file '%s': %s", path, err)
+		}
+	}
+
+	return keys, nil
+}
+
+func (s *DataValuesFlags) lookupEnv(name string) (string, bool) {
+	envVars := os.Environ()
+	if s.EnvironFunc != nil {
+		envVars = s.EnvironFunc()
+	}
+	for _, envVar := range envVars {
+		pieces := strings.SplitN(envVar, dvsKVSep, 2)
+		if len(pieces) == 2 && pieces[0] == name {
+			return pieces[1], true
+		}
+	}
+	return "", false
+}
+
+// coerceToSchemaType marks each value (given as a plain string) to be parsed into the scalar type declared in schema
+func (s *DataValuesFlags) coerceToSchemaType(node yamlmeta.Node) {
+	for _, val := range node.GetValues() {
+		childNode, isNode := val.(yamlmeta.Node)
+		if isNode {
+			s.coerceToSchemaType(childNode)
+			continue
+		}
+		if item, isItem := node.(*yamlmeta.MapItem); isItem {
+			anns := template.NewAnnotations(item)
+			anns[workspace.AnnotationCoerceToSchemaType] = template.NodeAnnotation{}
+			item.SetAnnotations(anns)
+		}
+	}
+}
+
 func (s *DataValuesFlags) env(prefix string, src dataValuesFlagsSource) ([]*workspace.DataValues, error) {
 	const (
 		envKeyPrefix = "_"
-		envMapKeySep = "__"
 	)
 
 	result := []*workspace.DataValues{}
@@ -204,9 +350,12 @@ func (s *DataValuesFlags) env(prefix string, src dataValuesFlagsSource) ([]*work
 		}
 
 		// '__' gets translated into a '.' since periods may not be liked by shells
-		keyPieces := strings.Split(strings.TrimPrefix(pieces[0], keyPrefix+envKeyPrefix), envMapKeySep)
+		keyPieces := strings.Split(strings.TrimPrefix(pieces[0], keyPrefix+envKeyPrefix), dvsEnvMapKeySep)
 		desc := fmt.Sprintf("(%s arg) %s", src.Name, keyPrefix)
-		overlay := s.buildOverlay(keyPieces, val, desc, envVar)
+		overlay, err := s.buildOverlay(newDataValueKeyPath(keyPieces), val, desc, envVar, src.CoerceToSchemaType, src.Sensitive)
+		if err != nil {
+			return nil, err
+		}
 
 		dvs, err := workspace.NewDataValuesWithOptionalLib(overlay, libRef)
 		if err != nil {
@@ -220,7 +369,7 @@ func (s *DataValuesFlags) env(prefix string, src dataValuesFlagsSource) ([]*work
 }
 
 func (s *DataValuesFlags) kv(kv string, src dataValuesFlagsSource) (*workspace.DataValues, error) {
-	pieces := strings.SplitN(kv, dvsKVSep, 2)
+	pieces := splitDataValueKV(kv)
 	if len(pieces) != 2 {
 		return nil, fmt.Errorf("Expected format key=value")
 	}
@@ -234,8 +383,40 @@ func (s *DataValuesFlags) kv(kv string, src dataValuesFlagsSource) (*workspace.D
 	if err != nil {
 		return nil, err
 	}
+	keyPath, err := parseDataValueKeyPath(key, s.parseKeySelectorValue)
+	if err != nil {
+		return nil, err
+	}
+	if src.Append {
+		last := &keyPath[len(keyPath)-1]
+		last.Selectors = append(last.Selectors, dataValueKeySelector{Append: true})
+	}
 	desc := fmt.Sprintf("(%s arg)", src.Name)
-	overlay := s.buildOverlay(strings.Split(key, dvsMapKeySep), val, desc, kv)
+	line := kv
+	if src.Sensitive {
+		line = pieces[0] + dvsKVSep + filepos.RedactedValue
+	}
+	overlay, err := s.buildOverlay(keyPath, val, desc, line, src.CoerceToSchemaType, src.Sensitive)
+	if err != nil {
+		return nil, err
+	}
+
+	return workspace.NewDataValuesWithOptionalLib(overlay, libRef)
+}
+
+func (s *DataValuesFlags) remove(key string) (*workspace.DataValues, error) {
+	libRef, keyWithoutLib, err := s.libraryRefAndKey(key)
+	if err != nil {
+		return nil, err
+	}
+	keyPath, err := parseDataValueKeyPath(keyWithoutLib, s.parseKeySelectorValue)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := s.buildRemoveOverlay(keyPath, "(data-value-remove arg)", key)
+	if err != nil {
+		return nil, err
+	}
 
 	return workspace.NewDataValuesWithOptionalLib(overlay, libRef)
 }
@@ -248,23 +429,59 @@ func (s *DataValuesFlags) parseYAML(data string, strict bool) (interface{}, erro
 	return docSet.Items[0].Value, nil
 }
 
-func (s *DataValuesFlags) kvFile(kv string) (*workspace.DataValues, error) {
-	pieces := strings.SplitN(kv, dvsKVSep, 2)
+// parseKeySelectorValue parses the value to match in a key path selector (e.g. "8080" in "ports[port=8080]") as YAML
+func (s *DataValuesFlags) parseKeySelectorValue(rawVal string) (interface{}, error) {
+	val, err := s.parseYAML(rawVal, false)
+	if err != nil {
+		return nil, fmt.Errorf("Deserializing YAML value '%s': %s", rawVal, err)
+	}
+	return val, nil
+}
+
+func (s *DataValuesFlags) kvFile(kv string, sensitive bool) (*workspace.DataValues, error) {
+	pieces := splitDataValueKV(kv)
 	if len(pieces) != 2 {
 		return nil, fmt.Errorf("Expected format key=/file/path")
 	}
 
-	contents, err := s.readFile(pieces[1])
+	// only when a format is explicitly given, is the file's contents decoded (rather than used as a string)
+	format, path := dataValuesFileFormat(pieces[1], false)
+
+	contents, err := s.readFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("Reading file '%s'", pieces[1])
+		return nil, fmt.Errorf("Reading file '%s'", path)
+	}
+
+	var value interface{} = string(contents)
+	if format != "" {
+		docs, err := dataValuesFileDecoder{path, contents, false}.Decode(format)
+		if err != nil {
+			return nil, err
+		}
+		if len(docs) != 1 {
+			return nil, fmt.Errorf("Expected file '%s' to contain exactly one document, but found %d", path, len(docs))
+		}
+		value = docs[0].Value
 	}
 
 	libRef, key, err := s.libraryRefAndKey(pieces[0])
 	if err != nil {
 		return nil, err
 	}
+	keyPath, err := parseDataValueKeyPath(key, s.parseKeySelectorValue)
+	if err != nil {
+		return nil, err
+	}
 	desc := fmt.Sprintf("(data-value-file arg) %s=%s", key, pieces[1])
-	overlay := s.buildOverlay(strings.Split(key, dvsMapKeySep), string(contents), desc, string(contents))
+	line := string(contents)
+	if sensitive {
+		desc = fmt.Sprintf("(data-value-sensitive-file arg) %s=%s", key, pieces[1])
+		line = filepos.RedactedValue
+	}
+	overlay, err := s.buildOverlay(keyPath, value, desc, line, false, sensitive)
+	if err != nil {
+		return nil, err
+	}
 
 	return workspace.NewDataValuesWithOptionalLib(overlay, libRef)
 }
@@ -291,47 +508,114 @@ func (DataValuesFlags) libraryRefAndKey(key string) (string, string, error) {
 	}
 }
 
-func (s *DataValuesFlags) buildOverlay(keyPieces []string, value interface{}, desc string, line string) *yamlmeta.Document {
-	resultMap := &yamlmeta.Map{}
-	currMap := resultMap
-	var lastMapItem *yamlmeta.MapItem
-
+func (s *DataValuesFlags) buildOverlay(keyPath []dataValueKeyPathPiece, value interface{}, desc string, line string, coerceToSchemaType, sensitive bool) (*yamlmeta.Document, error) {
 	pos := filepos.NewPosition(1)
 	pos.SetFile(desc)
 	pos.SetLine(line)
 
-	for _, piece := range keyPieces {
-		newMap := &yamlmeta.Map{}
-		lastMapItem = &yamlmeta.MapItem{Key: piece, Value: newMap, Position: pos}
-
-		// Data values schemas should be enough to provide key checking/validations.
-		lastMapItem.SetAnnotations(template.NodeAnnotations{
-			yttoverlay.AnnotationMatch: template.NodeAnnotation{
-				Kwargs: []starlark.Tuple{{
-					starlark.String(yttoverlay.MatchAnnotationKwargMissingOK),
-					starlark.Bool(true),
-				}},
-			},
-		})
-
-		currMap.Items = append(currMap.Items, lastMapItem)
-		currMap = newMap
+	// Data values schemas should be enough to provide key checking/validations.
+	resultMap, lastNode, err := s.buildKeyPath(keyPath, pos, true)
+	if err != nil {
+		return nil, err
 	}
 
-	lastMapItem.Value = yamlmeta.NewASTFromInterface(value)
+	lastNode.SetValue(yamlmeta.NewASTFromInterface(value))
 
 	// Explicitly replace entire value at given key
 	// (this allows to specify non-scalar data values)
-	existingAnns := template.NewAnnotations(lastMapItem)
-	existingAnns[yttoverlay.AnnotationReplace] = template.NodeAnnotation{
-		Kwargs: []starlark.Tuple{{
-			starlark.String(yttoverlay.ReplaceAnnotationKwargOrAdd),
-			starlark.Bool(true),
-		}},
+	existingAnns := template.NewAnnotations(lastNode)
+	_, isArrayItem := lastNode.(*yamlmeta.ArrayItem)
+	switch {
+	case existingAnns.Has(yttoverlay.AnnotationAppend):
+		// appended array item is added as is
+	case isArrayItem:
+		// matched array item must already exist
+		existingAnns[yttoverlay.AnnotationReplace] = template.NodeAnnotation{}
+	default:
+		existingAnns[yttoverlay.AnnotationReplace] = template.NodeAnnotation{
+			Kwargs: []starlark.Tuple{{
+				starlark.String(yttoverlay.ReplaceAnnotationKwargOrAdd),
+				starlark.Bool(true),
+			}},
+		}
+	}
+	if coerceToSchemaType {
+		existingAnns[workspace.AnnotationCoerceToSchemaType] = template.NodeAnnotation{}
+	}
+	lastNode.SetAnnotations(existingAnns)
+
+	if sensitive {
+		// given its own position, so that only this data value (rather than its parents) is concealed
+		lastNode.SetPosition(pos.DeepCopy())
+		yamlmeta.MarkSensitive(lastNode)
+	}
+
+	return &yamlmeta.Document{Value: resultMap, Position: pos}, nil
+}
+
+func (s *DataValuesFlags) buildRemoveOverlay(keyPath []dataValueKeyPathPiece, desc string, line string) (*yamlmeta.Document, error) {
+	pos := filepos.NewPosition(1)
+	pos.SetFile(desc)
+	pos.SetLine(line)
+
+	// Removed data value (and those containing it) must exist
+	resultMap, lastNode, err := s.buildKeyPath(keyPath, pos, false)
+	if err != nil {
+		return nil, err
+	}
+
+	existingAnns := template.NewAnnotations(lastNode)
+	if existingAnns.Has(yttoverlay.AnnotationAppend) {
+		return nil, fmt.Errorf("Expected key path to refer to existing data value, but was to append array item")
+	}
+	existingAnns[yttoverlay.AnnotationRemove] = template.NodeAnnotation{}
+	lastNode.SetAnnotations(existingAnns)
+
+	return &yamlmeta.Document{Value: resultMap, Position: pos}, nil
+}
+
+// buildKeyPath nests a map item for each key in `keyPath` (and an array item for each of their selectors),
+// returning the outermost map and the innermost item (whose value is left to be set).
+func (s *DataValuesFlags) buildKeyPath(keyPath []dataValueKeyPathPiece, pos *filepos.Position, missingOK bool) (*yamlmeta.Map, yamlmeta.Node, error) {
+	resultMap := &yamlmeta.Map{}
+	currMap := resultMap
+	var lastNode yamlmeta.Node
+
+	for _, piece := range keyPath {
+		mapItem := &yamlmeta.MapItem{Key: piece.Key, Position: pos}
+
+		if missingOK {
+			mapItem.SetAnnotations(template.NodeAnnotations{
+				yttoverlay.AnnotationMatch: template.NodeAnnotation{
+					Kwargs: []starlark.Tuple{{
+						starlark.String(yttoverlay.MatchAnnotationKwargMissingOK),
+						starlark.Bool(true),
+					}},
+				},
+			})
+		}
+
+		currMap.Items = append(currMap.Items, mapItem)
+		lastNode = mapItem
+
+		// Array items are selected via overlay matchers (e.g. key[0] as @overlay/match by=overlay.index(0))
+		for _, selector := range piece.Selectors {
+			anns, err := selector.Annotations()
+			if err != nil {
+				return nil, nil, err
+			}
+			arrayItem := &yamlmeta.ArrayItem{Position: pos}
+			arrayItem.SetAnnotations(anns)
+
+			lastNode.SetValue(&yamlmeta.Array{Items: []*yamlmeta.ArrayItem{arrayItem}, Position: pos})
+			lastNode = arrayItem
+		}
+
+		currMap = &yamlmeta.Map{}
+		lastNode.SetValue(currMap)
 	}
-	lastMapItem.SetAnnotations(existingAnns)
 
-	return &yamlmeta.Document{Value: resultMap, Position: pos}
+	return resultMap, lastNode, nil
 }
 
 func (s *DataValuesFlags) readFile(path string) ([]byte, error) {
@@ -0,0 +1,174 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/k14s/starlark-go/starlark"
+	"github.com/k14s/starlark-go/starlarkstruct"
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/template/core"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
+)
+
+const (
+	dvsKeyPathAppend = "-"
+)
+
+// dataValueKeyPathPiece is a single key of a data value key path, along with the array items to select within
+// its value (e.g. "containers[name=web]" in "containers[name=web].image")
+type dataValueKeyPathPiece struct {
+	Key       string
+	Selectors []dataValueKeySelector
+}
+
+// dataValueKeySelector picks an array item: by index (e.g. "[0]"), by the value of one of its keys
+// (e.g. "[name=web]"), or a new item to append (i.e. "[-]")
+type dataValueKeySelector struct {
+	Index      int
+	Append     bool
+	MatchKey   string
+	MatchValue interface{}
+}
+
+// newDataValueKeyPath builds a key path of plain keys (i.e. without array selectors)
+func newDataValueKeyPath(keys []string) []dataValueKeyPathPiece {
+	var result []dataValueKeyPathPiece
+	for _, key := range keys {
+		result = append(result, dataValueKeyPathPiece{Key: key})
+	}
+	return result
+}
+
+// parseDataValueKeyPath splits a key path (e.g. `spec.containers[name=web].ports[0]`, `labels."app.io/name"`) into
+// its keys. Keys containing dots (or brackets) may be quoted.
+func parseDataValueKeyPath(path string, parseValFunc func(string) (interface{}, error)) ([]dataValueKeyPathPiece, error) {
+	var result []dataValueKeyPathPiece
+	rest := path
+
+	for {
+		var piece dataValueKeyPathPiece
+
+		if strings.HasPrefix(rest, `"`) || strings.HasPrefix(rest, "'") {
+			endIdx := strings.Index(rest[1:], rest[:1])
+			if endIdx < 0 {
+				return nil, fmt.Errorf("Expected key path '%s' to close quote %s", path, rest[:1])
+			}
+			piece.Key = rest[1 : endIdx+1]
+			rest = rest[endIdx+2:]
+		} else {
+			endIdx := strings.IndexAny(rest, dvsMapKeySep+"[")
+			if endIdx < 0 {
+				endIdx = len(rest)
+			}
+			piece.Key = rest[:endIdx]
+			rest = rest[endIdx:]
+		}
+		if piece.Key == "" {
+			return nil, fmt.Errorf("Expected key path '%s' to not have empty keys", path)
+		}
+
+		for strings.HasPrefix(rest, "[") {
+			endIdx := strings.Index(rest, "]")
+			if endIdx < 0 {
+				return nil, fmt.Errorf("Expected key path '%s' to close bracket [", path)
+			}
+			selector, err := parseDataValueKeySelector(rest[1:endIdx], parseValFunc)
+			if err != nil {
+				return nil, fmt.Errorf("Parsing array item selector in key path '%s': %s", path, err)
+			}
+			piece.Selectors = append(piece.Selectors, selector)
+			rest = rest[endIdx+1:]
+		}
+
+		result = append(result, piece)
+
+		if rest == "" {
+			return result, nil
+		}
+		if !strings.HasPrefix(rest, dvsMapKeySep) {
+			return nil, fmt.Errorf("Expected key path '%s' to separate keys with '%s'", path, dvsMapKeySep)
+		}
+		rest = rest[len(dvsMapKeySep):]
+	}
+}
+
+// splitDataValueKV splits `kv` into its key path and value, at the first separator that is not within
+// a quoted key or an array item selector (e.g. "containers[name=web].image=nginx")
+func splitDataValueKV(kv string) []string {
+	var quote rune
+	inSelector := false
+
+	for i, ch := range kv {
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case inSelector:
+			if ch == ']' {
+				inSelector = false
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '[':
+			inSelector = true
+		case strings.HasPrefix(kv[i:], dvsKVSep):
+			return []string{kv[:i], kv[i+len(dvsKVSep):]}
+		}
+	}
+	return []string{kv}
+}
+
+func parseDataValueKeySelector(selector string, parseValFunc func(string) (interface{}, error)) (dataValueKeySelector, error) {
+	if selector == dvsKeyPathAppend {
+		return dataValueKeySelector{Append: true}, nil
+	}
+	if pieces := strings.SplitN(selector, dvsKVSep, 2); len(pieces) == 2 {
+		val, err := parseValFunc(pieces[1])
+		if err != nil {
+			return dataValueKeySelector{}, err
+		}
+		return dataValueKeySelector{MatchKey: pieces[0], MatchValue: val}, nil
+	}
+	idx, err := strconv.Atoi(selector)
+	if err != nil || idx < 0 {
+		return dataValueKeySelector{}, fmt.Errorf("Expected '%s' to be an index (e.g. [0]), a key and value to match (e.g. [name=web]) or '%s' to append", selector, dvsKeyPathAppend)
+	}
+	return dataValueKeySelector{Index: idx}, nil
+}
+
+// Annotations expresses the selector as overlay annotations on an array item
+// (i.e. @overlay/match by=overlay.index(...), @overlay/match by=overlay.subset(...) or @overlay/append)
+func (s dataValueKeySelector) Annotations() (template.NodeAnnotations, error) {
+	if s.Append {
+		return template.NodeAnnotations{yttoverlay.AnnotationAppend: template.NodeAnnotation{}}, nil
+	}
+
+	var matcherName string
+	var matcherArg starlark.Value
+	if s.MatchKey != "" {
+		matcherName = "subset"
+		matcherArg = core.NewGoValue(orderedmap.NewMapWithItems([]orderedmap.MapItem{{Key: s.MatchKey, Value: s.MatchValue}})).AsStarlarkValue()
+	} else {
+		matcherName = "index"
+		matcherArg = starlark.MakeInt(s.Index)
+	}
+
+	overlayModule := yttoverlay.API["overlay"].(*starlarkstruct.Module)
+	matcher, err := starlark.Call(&starlark.Thread{Name: "data-values-key-path"}, overlayModule.Members[matcherName], starlark.Tuple{matcherArg}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return template.NodeAnnotations{
+		yttoverlay.AnnotationMatch: template.NodeAnnotation{
+			Kwargs: []starlark.Tuple{{starlark.String(yttoverlay.MatchAnnotationKwargBy), matcher}},
+		},
+	}, nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestDataValuesKeyPath_Sets_array_items(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+containers:
+- name: ""
+  image: ""
+  ports:
+  - 0
+`
+	valuesYAML := `#@data/values
+---
+containers:
+- name: web
+  image: nginx:1.19
+  ports:
+  - 80
+- name: sidecar
+  image: envoy:1.16
+  ports:
+  - 9901
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+containers: #@ data.values.containers
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+	})
+
+	t.Run("by index", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"containers[0].image=nginx:1.21", "containers[1].ports[0]=9902"}
+		expected := `containers:
+- name: web
+  image: nginx:1.21
+  ports:
+  - 80
+- name: sidecar
+  image: envoy:1.16
+  ports:
+  - 9902
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("by the value of one of their keys", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"containers[name=sidecar].image=envoy:1.17"}
+		expected := `containers:
+- name: web
+  image: nginx:1.19
+  ports:
+  - 80
+- name: sidecar
+  image: envoy:1.17
+  ports:
+  - 9901
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("by appending", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"containers[name=web].ports[-]=443"}
+		opts.DataValuesFlags.KVsFromYAML = []string{"containers[-]={name: metrics, image: prom:2.26}"}
+		expected := `containers:
+- name: web
+  image: nginx:1.19
+  ports:
+  - 80
+  - 443
+- name: sidecar
+  image: envoy:1.16
+  ports:
+  - 9901
+- name: metrics
+  image: prom:2.26
+  ports: []
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("fails when no item matches", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"containers[2].image=redis"}
+		expectedErr := `Expected number of matched nodes to be 1, but was 0`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
+
+func TestDataValuesKeyPath_Quoted_keys(t *testing.T) {
+	valuesYAML := `#@data/values
+---
+labels:
+  app.kubernetes.io/name: web
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+labels: #@ data.values.labels
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+	})
+
+	opts := cmdtpl.NewOptions()
+	opts.DataValuesFlags.KVsFromStrings = []string{`labels."app.kubernetes.io/name"=api`, `labels.'app.kubernetes.io/part-of'=shop`}
+	expected := `labels:
+  app.kubernetes.io/name: api
+  app.kubernetes.io/part-of: shop
+`
+	assertSucceeds(t, filesToProcess, expected, opts)
+}
+
+func TestDataValuesKeyPath_Errors(t *testing.T) {
+	filesToProcess := files.NewSortedFiles([]*files.File{})
+
+	cases := map[string]string{
+		`labels."app.io=web`:   `Expected format key=value`,
+		`ports[0=80`:           `Expected format key=value`,
+		`ports[first]=80`:      `Expected 'first' to be an index (e.g. [0]), a key and value to match (e.g. [name=web]) or '-' to append`,
+		`ports..port=80`:       `Expected key path 'ports..port' to not have empty keys`,
+		`ports[0]port=80`:      `Expected key path 'ports[0]port' to separate keys with '.'`,
+		`containers[-1].x=abc`: `Expected '-1' to be an index (e.g. [0]), a key and value to match (e.g. [name=web]) or '-' to append`,
+	}
+	for kv, expectedErr := range cases {
+		t.Run(kv, func(t *testing.T) {
+			opts := cmdtpl.NewOptions()
+			opts.DataValuesFlags.KVsFromStrings = []string{kv}
+
+			assertFails(t, filesToProcess, expectedErr, opts)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"bytes"
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/cmd/ui"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataValuesLibraryInspect(t *testing.T) {
+	rootSchemaYAML := `#@data/values-schema
+---
+app_name: ""
+
+#@library/ref "@db"
+#@data/values-schema
+---
+#@overlay/match missing_ok=True
+replicas: 1
+`
+	rootValuesYAML := `#@data/values
+---
+app_name: shop
+
+#@library/ref "@db"
+#@data/values
+---
+db_name: shop
+`
+	libSchemaYAML := `#@data/values-schema
+---
+user: admin
+db_name: ""
+`
+	libValuesYAML := `#@library/ref "@backup"
+#@data/values
+---
+schedule: daily
+`
+	nestedValuesYAML := `#@data/values
+---
+schedule: weekly
+bucket: default
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(rootSchemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(rootValuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/db/schema.yml", []byte(libSchemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/db/values.yml", []byte(libValuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/db/_ytt_lib/backup/values.yml", []byte(nestedValuesYAML))),
+	})
+
+	t.Run("data values, as given by the root library and flags", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Inspect = true
+		opts.DataValuesFlags.Library = "@db"
+		opts.DataValuesFlags.KVsFromStrings = []string{"@db:user=root"}
+		expected := `user: root
+db_name: shop
+replicas: 1
+`
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+	t.Run("data values of nested library", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Inspect = true
+		opts.DataValuesFlags.Library = "@db@backup"
+		opts.DataValuesFlags.KVsFromStrings = []string{"@db@backup:bucket=shop-backups"}
+		expected := `schedule: daily
+bucket: shop-backups
+`
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+	t.Run("schema, as given by the root library", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.InspectSchema = true
+		opts.DataValuesFlags.Library = "@db"
+		opts.RegularFilesSourceOpts.OutputType.Types = []string{"data-values-template"}
+		expected := `#@data/values
+---
+#! type: string
+user: admin
+#! type: string
+db_name: ""
+#! type: integer
+replicas: 1
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("fails when library does not exist", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Inspect = true
+		opts.DataValuesFlags.Library = "@cache"
+		expectedErr := `Expected to find library 'cache'`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("fails when not inspecting", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Library = "@db"
+		expectedErr := `Expected --library to be used to inspect data values or schema (i.e. with --data-values-inspect or --data-values-schema-inspect)`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
+
+func TestDataValuesLibraryInspect_Evaluated_by_templates(t *testing.T) {
+	libSchemaYAML := `#@data/values-schema
+---
+user: admin
+db_name: ""
+`
+	rootValuesYAML := `#@library/ref "@db"
+#@data/values
+---
+user: root
+`
+	templateYAML := `#@ load("@ytt:library", "library")
+#@ load("@ytt:template", "template")
+
+--- #@ template.replace(library.get("db").with_data_values({"db_name": "shop"}).eval())
+--- #@ template.replace(library.get("db").with_data_values({"db_name": "blog"}).eval())
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(rootValuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(templateYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/db/schema.yml", []byte(libSchemaYAML))),
+	})
+
+	t.Run("data values of each evaluation, including those given via with_data_values()", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Inspect = true
+		opts.DataValuesFlags.Library = "@db"
+		expected := `user: root
+db_name: shop
+---
+user: root
+db_name: blog
+`
+		expectedLabels := `Document 1: data values of library '@db' as evaluated by config.yml:4
+Document 2: data values of library '@db' as evaluated by config.yml:5
+`
+
+		stderr := bytes.NewBufferString("")
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewCustomWriterTTY(false, nil, stderr))
+		require.NoError(t, out.Err)
+
+		outBytes, err := out.DocSet.AsBytes()
+		require.NoError(t, err)
+		require.Equal(t, expected, string(outBytes))
+		require.Equal(t, expectedLabels, stderr.String())
+	})
+	t.Run("provenance of each evaluation, labelling data values given via with_data_values() by their source", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.InspectProvenance = true
+		opts.DataValuesFlags.Library = "@db"
+		expected := `user:
+  value: root
+  sources:
+  - from: _ytt_lib/db/schema.yml:3 (schema default)
+    value: admin
+  - from: values.yml:4
+    value: root
+db_name:
+  value: shop
+  sources:
+  - from: _ytt_lib/db/schema.yml:4 (schema default)
+    value: ""
+  - from: config.yml:4
+    value: shop
+---
+user:
+  value: root
+  sources:
+  - from: _ytt_lib/db/schema.yml:3 (schema default)
+    value: admin
+  - from: values.yml:4
+    value: root
+db_name:
+  value: blog
+  sources:
+  - from: _ytt_lib/db/schema.yml:4 (schema default)
+    value: ""
+  - from: config.yml:5
+    value: blog
+`
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"bytes"
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/cmd/ui"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataValuesProfiles(t *testing.T) {
+	valuesYAML := `#@data/values
+---
+replicas: 1
+region: us-east-1
+debug: true
+`
+	prodValuesYAML := `#@data/values profile="prod"
+---
+replicas: 3
+debug: false
+`
+	euValuesYAML := `#@data/values profile="eu"
+---
+region: eu-west-1
+replicas: 2
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+replicas: #@ data.values.replicas
+region: #@ data.values.region
+debug: #@ data.values.debug
+`
+
+	// profiled documents are listed first, to show that they are applied after unprofiled ones regardless
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("a-prod.yml", []byte(prodValuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("b-eu.yml", []byte(euValuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+	})
+
+	t.Run("are not applied by default", func(t *testing.T) {
+		expected := `replicas: 1
+region: us-east-1
+debug: true
+`
+		assertSucceeds(t, filesToProcess, expected, cmdtpl.NewOptions())
+	})
+	t.Run("are applied after unprofiled documents", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Profiles = []string{"prod"}
+		expected := `replicas: 3
+region: us-east-1
+debug: false
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("are applied in the order given", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Profiles = []string{"eu", "prod"}
+		expected := `replicas: 3
+region: eu-west-1
+debug: false
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+
+		opts.DataValuesFlags.Profiles = []string{"prod", "eu"}
+		expected = `replicas: 2
+region: eu-west-1
+debug: false
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("are applied before data values given via flags", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Profiles = []string{"prod"}
+		opts.DataValuesFlags.KVsFromYAML = []string{"replicas=5"}
+		expected := `replicas: 5
+region: us-east-1
+debug: false
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("are listed when inspected", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Profiles = []string{"prod", "eu"}
+		opts.DataValuesFlags.Inspect = true
+
+		stderr := bytes.NewBufferString("")
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewCustomWriterTTY(false, nil, stderr))
+		require.NoError(t, out.Err)
+
+		outBytes, err := out.DocSet.AsBytes()
+		require.NoError(t, err)
+		require.Equal(t, "replicas: 2\nregion: eu-west-1\ndebug: false\n", string(outBytes))
+		require.Equal(t, "Applied data values profiles (in order): prod, eu\n", stderr.String())
+	})
+	t.Run("are applied within private libraries", func(t *testing.T) {
+		libValuesYAML := `#@data/values
+---
+replicas: 1
+
+#@data/values profile="prod"
+---
+replicas: 3
+`
+		libConfigYAML := `#@ load("@ytt:data", "data")
+lib_replicas: #@ data.values.replicas
+`
+		configYAML := `#@ load("@ytt:template", "template")
+#@ load("@ytt:library", "library")
+--- #@ template.replace(library.get("lib").eval())
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/lib/values.yml", []byte(libValuesYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/lib/config.yml", []byte(libConfigYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Profiles = []string{"prod"}
+
+		assertSucceeds(t, filesToProcess, "lib_replicas: 3\n", opts)
+	})
+	t.Run("fails when profile is not declared", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Profiles = []string{"prd"}
+		expectedErr := `Expected data values profile 'prd' to be declared by at least one data values document (e.g. @data/values profile="prd")`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
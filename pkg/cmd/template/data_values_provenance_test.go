@@ -0,0 +1,121 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"fmt"
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestDataValuesProvenance_Lists_sources_of_each_data_value_in_order_applied(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+image:
+  name: nginx
+  tag: latest
+replicas: 1
+hosts:
+- ""
+`
+	valuesYAML := `#@data/values
+---
+image:
+  tag: "1.0"
+`
+	dvsFileYAML := `replicas: 3
+hosts:
+- example.com
+`
+	expected := `image.name:
+  value: nginx
+  sources:
+  - from: schema.yml:4 (schema default)
+    value: nginx
+image.tag:
+  value: 1.2.3
+  sources:
+  - from: schema.yml:5 (schema default)
+    value: latest
+  - from: values.yml:4
+    value: "1.0"
+  - from: (data-value arg):1
+    value: 1.2.3
+replicas:
+  value: 3
+  sources:
+  - from: schema.yml:6 (schema default)
+    value: 1
+  - from: dvs.yml:1
+    value: 3
+hosts:
+  value:
+  - example.com
+  sources:
+  - from: schema.yml:7 (schema default)
+    value: []
+  - from: dvs.yml:2
+    value:
+    - example.com
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+	})
+
+	opts := cmdtpl.NewOptions()
+	opts.DataValuesFlags = cmdtpl.DataValuesFlags{
+		InspectProvenance: true,
+		FromFiles:         []string{"dvs.yml"},
+		KVsFromStrings:    []string{"image.tag=1.2.3"},
+		ReadFileFunc: func(path string) ([]byte, error) {
+			if path == "dvs.yml" {
+				return []byte(dvsFileYAML), nil
+			}
+			return nil, fmt.Errorf("Unknown file '%s'", path)
+		},
+	}
+
+	assertSucceedsDocSet(t, filesToProcess, expected, opts)
+}
+
+func TestDataValuesProvenance_Includes_sources_that_replaced_parent_map(t *testing.T) {
+	valuesYAML := `#@data/values
+---
+db:
+  host: localhost
+  port: 5432
+`
+	overrideYAML := `#@ load("@ytt:overlay", "overlay")
+#@data/values
+---
+#@overlay/replace
+db:
+  host: db.example.com
+`
+	expected := `db.host:
+  value: db.example.com
+  sources:
+  - from: values.yml:4
+    value: localhost
+  - from: values2.yml:5
+    value:
+      host: db.example.com
+  - from: values2.yml:6
+    value: db.example.com
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("values2.yml", []byte(overrideYAML))),
+	})
+
+	opts := cmdtpl.NewOptions()
+	opts.DataValuesFlags.InspectProvenance = true
+
+	assertSucceedsDocSet(t, filesToProcess, expected, opts)
+}
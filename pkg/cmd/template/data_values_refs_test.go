@@ -0,0 +1,180 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataValuesRefs_Resolves_references_once_enabled(t *testing.T) {
+	secretPath := filepath.Join(t.TempDir(), "db")
+	require.NoError(t, ioutil.WriteFile(secretPath, []byte("hunter2\n"), 0600))
+	t.Setenv("YTT_TEST_DB_PORT", "5432")
+
+	schemaYAML := `#@data/values-schema
+---
+db:
+  password: ""
+  port: 0
+  user: ""
+  hosts:
+  - ""
+`
+	valuesYAML := `#@data/values
+---
+db:
+  password: ref+file://` + secretPath + `
+  port: ref+env://YTT_TEST_DB_PORT
+  user: ref+exec://echo admin
+  hosts:
+  - ref+env://YTT_TEST_DB_HOST
+`
+	overrideValuesYAML := `#@data/values
+---
+db:
+  #@overlay/replace
+  hosts:
+  - db.example.com
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+db: #@ data.values.db
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("values2.yml", []byte(overrideValuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+	})
+
+	t.Run("not resolved by default", func(t *testing.T) {
+		expected := `db:
+  password: ref+file://` + secretPath + `
+  port: ref+env://YTT_TEST_DB_PORT
+  user: ref+exec://echo admin
+  hosts:
+  - db.example.com
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values2.yml", []byte(overrideValuesYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+		assertSucceeds(t, filesToProcess, expected, cmdtpl.NewOptions())
+	})
+	t.Run("after all overlays, parsed into the type declared in schema", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.ResolveRefs = []string{"file", "env", "exec"}
+		expected := `db:
+  password: hunter2
+  port: 5432
+  user: admin
+  hosts:
+  - db.example.com
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("as sensitive values", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.ResolveRefs = []string{"file", "env", "exec"}
+		opts.DataValuesFlags.Inspect = true
+		expected := `db:
+  password: (redacted)
+  port: (redacted)
+  user: (redacted)
+  hosts:
+  - db.example.com
+`
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+	t.Run("running commands given as a JSON array, so that arguments may contain spaces", func(t *testing.T) {
+		valuesYAML := `#@data/values
+---
+greeting: 'ref+exec://["echo", "hello  world"]'
+`
+		templateYAML := `#@ load("@ytt:data", "data")
+---
+greeting: #@ data.values.greeting
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.ResolveRefs = []string{"exec"}
+
+		assertSucceeds(t, filesToProcess, "greeting: hello  world\n", opts)
+	})
+	t.Run("fails when kind of reference is not enabled", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.ResolveRefs = []string{"file", "env"}
+		expectedErr := `Resolving data value 'db.user' (set by values.yml:6): Resolving references of kind 'exec' is not enabled (enabled: env, file)`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
+
+func TestDataValuesRefs_Errors(t *testing.T) {
+	valuesYAML := `#@data/values
+---
+password: ref+env://YTT_TEST_UNSET_VAR
+`
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+	})
+
+	t.Run("when reference cannot be resolved", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.ResolveRefs = []string{"env"}
+		expectedErr := `Resolving data value 'password' (set by values.yml:3): Expected environment variable 'YTT_TEST_UNSET_VAR' to be set`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when a value that is not a reference is of the wrong type", func(t *testing.T) {
+		schemaYAML := `#@data/values-schema
+---
+port: 0
+password: ""
+`
+		valuesYAML := `#@data/values
+---
+port: notanint
+password: ref+env://YTT_TEST_UNSET_VAR
+`
+		overrideValuesYAML := `#@data/values
+---
+port: 8080
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values2.yml", []byte(overrideValuesYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.ResolveRefs = []string{"env"}
+		expectedErr := `values.yml:
+    |
+  3 | port: notanint
+    |
+
+    = found: string
+    = expected: integer (by schema.yml:3)`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when kind of reference is unknown", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.ResolveRefs = []string{"vault"}
+		expectedErr := `Unknown kind of data value reference 'vault' (expected one of: env, exec, file)`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
@@ -17,7 +17,7 @@ type FileMarksOpts struct {
 }
 
 func (s *FileMarksOpts) Set(cmd *cobra.Command) {
-	cmd.Flags().StringArrayVar(&s.FileMarks, "file-mark", nil, "File mark (ie change file path, mark as non-template) (format: file:key=value) (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&s.FileMarks, "file-mark", nil, "File mark (ie change file path, mark as non-template, mark as patch with type=json-patch or type=strategic-merge-patch and patch-target=kind=...,name=...) (format: file:key=value) (can be specified multiple times)")
 }
 
 func (s *FileMarksOpts) Apply(filesToProcess []*files.File) ([]*files.File, error) {
@@ -74,10 +74,23 @@ func (s *FileMarksOpts) Apply(filesToProcess []*files.File) ([]*files.File, erro
 					case "data":
 						file.MarkType(files.TypeUnknown)
 						file.MarkTemplate(false)
+					case "json-patch": // RFC 6902 JSON Patch, applied as overlays
+						file.MarkType(files.TypeYAML)
+						file.MarkTemplate(false)
+						file.MarkForOutput(false)
+						file.MarkPatchType(files.PatchTypeJSON)
+					case "strategic-merge-patch": // applied as overlays
+						file.MarkType(files.TypeYAML)
+						file.MarkTemplate(false)
+						file.MarkForOutput(false)
+						file.MarkPatchType(files.PatchTypeStrategicMerge)
 					default:
 						return nil, fmt.Errorf("Unknown value in file mark '%s'", mark)
 					}
 
+				case "patch-target":
+					file.MarkPatchTarget(kv[1])
+
 				case "for-output":
 					switch kv[1] {
 					case "true":
@@ -0,0 +1,166 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/cmd/ui"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlayPriority(t *testing.T) {
+	configYAML := `---
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+`
+	platformOverlayYAML := `#@ load("@ytt:overlay", "overlay")
+
+#@overlay/match by=overlay.subset({"kind": "Deployment"})
+#@overlay/priority 100
+---
+spec:
+  replicas: 5
+
+#@overlay/match by=overlay.subset({"kind": "Deployment"})
+---
+metadata:
+  #@overlay/match missing_ok=True
+  labels:
+    team: platform
+`
+	teamOverlayYAML := `#@ load("@ytt:overlay", "overlay")
+
+#@overlay/match by=overlay.subset({"kind": "Deployment"})
+---
+spec:
+  replicas: 3
+  #@overlay/match missing_ok=True
+  paused: false
+
+#@overlay/match by=overlay.subset({"kind": "Deployment"})
+#@overlay/priority -10
+---
+metadata:
+  #@overlay/match missing_ok=True
+  labels:
+    team: app
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("platform/overlay.yml", []byte(platformOverlayYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("team/overlay.yml", []byte(teamOverlayYAML))),
+	})
+
+	t.Run("applies overlays with higher priority later", func(t *testing.T) {
+		expected := `kind: Deployment
+metadata:
+  name: web
+  labels:
+    team: platform
+spec:
+  replicas: 5
+  paused: false
+`
+		assertSucceeds(t, filesToProcess, expected, cmdtpl.NewOptions())
+	})
+
+	t.Run("shows overlays order when inspecting files", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.InspectFiles = true
+
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewTTY(false))
+		require.NoError(t, out.Err)
+
+		outBytes, err := out.DocSet.AsBytes()
+		require.NoError(t, err)
+		require.Equal(t, `- config.yml
+- platform/overlay.yml
+- team/overlay.yml
+---
+overlays:
+- overlay: team/overlay.yml:12
+  priority: -10
+- overlay: platform/overlay.yml:10
+  priority: 0
+- overlay: team/overlay.yml:4
+  priority: 0
+- overlay: platform/overlay.yml:5
+  priority: 100
+`, string(outBytes))
+	})
+
+	t.Run("shows priorities that are not literals when inspecting files", func(t *testing.T) {
+		computedOverlayYAML := `#@ load("@ytt:overlay", "overlay")
+#@ p = 10
+#@overlay/match by=overlay.subset({"kind": "Deployment"})
+#@overlay/priority p
+---
+spec:
+  replicas: 7
+`
+		malformedYAML := "#@ load(\"@ytt:overlay\", \"overlay\")\nkey: [\n"
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("computed.yml", []byte(computedOverlayYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("malformed.yml", []byte(malformedYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.InspectFiles = true
+
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewTTY(false))
+		require.NoError(t, out.Err)
+
+		outBytes, err := out.DocSet.AsBytes()
+		require.NoError(t, err)
+		require.Equal(t, `- config.yml
+- computed.yml
+- malformed.yml
+---
+overlays:
+- overlay: computed.yml:5
+  priority: p (evaluated at runtime)
+`, string(outBytes))
+	})
+
+	t.Run("lists priorities in order of overlaying on error", func(t *testing.T) {
+		failingOverlayYAML := `#@ load("@ytt:overlay", "overlay")
+#@overlay/match by=overlay.subset({"kind": "Service"})
+#@overlay/priority 50
+---
+spec: {}
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("platform/overlay.yml", []byte(platformOverlayYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("team/overlay.yml", []byte(teamOverlayYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("failing.yml", []byte(failingOverlayYAML))),
+		})
+		expectedErr := `Overlaying (in following order: team/overlay.yml (priority -10), platform/overlay.yml, team/overlay.yml, failing.yml (priority 50), platform/overlay.yml (priority 100)): Document on line failing.yml:4: Expected number of matched nodes to be 1, but was 0`
+
+		assertFails(t, filesToProcess, expectedErr, cmdtpl.NewOptions())
+	})
+
+	t.Run("fails when priority is not an integer", func(t *testing.T) {
+		invalidOverlayYAML := `#@ load("@ytt:overlay", "overlay")
+#@overlay/match by=overlay.all
+#@overlay/priority "last"
+---
+spec: {}
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("overlay.yml", []byte(invalidOverlayYAML))),
+		})
+		expectedErr := `Document on overlay.yml:4: Expected 'overlay/priority' annotation argument to be an integer`
+
+		assertFails(t, filesToProcess, expectedErr, cmdtpl.NewOptions())
+	})
+}
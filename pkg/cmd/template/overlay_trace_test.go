@@ -0,0 +1,177 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"bytes"
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/cmd/ui"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlayTrace(t *testing.T) {
+	configYAML := `---
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+---
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+`
+	overlayYAML := `#@ load("@ytt:overlay", "overlay")
+
+#@overlay/match by=overlay.subset({"kind": "Deployment"})
+---
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      #@overlay/match by="name"
+      - name: web
+        image: nginx:1.21
+      - name: sidecar
+        image: envoy
+  #@overlay/match missing_ok=True
+  #@overlay/remove
+  paused:
+
+#@overlay/match by=overlay.subset({"kind": "Service"})
+#@overlay/remove
+---
+
+#@overlay/match by=overlay.subset({"kind": "Ingress"}), expects="0+"
+---
+spec: {}
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("overlay.yml", []byte(overlayYAML))),
+	})
+
+	runWithTrace := func(t *testing.T, format string) string {
+		opts := cmdtpl.NewOptions()
+		opts.OverlayTrace = true
+		opts.OverlayTraceFormat = format
+
+		stderr := bytes.NewBufferString("")
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewCustomWriterTTY(false, nil, stderr))
+		require.NoError(t, out.Err)
+
+		outBytes, err := out.DocSet.AsBytes()
+		require.NoError(t, err)
+		require.Equal(t, `kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.21
+      - name: sidecar
+        image: envoy
+`, string(outBytes))
+		return stderr.String()
+	}
+
+	t.Run("as text", func(t *testing.T) {
+		expected := `Overlay overlay.yml:4 (merge)
+  matched config.yml[0] (kind: Deployment, name: web)
+    spec.replicas: merge (overlay.yml:6)
+    spec.template.spec.containers[0].name: merge (overlay.yml:11)
+    spec.template.spec.containers[0].image: merge (overlay.yml:12)
+    spec.template.spec.containers[1]: add (overlay.yml:13)
+    spec.paused: none (overlay.yml:17)
+Overlay overlay.yml:21 (remove)
+  matched config.yml[1] (kind: Service, name: web, namespace: shop)
+Overlay overlay.yml:24 (merge)
+  matched nothing
+`
+		require.Equal(t, expected, runWithTrace(t, cmdtpl.OverlayTraceFormatText))
+	})
+	t.Run("as yaml", func(t *testing.T) {
+		expected := `overlays:
+- overlay: overlay.yml:4
+  op: merge
+  matches:
+  - file: config.yml
+    index: 0
+    kind: Deployment
+    name: web
+    nodes:
+    - path: spec.replicas
+      op: merge
+      overlay: overlay.yml:6
+    - path: spec.template.spec.containers[0].name
+      op: merge
+      overlay: overlay.yml:11
+    - path: spec.template.spec.containers[0].image
+      op: merge
+      overlay: overlay.yml:12
+    - path: spec.template.spec.containers[1]
+      op: add
+      overlay: overlay.yml:13
+    - path: spec.paused
+      op: none
+      overlay: overlay.yml:17
+- overlay: overlay.yml:21
+  op: remove
+  matches:
+  - file: config.yml
+    index: 1
+    kind: Service
+    name: web
+    namespace: shop
+    nodes: []
+- overlay: overlay.yml:24
+  op: merge
+  matches: []
+`
+		require.Equal(t, expected, runWithTrace(t, cmdtpl.OverlayTraceFormatYAML))
+	})
+	t.Run("as json", func(t *testing.T) {
+		expected := `{"overlays":[{"matches":[{"file":"config.yml","index":1,"kind":"Service","name":"web","namespace":"shop","nodes":[]}],"op":"remove","overlay":"overlay.yml:4"}]}`
+
+		overlayYAML := `#@ load("@ytt:overlay", "overlay")
+#@overlay/match by=overlay.subset({"kind": "Service"})
+#@overlay/remove
+---
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("overlay.yml", []byte(overlayYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.OverlayTrace = true
+		opts.OverlayTraceFormat = cmdtpl.OverlayTraceFormatJSON
+
+		stderr := bytes.NewBufferString("")
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewCustomWriterTTY(false, nil, stderr))
+		require.NoError(t, out.Err)
+		require.JSONEq(t, expected, stderr.String())
+	})
+	t.Run("fails with unknown format", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.OverlayTrace = true
+		opts.OverlayTraceFormat = "xml"
+		expectedErr := `Expected --overlay-trace-format to be one of: text, yaml, json, but was 'xml'`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
@@ -0,0 +1,309 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestPatches(t *testing.T) {
+	configYAML := `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+        env:
+        - name: DEBUG
+          value: "true"
+      - name: sidecar
+        image: envoy
+      tolerations:
+      - key: a
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  replicas: 1
+`
+
+	t.Run("JSON patch", func(t *testing.T) {
+		patchYAML := `
+- op: test
+  path: /spec/replicas
+  value: 1
+- op: replace
+  path: /spec/replicas
+  value: 3
+- op: add
+  path: /metadata/labels
+  value:
+    app: web
+- op: add
+  path: /spec/template/spec/containers/1
+  value:
+    name: init
+    image: busybox
+- op: add
+  path: /spec/template/spec/containers/-
+  value:
+    name: logger
+    image: fluentd
+- op: remove
+  path: /spec/template/spec/containers/0/env
+- op: replace
+  path: /spec/template/spec/containers/0/image
+  value: nginx:1.21
+`
+		expected := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.21
+      - name: init
+        image: busybox
+      - name: sidecar
+        image: envoy
+      - name: logger
+        image: fluentd
+      tolerations:
+      - key: a
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  replicas: 1
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("patch.yml", []byte(patchYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.FileMarksOpts.FileMarks = []string{"patch.yml:type=json-patch", "patch.yml:patch-target=kind=Deployment,name=web"}
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("JSON patch with numeric map keys", func(t *testing.T) {
+		configMapYAML := `---
+kind: ConfigMap
+metadata:
+  name: ports
+data:
+  "0": http
+  "1": https
+`
+		patchYAML := `
+- op: test
+  path: /data/0
+  value: http
+- op: replace
+  path: /data/0
+  value: grpc
+- op: remove
+  path: /data/1
+- op: add
+  path: /data/2
+  value: metrics
+`
+		expected := `kind: ConfigMap
+metadata:
+  name: ports
+data:
+  "0": grpc
+  "2": metrics
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configMapYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("patch.yml", []byte(patchYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.FileMarksOpts.FileMarks = []string{"patch.yml:type=json-patch", "patch.yml:patch-target=kind=ConfigMap"}
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("strategic merge patch", func(t *testing.T) {
+		patchYAML := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    owner: team-a
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.21
+        env:
+        - name: DEBUG
+          value: "false"
+        - name: LOG_LEVEL
+          value: info
+      - name: sidecar
+        $patch: delete
+      - name: logger
+        image: fluentd
+      tolerations:
+      - key: b
+`
+		expected := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    owner: team-a
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.21
+        env:
+        - name: DEBUG
+          value: "false"
+        - name: LOG_LEVEL
+          value: info
+      - name: logger
+        image: fluentd
+      tolerations:
+      - key: b
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  replicas: 1
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("patch.yml", []byte(patchYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.FileMarksOpts.FileMarks = []string{"patch.yml:type=strategic-merge-patch"}
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("strategic merge patch removes null keys", func(t *testing.T) {
+		patchYAML := `
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  replicas: null
+  paused: null
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("patch.yml", []byte(patchYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.FileMarksOpts.FileMarks = []string{"patch.yml:type=strategic-merge-patch"}
+
+		assertSucceedsWithRegexp(t, filesToProcess, `(?s)name: web\nspec:\n  replicas: 1\n.*name: worker\nspec: \{\}\n$`, opts)
+	})
+	t.Run("fails when JSON patch test does not pass pointing at the patch", func(t *testing.T) {
+		patchYAML := `
+- op: test
+  path: /spec/replicas
+  value: 2
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("patch.yml", []byte(patchYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.FileMarksOpts.FileMarks = []string{"patch.yml:type=json-patch", "patch.yml:patch-target=kind=Deployment"}
+
+		assertFails(t, filesToProcess, `Map item (key 'replicas') on line patch.yml:3: Expected objects to equal, but did not`, opts)
+	})
+	t.Run("fails when JSON patch path does not exist pointing at the patch", func(t *testing.T) {
+		patchYAML := `
+- op: replace
+  path: /spec/paused
+  value: true
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("patch.yml", []byte(patchYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.FileMarksOpts.FileMarks = []string{"patch.yml:type=json-patch", "patch.yml:patch-target=kind=Deployment,name=web"}
+
+		assertFails(t, filesToProcess, `Map item (key 'paused') on line patch.yml:3: Expected number of matched nodes to be 1, but was 0`, opts)
+	})
+	t.Run("fails when JSON patch array index does not exist pointing at the patch", func(t *testing.T) {
+		patchYAML := `
+- op: replace
+  path: /spec/template/spec/containers/2/image
+  value: nginx
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("patch.yml", []byte(patchYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.FileMarksOpts.FileMarks = []string{"patch.yml:type=json-patch", "patch.yml:patch-target=kind=Deployment,name=web"}
+		expectedErr := `JSON patch operation 'replace' on patch.yml:3: Expected path '/spec/template/spec/containers/2/image' to exist, but array of 2 items did not have index '2'`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("fails with unsupported JSON patch operation", func(t *testing.T) {
+		patchYAML := `
+- op: move
+  from: /spec/replicas
+  path: /spec/count
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("patch.yml", []byte(patchYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.FileMarksOpts.FileMarks = []string{"patch.yml:type=json-patch", "patch.yml:patch-target=kind=Deployment"}
+		expectedErr := `Converting patch file 'patch.yml' to overlays: JSON patch operation on patch.yml:2: Expected op to be one of add, remove, replace or test (move and copy are not supported), but was 'move'`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("fails when JSON patch has no target", func(t *testing.T) {
+		patchYAML := `
+- op: remove
+  path: /spec/replicas
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("config.yml", []byte(configYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("patch.yml", []byte(patchYAML))),
+		})
+		opts := cmdtpl.NewOptions()
+		opts.FileMarksOpts.FileMarks = []string{"patch.yml:type=json-patch"}
+		expectedErr := `Converting patch file 'patch.yml' to overlays: Expected JSON patch file 'patch.yml' to be given a target (e.g. --file-mark 'patch.yml:patch-target=kind=Deployment,name=web')`
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
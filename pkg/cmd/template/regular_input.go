@@ -28,7 +28,7 @@ type RegularFilesSourceOpts struct {
 
 // OutputType holds the user's desire for two (2) categories of output:
 // - file format type :: yaml, json, pos
-// - schema type :: OpenAPI V3, ytt Schema
+// - schema type :: OpenAPI V3, JSON Schema, Markdown reference docs, ytt Schema
 type OutputType struct {
 	Types []string
 }
@@ -86,6 +86,17 @@ func (s *RegularFilesSource) Output(out Output) error {
 	case len(s.opts.OutputFiles) > 0:
 		return files.NewOutputDirectory(s.opts.OutputFiles, out.Files, s.ui).WriteFiles()
 	default:
+		schemaType, err := s.opts.OutputType.Schema()
+		if err != nil {
+			return err
+		}
+		if schemaType == RegularFilesOutputTypeDocsMD || schemaType == RegularFilesOutputTypeDVTemplate {
+			// reference docs are not YAML and data values templates carry comments, and so are rendered as-is
+			for _, file := range out.Files {
+				s.ui.Printf("%s", file.Bytes())
+			}
+			return nil
+		}
 		for _, file := range out.Files {
 			if file.Type() != files.TypeYAML {
 				nonYamlFileNames = append(nonYamlFileNames, file.RelativePath())
@@ -135,14 +146,17 @@ const (
 
 // When the FileSource are RegularFilesSource, indicates which schema type to use when rendering the output.
 const (
-	RegularFilesOutputTypeOpenAPI = "openapi-v3"
-	RegularFilesOutputTypeNone    = ""
+	RegularFilesOutputTypeOpenAPI    = "openapi-v3"
+	RegularFilesOutputTypeJSONSchema = "json-schema"
+	RegularFilesOutputTypeDocsMD     = "schema-docs-markdown"
+	RegularFilesOutputTypeDVTemplate = "data-values-template"
+	RegularFilesOutputTypeNone       = ""
 )
 
 // Collections of each category of output type
 var (
 	RegularFilesOutputFormatTypes = []string{RegularFilesOutputTypeYAML, RegularFilesOutputTypeJSON, RegularFilesOutputTypePos}
-	RegularFilesOutputSchemaTypes = []string{RegularFilesOutputTypeOpenAPI}
+	RegularFilesOutputSchemaTypes = []string{RegularFilesOutputTypeOpenAPI, RegularFilesOutputTypeJSONSchema, RegularFilesOutputTypeDocsMD, RegularFilesOutputTypeDVTemplate}
 	RegularFilesOutputTypes       = append(RegularFilesOutputFormatTypes, RegularFilesOutputSchemaTypes...)
 )
 
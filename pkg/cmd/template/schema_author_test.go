@@ -200,7 +200,7 @@ schema.yml:
 
     = found: unknown_kwarg (by schema.yml:3)
     = expected: A valid kwarg
-    = hint: Supported kwargs are 'any'
+    = hint: Supported kwargs are 'any', 'map_of', 'one_of'
 `
 
 		filesToProcess := files.NewSortedFiles([]*files.File{
@@ -229,7 +229,7 @@ schema.yml:
 
     = found: starlark.Int (by schema.yml:3)
     = expected: starlark.Bool
-    = hint: Supported kwargs are 'any'
+    = hint: Supported kwargs are 'any', 'map_of', 'one_of'
 `
 
 		filesToProcess := files.NewSortedFiles([]*files.File{
@@ -258,7 +258,7 @@ schema.yml:
 
     = found: missing keyword argument and value (by schema.yml:3)
     = expected: valid keyword argument and value
-    = hint: Supported key-value pairs are 'any=True', 'any=False'
+    = hint: Supported key-value pairs are 'any=True', 'any=False', 'map_of=<example value>', 'one_of=[<example value>, ...]'
 `
 
 		filesToProcess := files.NewSortedFiles([]*files.File{
@@ -285,7 +285,7 @@ schema.yml:
 
     = found: missing keyword argument and value (by schema.yml:3)
     = expected: valid keyword argument and value
-    = hint: Supported key-value pairs are 'any=True', 'any=False'
+    = hint: Supported key-value pairs are 'any=True', 'any=False', 'map_of=<example value>', 'one_of=[<example value>, ...]'
 `
 
 		filesToProcess = files.NewSortedFiles([]*files.File{
@@ -1918,7 +1918,7 @@ foo: 7
 foo: #@ data.values.foo
 `)
 
-		cmdOpts.DataValuesFlags.KVsFromStrings = []string{"@lib:foo=42"}
+		cmdOpts.DataValuesFlags.KVsFromStrings = []string{"@lib:foo=forty-two"}
 		filesToProcess := files.NewSortedFiles([]*files.File{
 			files.MustNewFileFromSource(files.NewBytesSource("root.yml", rootYAML)),
 			files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/lib/config.yml", libConfigYAML)),
@@ -1936,7 +1936,7 @@ foo: #@ data.values.foo
      
      (data-value arg):
          |
-       1 | @lib:foo=42
+       1 | @lib:foo=forty-two
          |
      
          = found: string
@@ -0,0 +1,180 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestSchemaDataValuesTemplate_Renders_every_data_value_with_its_default(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+#@schema/desc "Configuration of the app"
+---
+#@schema/desc "Port to listen on"
+port: 8080
+#@schema/nullable
+nickname: ""
+#@schema/desc "TLS settings"
+tls:
+  enabled: false
+  #@schema/desc "Certificate\n(PEM encoded)"
+  cert: ""
+#@schema/default ["example.com"]
+hosts:
+- ""
+#@schema/type map_of=""
+labels: {}
+#@schema/deprecated "use 'port' instead"
+#@schema/type one_of=[0, ""]
+listen: 80
+#@schema/type any=True
+extra:
+  key: value
+`
+	expected := `#@data/values
+---
+#! Port to listen on
+#! type: integer
+port: 8080
+#! type: string (nullable)
+nickname: null
+#! TLS settings
+#! type: map
+tls:
+  #! type: boolean
+  enabled: false
+  #! Certificate
+  #! (PEM encoded)
+  #! type: string
+  cert: ""
+#! type: array of string
+#@overlay/replace
+hosts:
+- example.com
+#! type: map of string
+labels: {}
+#! type: one of (integer, string)
+#! DEPRECATED: use 'port' instead
+#! listen: 80
+#! type: any
+#@overlay/replace
+extra:
+  key: value
+`
+
+	t.Run("as a data values document", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.InspectSchema = true
+		opts.RegularFilesSourceOpts.OutputType.Types = []string{"data-values-template"}
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("that can be used as-is", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Inspect = true
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(expected))),
+		})
+
+		assertSucceedsDocSet(t, filesToProcess, `port: 8080
+nickname: null
+tls:
+  enabled: false
+  cert: ""
+hosts:
+- example.com
+labels: {}
+listen: 80
+extra:
+  key: value
+`, opts)
+	})
+}
+
+func TestSchemaDataValuesTemplate_When_no_schema_is_given(t *testing.T) {
+	opts := cmdtpl.NewOptions()
+	opts.DataValuesFlags.InspectSchema = true
+	opts.RegularFilesSourceOpts.OutputType.Types = []string{"data-values-template"}
+
+	templateYAML := `foo: bar
+`
+	expected := `#@data/values
+---
+#! No data values are declared in schema.
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+	})
+
+	assertSucceeds(t, filesToProcess, expected, opts)
+}
+
+func TestSchemaDataValuesTemplate_Includes_libraries(t *testing.T) {
+	rootSchemaYAML := `#@data/values-schema
+---
+app_name: ""
+
+#@library/ref "@db"
+#@data/values-schema
+---
+db_name: app
+`
+	libSchemaYAML := `#@data/values-schema
+---
+user: admin
+#@schema/desc "Name of the database"
+db_name: ""
+`
+	otherLibTemplateYAML := `foo: bar
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(rootSchemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/db/schema.yml", []byte(libSchemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("_ytt_lib/other/template.yml", []byte(otherLibTemplateYAML))),
+	})
+
+	t.Run("when requested, each with a schema", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.InspectSchema = true
+		opts.DataValuesFlags.InspectSchemaLibraries = true
+		opts.RegularFilesSourceOpts.OutputType.Types = []string{"data-values-template"}
+
+		expected := `#@data/values
+---
+#! type: string
+app_name: ""
+#@library/ref "@db"
+#@data/values
+---
+#! type: string
+user: admin
+#! Name of the database
+#! type: string
+db_name: app
+`
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("not in other output formats", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.InspectSchema = true
+		opts.DataValuesFlags.InspectSchemaLibraries = true
+		opts.RegularFilesSourceOpts.OutputType.Types = []string{"openapi-v3"}
+
+		expectedErr := "Inspecting schema of libraries only supported as a data values template; specify format with --output=data-values-template flag"
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
@@ -0,0 +1,249 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"bytes"
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/cmd/ui"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaDeprecated_Warns_when_deprecated_data_values_are_set(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+image:
+  #@schema/deprecated "use 'image.repo' instead"
+  name: ""
+  repo: nginx
+#@schema/deprecated "no longer used"
+replicas: 1
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+image: #@ data.values.image
+`
+
+	t.Run("when not set, does not warn", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		expected := `image:
+  name: ""
+  repo: nginx
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertSucceedsWithWarnings(t, filesToProcess, expected, "", opts)
+	})
+	t.Run("when set in data values files, warns at the position that set it", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		dataValuesYAML := `#@data/values
+---
+image:
+  name: httpd
+replicas: 3
+`
+		expected := `image:
+  name: httpd
+  repo: nginx
+`
+		expectedWarnings := `Warning: Data value 'image.name' is deprecated (set by values.yml:4): use 'image.repo' instead
+Warning: Data value 'replicas' is deprecated (set by values.yml:5): no longer used
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(dataValuesYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertSucceedsWithWarnings(t, filesToProcess, expected, expectedWarnings, opts)
+	})
+	t.Run("when set via command line flags or env vars", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"image.name=httpd"}
+		opts.DataValuesFlags.EnvFromYAML = []string{"DVS"}
+		opts.DataValuesFlags.EnvironFunc = func() []string { return []string{"DVS_replicas=2"} }
+		expected := `image:
+  name: httpd
+  repo: nginx
+`
+		expectedWarnings := `Warning: Data value 'replicas' is deprecated (set by (data-values-env-yaml arg) DVS:1): no longer used
+Warning: Data value 'image.name' is deprecated (set by (data-value arg):1): use 'image.repo' instead
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertSucceedsWithWarnings(t, filesToProcess, expected, expectedWarnings, opts)
+	})
+	t.Run("when deprecations are errors, fails", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.DeprecatedAsErrors = true
+		opts.DataValuesFlags.KVsFromYAML = []string{"replicas=2"}
+		expectedErr := `Data value 'replicas' is deprecated (set by (data-value-yaml arg):1): no longer used`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when set within array items", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		schemaYAML := `#@data/values-schema
+---
+servers:
+- host: ""
+  #@schema/deprecated "use 'tls' instead"
+  secure: false
+  tls: false
+`
+		dataValuesYAML := `#@data/values
+---
+servers:
+- host: a.example.com
+  tls: true
+- host: b.example.com
+  secure: true
+`
+		templateYAML := `#@ load("@ytt:data", "data")
+---
+servers: #@ data.values.servers
+`
+		expected := `servers:
+- host: a.example.com
+  tls: true
+  secure: false
+- host: b.example.com
+  secure: true
+  tls: false
+`
+		expectedWarnings := `Warning: Data value 'servers[].secure' is deprecated (set by values.yml:7): use 'tls' instead
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(dataValuesYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertSucceedsWithWarnings(t, filesToProcess, expected, expectedWarnings, opts)
+	})
+}
+
+func TestSchemaDeprecated_When_invalid_reports_error(t *testing.T) {
+	t.Run("when the message is missing", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		schemaYAML := `#@data/values-schema
+---
+#@schema/deprecated
+replicas: 1
+`
+		expectedErr := `
+Invalid schema
+==============
+
+syntax error in @schema/deprecated annotation
+schema.yml:
+    |
+  3 | #@schema/deprecated
+  4 | replicas: 1
+    |
+
+    = found: missing value in @schema/deprecated (by schema.yml:3)
+    = expected: string
+    = hint: explain what to use instead, e.g. @schema/deprecated "use 'image.repo' instead".
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when annotating an array item", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		schemaYAML := `#@data/values-schema
+---
+hosts:
+#@schema/deprecated "no longer used"
+- ""
+`
+		expectedErr := `
+Invalid schema - @schema/deprecated not supported on array item
+===============================================================
+
+schema.yml:
+    |
+  4 | #@schema/deprecated "no longer used"
+  5 | - ""
+    |
+
+
+
+    = hint: only keys of a map (i.e. data values themselves) can be deprecated.
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
+
+func TestSchemaDeprecated_Inspect_marks_deprecated(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+#@schema/deprecated "no longer used"
+replicas: 1
+`
+	opts := cmdtpl.NewOptions()
+	opts.DataValuesFlags.InspectSchema = true
+	opts.RegularFilesSourceOpts.OutputType.Types = []string{"openapi-v3"}
+	expected := `openapi: 3.0.0
+info:
+  version: 0.1.0
+  title: Schema for data values, generated by ytt
+paths: {}
+components:
+  schemas:
+    dataValues:
+      type: object
+      additionalProperties: false
+      properties:
+        replicas:
+          type: integer
+          default: 1
+          deprecated: true
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+	})
+
+	assertSucceedsDocSet(t, filesToProcess, expected, opts)
+}
+
+func assertSucceedsWithWarnings(t *testing.T, filesToProcess []*files.File, expectedOut, expectedWarnings string, opts *cmdtpl.Options) {
+	t.Helper()
+	stderr := bytes.NewBufferString("")
+	out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewCustomWriterTTY(false, nil, stderr))
+	require.NoError(t, out.Err)
+
+	require.Len(t, out.Files, 1, "unexpected number of output files")
+
+	require.Equal(t, expectedOut, string(out.Files[0].Bytes()))
+	require.Equal(t, expectedWarnings, stderr.String())
+}
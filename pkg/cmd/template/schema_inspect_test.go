@@ -624,8 +624,190 @@ components:
 	})
 }
 
+func TestSchemaInspect_exports_a_JSON_Schema_doc(t *testing.T) {
+	t.Run("for all inferred types with their inferred defaults", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.InspectSchema = true
+		opts.RegularFilesSourceOpts.OutputType.Types = []string{"json-schema"}
+
+		schemaYAML := `#@data/values-schema
+---
+foo:
+  int_key: 10
+  bool_key: true
+  string_key: some text
+  float_key: 9.1
+  array_of_maps:
+  - foo: ""
+`
+		expected := `$schema: https://json-schema.org/draft/2020-12/schema
+title: Schema for data values, generated by ytt
+type: object
+additionalProperties: false
+properties:
+  foo:
+    type: object
+    additionalProperties: false
+    properties:
+      int_key:
+        type: integer
+        default: 10
+      bool_key:
+        type: boolean
+        default: true
+      string_key:
+        type: string
+        default: some text
+      float_key:
+        type: number
+        default: 9.1
+      array_of_maps:
+        type: array
+        items:
+          type: object
+          additionalProperties: false
+          properties:
+            foo:
+              type: string
+              default: ""
+        default: []
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+	t.Run("including nullable, 'any', and described values", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.InspectSchema = true
+		opts.RegularFilesSourceOpts.OutputType.Types = []string{"json-schema"}
+
+		schemaYAML := `#@data/values-schema
+#@schema/desc "Configuration of the app"
+---
+#@schema/nullable
+#@schema/desc "Name shown to users"
+name: ""
+#@schema/nullable
+#@schema/default ["a"]
+tags:
+- ""
+#@schema/type any=True
+#@schema/desc "Passed through as-is"
+extra:
+  key: value
+`
+		expected := `$schema: https://json-schema.org/draft/2020-12/schema
+title: Schema for data values, generated by ytt
+type: object
+additionalProperties: false
+description: Configuration of the app
+properties:
+  name:
+    type:
+    - string
+    - "null"
+    default: null
+    description: Name shown to users
+  tags:
+    type:
+    - array
+    - "null"
+    items:
+      type: string
+      default: ""
+    default:
+    - a
+  extra:
+    default:
+      key: value
+    description: Passed through as-is
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+}
+
+func TestSchemaInspect_renders_Markdown_reference_docs(t *testing.T) {
+	opts := cmdtpl.NewOptions()
+	opts.DataValuesFlags.InspectSchema = true
+	opts.RegularFilesSourceOpts.OutputType.Types = []string{"schema-docs-markdown"}
+
+	schemaYAML := `#@data/values-schema
+---
+#@schema/desc "Port to listen on"
+port: 8080
+#@schema/nullable
+#@schema/desc "Name | alias"
+nickname: ""
+#@schema/desc "TLS settings"
+tls:
+  enabled: false
+  #@schema/desc "Certificate\n(PEM encoded)"
+  cert: ""
+#@schema/default ["example.com"]
+hosts:
+- ""
+containers:
+- name: ""
+  ports:
+  - 80
+#@schema/type any=True
+extra:
+  key: value
+#@schema/nullable
+#@schema/default 30
+timeout: 0
+#@schema/nullable
+#@schema/default ["a"]
+zones:
+- ""
+`
+	expected := `# Data Values Reference
+
+| Key | Type | Default | Nullable | Description | Source |
+|-----|------|---------|----------|-------------|--------|
+| ` + "`port`" + ` | integer | ` + "`8080`" + ` | no | Port to listen on | schema.yml:4 |
+| ` + "`nickname`" + ` | string | ` + "`null`" + ` | yes | Name \| alias | schema.yml:7 |
+| ` + "`tls`" + ` | map |  | no | TLS settings | schema.yml:9 |
+| ` + "`hosts`" + ` | array of string | ` + "`[\"example.com\"]`" + ` | no |  | schema.yml:14 |
+| ` + "`containers`" + ` | array of map | ` + "`[]`" + ` | no |  | schema.yml:16 |
+| ` + "`extra`" + ` | any | ` + "`{\"key\":\"value\"}`" + ` | no |  | schema.yml:21 |
+| ` + "`timeout`" + ` | integer | ` + "`30`" + ` | yes |  | schema.yml:25 |
+| ` + "`zones`" + ` | array of string | ` + "`[\"a\"]`" + ` | yes |  | schema.yml:28 |
+
+## ` + "`tls`" + `
+
+TLS settings
+
+| Key | Type | Default | Nullable | Description | Source |
+|-----|------|---------|----------|-------------|--------|
+| ` + "`tls.enabled`" + ` | boolean | ` + "`false`" + ` | no |  | schema.yml:10 |
+| ` + "`tls.cert`" + ` | string | ` + "`\"\"`" + ` | no | Certificate<br>(PEM encoded) | schema.yml:12 |
+
+## ` + "`containers[]`" + `
+
+| Key | Type | Default | Nullable | Description | Source |
+|-----|------|---------|----------|-------------|--------|
+| ` + "`containers[].name`" + ` | string | ` + "`\"\"`" + ` | no |  | schema.yml:17 |
+| ` + "`containers[].ports`" + ` | array of integer | ` + "`[]`" + ` | no |  | schema.yml:18 |
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+	})
+
+	assertSucceeds(t, filesToProcess, expected, opts)
+}
+
 func TestSchemaInspect_errors(t *testing.T) {
-	t.Run("when --output is anything other than 'openapi-v3'", func(t *testing.T) {
+	t.Run("when --output is anything other than 'openapi-v3', 'json-schema', 'schema-docs-markdown' or 'data-values-template'", func(t *testing.T) {
 		opts := cmdtpl.NewOptions()
 		opts.DataValuesFlags.InspectSchema = true
 
@@ -633,7 +815,7 @@ func TestSchemaInspect_errors(t *testing.T) {
 ---
 foo: doesn't matter
 `
-		expectedErr := "Data values schema export only supported in OpenAPI v3 format; specify format with --output=openapi-v3 flag"
+		expectedErr := "Data values schema export only supported in OpenAPI v3, JSON Schema, Markdown or data values template format; specify format with --output=openapi-v3, --output=json-schema, --output=schema-docs-markdown or --output=data-values-template flag"
 
 		filesToProcess := files.NewSortedFiles([]*files.File{
 			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
@@ -659,6 +841,27 @@ foo: doesn't matter
 
 		assertFails(t, filesToProcess, expectedErr, opts)
 	})
+
+	t.Run("when a validation rule value cannot be exported", func(t *testing.T) {
+		schemaYAML := `#@data/values-schema
+---
+#@schema/validation one_of=[1, len]
+port: 1
+`
+		expectedErr := "Converting value of validation rule 'one_of' (in @schema/validation): unknown type *starlark.Builtin for conversion to go value"
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		for _, output := range []string{"openapi-v3", "json-schema"} {
+			opts := cmdtpl.NewOptions()
+			opts.DataValuesFlags.InspectSchema = true
+			opts.RegularFilesSourceOpts.OutputType.Types = []string{output}
+
+			assertFails(t, filesToProcess, expectedErr, opts)
+		}
+	})
 }
 
 func assertSucceedsDocSet(t *testing.T, filesToProcess []*files.File, expectedOut string, opts *cmdtpl.Options) {
@@ -0,0 +1,317 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestSchemaMapOf_Permits_arbitrary_keys(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+#@schema/type map_of=""
+labels:
+  app: web
+#@schema/type map_of={"cpu": "1", "memory": "1Gi"}
+quotas: {}
+#@schema/type map_of=0
+#@schema/nullable
+ports: {}
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+labels: #@ data.values.labels
+quotas: #@ data.values.quotas
+ports: #@ data.values.ports
+`
+
+	t.Run("defaulting to the value given in schema", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		expected := `labels:
+  app: web
+quotas: {}
+ports: null
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("set in data values files (without @overlay/match missing_ok=True), defaulting nested values", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		dataValuesYAML := `#@data/values
+---
+labels:
+  tier: frontend
+quotas:
+  team-a:
+    cpu: "2"
+ports:
+  http: 80
+`
+		expected := `labels:
+  app: web
+  tier: frontend
+quotas:
+  team-a:
+    cpu: "2"
+    memory: 1Gi
+ports:
+  http: 80
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(dataValuesYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("set via command line flags", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"labels.tier=backend"}
+		opts.DataValuesFlags.KVsFromYAML = []string{"quotas.team-b.memory=2Gi", "ports.https=443"}
+		expected := `labels:
+  app: web
+  tier: backend
+quotas:
+  team-b:
+    memory: 2Gi
+    cpu: "1"
+ports:
+  https: 443
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+}
+
+func TestSchemaMapOf_Reports_values_of_the_wrong_type(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+#@schema/type map_of=""
+labels: {}
+#@schema/type map_of={"cpu": "1"}
+quotas: {}
+`
+
+	t.Run("when a value in the map is the wrong type", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		dataValuesYAML := `#@data/values
+---
+labels:
+  tier: 1
+`
+		expectedErr := `
+One or more data values were invalid
+====================================
+
+values.yml:
+    |
+  4 |   tier: 1
+    |
+
+    = found: integer
+    = expected: string (by schema.yml:3)
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(dataValuesYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when a value in the map contains an undeclared key", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		dataValuesYAML := `#@data/values
+---
+quotas:
+  team-a:
+    gpu: "1"
+`
+		expectedErr := `Given data value is not declared in schema`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(dataValuesYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when the value is not a map", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromYAML = []string{"labels=[]"}
+		expectedErr := `
+    = found: array
+    = expected: map of string (by schema.yml:4)
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
+
+func TestSchemaMapOf_When_invalid_reports_error(t *testing.T) {
+	t.Run("when the example value is None", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		schemaYAML := `#@data/values-schema
+---
+#@schema/type map_of=None
+labels: {}
+`
+		expectedErr := `
+Invalid schema
+==============
+
+invalid @schema/type annotation keyword argument 'map_of'
+schema.yml:
+    |
+  3 | #@schema/type map_of=None
+  4 | labels: {}
+    |
+
+    = found: None, which implies no type (by schema.yml:3)
+    = expected: an example value (of the type of each value in the map)
+    = hint: e.g. 'map_of=""' for a map of strings, 'map_of={"cpu": 1}' for a map of maps.
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when the default contains a value of the wrong type", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		schemaYAML := `#@data/values-schema
+---
+#@schema/type map_of=""
+labels:
+  app: 1
+`
+		expectedErr := `
+Invalid schema - value is wrong type
+====================================
+
+schema.yml:
+    |
+  5 |   app: 1
+    |
+
+    = found: integer
+    = expected: string (by schema.yml:3)
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
+
+func TestSchemaMapOf_Inspect_exports_additionalProperties(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+#@schema/desc "Labels of the app"
+#@schema/type map_of=""
+labels:
+  app: web
+#@schema/type map_of={"cpu": "1"}
+quotas: {}
+`
+
+	t.Run("in OpenAPI v3", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.InspectSchema = true
+		opts.RegularFilesSourceOpts.OutputType.Types = []string{"openapi-v3"}
+		expected := `openapi: 3.0.0
+info:
+  version: 0.1.0
+  title: Schema for data values, generated by ytt
+paths: {}
+components:
+  schemas:
+    dataValues:
+      type: object
+      additionalProperties: false
+      properties:
+        labels:
+          type: object
+          additionalProperties:
+            type: string
+            default: ""
+          description: Labels of the app
+          default:
+            app: web
+        quotas:
+          type: object
+          additionalProperties:
+            type: object
+            additionalProperties: false
+            properties:
+              cpu:
+                type: string
+                default: "1"
+          default: {}
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+	t.Run("in JSON Schema", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.InspectSchema = true
+		opts.RegularFilesSourceOpts.OutputType.Types = []string{"json-schema"}
+		expected := `$schema: https://json-schema.org/draft/2020-12/schema
+title: Schema for data values, generated by ytt
+type: object
+additionalProperties: false
+properties:
+  labels:
+    type: object
+    additionalProperties:
+      type: string
+      default: ""
+    description: Labels of the app
+    default:
+      app: web
+  quotas:
+    type: object
+    additionalProperties:
+      type: object
+      additionalProperties: false
+      properties:
+        cpu:
+          type: string
+          default: "1"
+    default: {}
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+}
@@ -0,0 +1,333 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestSchemaOneOf_Permits_a_value_of_any_alternative(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+#@schema/type one_of=["", {"repo": "", "tag": "latest"}]
+image: nginx:1.2
+#@schema/type one_of=[0, ""]
+port: 8080
+#@schema/type one_of=["", [""]]
+#@schema/nullable
+hosts: ""
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+image: #@ data.values.image
+port: #@ data.values.port
+hosts: #@ data.values.hosts
+`
+
+	t.Run("defaulting to the value given in schema", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		expected := `image: nginx:1.2
+port: 8080
+hosts: null
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("set in data values files, defaulting keys omitted from a map alternative", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		dataValuesYAML := `#@data/values
+---
+image:
+  repo: registry.example.com/nginx
+port: http
+hosts:
+- example.com
+`
+		expected := `image:
+  repo: registry.example.com/nginx
+  tag: latest
+port: http
+hosts:
+- example.com
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(dataValuesYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("set via command line flags", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"port=https"}
+		opts.DataValuesFlags.KVsFromYAML = []string{"image={tag: 1.2.3}", "hosts=example.com"}
+		expected := `image:
+  tag: 1.2.3
+  repo: ""
+port: https
+hosts: example.com
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+}
+
+func TestSchemaOneOf_Reports_values_matching_no_alternative(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+#@schema/type one_of=["", {"repo": "", "tag": "latest"}]
+image: nginx:1.2
+`
+
+	t.Run("when a scalar is none of the alternatives", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		dataValuesYAML := `#@data/values
+---
+image: 42
+`
+		expectedErr := `
+One or more data values were invalid
+====================================
+
+values.yml:
+    |
+  3 | image: 42
+    |
+
+    = found: integer
+    = expected: one of (string, map) (by schema.yml:4)
+    = hint: not string: found integer
+    = hint: not map: found integer
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(dataValuesYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when a map is none of the alternatives", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		dataValuesYAML := `#@data/values
+---
+image:
+  registry: example.com
+`
+		expectedErr := `
+One or more data values were invalid
+====================================
+
+values.yml:
+    |
+  3 | image:
+    |
+
+    = found: map
+    = expected: one of (string, map) (by schema.yml:4)
+    = hint: not string: found map
+    = hint: not map: Given data value is not declared in schema (found: registry; expected: one of { repo, tag } (from schema.yml:3))
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(dataValuesYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
+
+func TestSchemaOneOf_When_invalid_reports_error(t *testing.T) {
+	t.Run("when given fewer than two alternatives", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		schemaYAML := `#@data/values-schema
+---
+#@schema/type one_of=[""]
+image: nginx
+`
+		expectedErr := `
+Invalid schema
+==============
+
+invalid @schema/type annotation keyword argument 'one_of'
+schema.yml:
+    |
+  3 | #@schema/type one_of=[""]
+  4 | image: nginx
+    |
+
+    = found: 1 example value(s) (by schema.yml:3)
+    = expected: a list of at least two example values (one for each alternative)
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when an alternative is None", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		schemaYAML := `#@data/values-schema
+---
+#@schema/type one_of=["", None]
+image: nginx
+`
+		expectedErr := `
+    = found: None, which implies no type (by schema.yml:3)
+    = expected: a list of at least two example values (one for each alternative)
+    = hint: e.g. 'one_of=["", {"repo": "", "tag": ""}]' for either a string or a map.
+    = hint: to also allow null, annotate with @schema/nullable.
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when the default is none of the alternatives", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		schemaYAML := `#@data/values-schema
+---
+#@schema/type one_of=["", {"repo": "", "tag": "latest"}]
+image: 1.2
+`
+		expectedErr := `
+Invalid schema - value is wrong type
+====================================
+
+schema.yml:
+    |
+  4 | image: 1.2
+    |
+
+    = found: float
+    = expected: one of (string, map) (by schema.yml:4)
+    = hint: not string: found float
+    = hint: not map: found float
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
+
+func TestSchemaOneOf_Inspect_exports_oneOf(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+#@schema/desc "Image to deploy"
+#@schema/type one_of=["", {"repo": "", "tag": "latest"}]
+image: nginx:1.2
+#@schema/type one_of=[0, ""]
+#@schema/nullable
+port: 0
+`
+
+	t.Run("in OpenAPI v3", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.InspectSchema = true
+		opts.RegularFilesSourceOpts.OutputType.Types = []string{"openapi-v3"}
+		expected := `openapi: 3.0.0
+info:
+  version: 0.1.0
+  title: Schema for data values, generated by ytt
+paths: {}
+components:
+  schemas:
+    dataValues:
+      type: object
+      additionalProperties: false
+      properties:
+        image:
+          oneOf:
+          - type: string
+            default: ""
+          - type: object
+            additionalProperties: false
+            properties:
+              repo:
+                type: string
+                default: ""
+              tag:
+                type: string
+                default: latest
+          description: Image to deploy
+          default: nginx:1.2
+        port:
+          oneOf:
+          - type: integer
+            default: 0
+          - type: string
+            default: ""
+          default: null
+          nullable: true
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+	t.Run("in JSON Schema", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.InspectSchema = true
+		opts.RegularFilesSourceOpts.OutputType.Types = []string{"json-schema"}
+		expected := `$schema: https://json-schema.org/draft/2020-12/schema
+title: Schema for data values, generated by ytt
+type: object
+additionalProperties: false
+properties:
+  image:
+    oneOf:
+    - type: string
+      default: ""
+    - type: object
+      additionalProperties: false
+      properties:
+        repo:
+          type: string
+          default: ""
+        tag:
+          type: string
+          default: latest
+    description: Image to deploy
+    default: nginx:1.2
+  port:
+    oneOf:
+    - type: integer
+      default: 0
+    - type: string
+      default: ""
+    - type: "null"
+    default: null
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+}
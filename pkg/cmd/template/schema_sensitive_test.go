@@ -0,0 +1,259 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/cmd/ui"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaSensitive_Redacts_sensitive_data_values(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+db:
+  user: admin
+  #@schema/sensitive
+  password: ""
+`
+	valuesYAML := `#@data/values
+---
+db:
+  password: hunter2
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+db: #@ data.values.db
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+	})
+
+	t.Run("but templates receive the actual value", func(t *testing.T) {
+		expected := `db:
+  user: admin
+  password: hunter2
+`
+		assertSucceeds(t, filesToProcess, expected, cmdtpl.NewOptions())
+	})
+	t.Run("when inspected", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Inspect = true
+		expected := `db:
+  user: admin
+  password: (redacted)
+`
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+	t.Run("when inspected with positions", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.Inspect = true
+		opts.RegularFilesSourceOpts.OutputType.Types = []string{"pos"}
+
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewTTY(false))
+		require.NoError(t, out.Err)
+
+		outBytes, err := out.DocSet.AsBytesWithPrinter(func(w io.Writer) yamlmeta.DocumentPrinter {
+			return yamlmeta.WrappedFilePositionPrinter{Printer: yamlmeta.NewFilePositionPrinter(w)}
+		})
+		require.NoError(t, err)
+		require.Contains(t, string(outBytes), "password: (redacted)")
+		require.NotContains(t, string(outBytes), "hunter2")
+	})
+	t.Run("when inspected with provenance", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.InspectProvenance = true
+		expected := `db.user:
+  value: admin
+  sources:
+  - from: schema.yml:4 (schema default)
+    value: admin
+db.password:
+  value: (redacted)
+  sources:
+  - from: schema.yml:6 (schema default)
+    value: (redacted)
+  - from: values.yml:4
+    value: (redacted)
+`
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+}
+
+func TestSchemaSensitive_Redacts_sensitive_data_values_from_errors(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+#@schema/sensitive
+#@schema/validation min_len=10
+password: ""
+`
+
+	t.Run("in schema type check errors", func(t *testing.T) {
+		valuesYAML := `#@data/values
+---
+password: 12345
+`
+		expectedErr := `One or more data values were invalid
+====================================
+
+values.yml:
+    |
+  3 | password: (redacted)
+    |
+
+    = found: integer
+    = expected: string (by schema.yml:5)
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, cmdtpl.NewOptions())
+	})
+	t.Run("in schema validation errors", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"password=hunter2"}
+		expectedErr := `(data-value arg):
+    |
+  1 | password=(redacted)
+    |
+
+    = found: length 7
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("in template evaluation errors", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"password=hunter2hunter2"}
+		templateYAML := `#@ load("@ytt:data", "data")
+---
+password: #@ int(data.values.password)
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		out := opts.RunWithFiles(cmdtpl.Input{Files: filesToProcess}, ui.NewTTY(false))
+		require.Error(t, out.Err)
+		require.Contains(t, out.Err.Error(), "invalid literal with base 10: (redacted)")
+		require.NotContains(t, out.Err.Error(), "hunter2")
+	})
+	t.Run("in messages formatted by templates, as whole words, whatever the type of value", func(t *testing.T) {
+		schemaYAML := `#@data/values-schema
+---
+#@schema/sensitive
+pin: 0
+#@schema/sensitive
+initial: ""
+`
+		valuesYAML := `#@data/values
+---
+pin: 1234
+initial: a
+`
+		templateYAML := `#@ load("@ytt:data", "data")
+#@ load("@ytt:assert", "assert")
+---
+#@ assert.fail("pin={} initial={} pin2={}".format(data.values.pin, data.values.initial, data.values.pin+1))
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(valuesYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertFails(t, filesToProcess, "assert.fail: fail: pin=(redacted) initial=(redacted) pin2=1235", cmdtpl.NewOptions())
+	})
+}
+
+func TestSchemaSensitive_Sensitive_data_value_flags(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+db:
+  user: ""
+  password: ""
+  #@schema/type any=True
+  tls: null
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+db: #@ data.values.db
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+	})
+	flags := cmdtpl.DataValuesFlags{
+		KVsFromStrings:          []string{"db.user=admin"},
+		SensitiveKVsFromStrings: []string{"db.password=hunter2"},
+		SensitiveKVsFromYAML:    []string{"db.tls={cert: abc, key: xyz}"},
+		SensitiveKVsFromFiles:   []string{"db.user=user.txt"},
+		ReadFileFunc: func(path string) ([]byte, error) {
+			if path == "user.txt" {
+				return []byte("root"), nil
+			}
+			return nil, fmt.Errorf("Unknown file '%s'", path)
+		},
+	}
+
+	t.Run("are given to templates", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags = flags
+		expected := `db:
+  user: root
+  password: hunter2
+  tls:
+    cert: abc
+    key: xyz
+`
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("are redacted when inspected", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags = flags
+		opts.DataValuesFlags.Inspect = true
+		expected := `db:
+  user: (redacted)
+  password: (redacted)
+  tls: (redacted)
+`
+		assertSucceedsDocSet(t, filesToProcess, expected, opts)
+	})
+}
+
+func TestSchemaSensitive_Errors_when_misused(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+hosts:
+#@schema/sensitive
+- ""
+`
+	expectedErr := `Invalid schema - @schema/sensitive not supported on array item`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+	})
+
+	assertFails(t, filesToProcess, expectedErr, cmdtpl.NewOptions())
+}
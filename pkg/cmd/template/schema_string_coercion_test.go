@@ -0,0 +1,170 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestSchemaStringCoercion_Parses_strings_into_the_type_declared_in_schema(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+port: 8080
+ratio: 0.5
+enabled: false
+name: ""
+#@schema/nullable
+replicas: 1
+#@schema/type map_of=0
+limits: {}
+#@schema/type any=True
+extra: ""
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+port: #@ data.values.port
+ratio: #@ data.values.ratio
+enabled: #@ data.values.enabled
+name: #@ data.values.name
+replicas: #@ data.values.replicas
+limits: #@ data.values.limits
+extra: #@ data.values.extra
+`
+
+	t.Run("when given via --data-value", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"port=9090", "ratio=1.5", "enabled=true", "name=42",
+			"replicas=3", "limits.cpu=2", "extra=7"}
+		expected := `port: 9090
+ratio: 1.5
+enabled: true
+name: "42"
+replicas: 3
+limits:
+  cpu: 2
+extra: "7"
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("when given via --data-values-env", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.EnvFromStrings = []string{"DVS"}
+		opts.DataValuesFlags.EnvironFunc = func() []string {
+			return []string{"DVS_port=9090", "DVS_ratio=2", "DVS_enabled=true", "DVS_name=true", "DVS_limits__memory=512"}
+		}
+		expected := `port: 9090
+ratio: 2
+enabled: true
+name: "true"
+replicas: null
+limits:
+  memory: 512
+extra: ""
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertSucceeds(t, filesToProcess, expected, opts)
+	})
+	t.Run("when given via --data-value-yaml, types are as given", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromYAML = []string{`port="9090"`}
+		expectedErr := `
+    = found: string
+    = expected: integer (by schema.yml:3)
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
+
+func TestSchemaStringCoercion_Reports_the_source_of_values_that_do_not_parse(t *testing.T) {
+	schemaYAML := `#@data/values-schema
+---
+port: 8080
+enabled: false
+`
+
+	t.Run("when given via --data-value", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"enabled=yes please"}
+		expectedErr := `
+One or more data values were invalid
+====================================
+
+(data-value arg):
+    |
+  1 | enabled=yes please
+    |
+
+    = found: string
+    = expected: boolean (by schema.yml:4)
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when given via --data-values-env", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.EnvFromStrings = []string{"DVS"}
+		opts.DataValuesFlags.EnvironFunc = func() []string { return []string{"DVS_port=http"} }
+		expectedErr := `
+One or more data values were invalid
+====================================
+
+(data-values-env arg) DVS:
+    |
+  1 | DVS_port=http
+    |
+
+    = found: string
+    = expected: integer (by schema.yml:3)
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when a boolean is given other than as true or false", func(t *testing.T) {
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags.KVsFromStrings = []string{"enabled=1"}
+		expectedErr := `
+(data-value arg):
+    |
+  1 | enabled=1
+    |
+
+    = found: string
+    = expected: boolean (by schema.yml:4)
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
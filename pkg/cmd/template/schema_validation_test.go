@@ -0,0 +1,340 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template_test
+
+import (
+	"testing"
+
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestSchemaValidation_Passes_when_DataValues_satisfy_rules(t *testing.T) {
+	opts := cmdtpl.NewOptions()
+
+	schemaYAML := `#@data/values-schema
+---
+#@schema/validation min=1, max=65535
+port: 8080
+#@schema/validation ("must be even", lambda v: v % 2 == 0)
+replicas: 2
+#@schema/validation min_len=1
+hosts:
+#@schema/validation regex="^[a-z.]+$"
+- example.com
+#@schema/validation one_of=["debug", "info"]
+level: info
+#@schema/nullable
+#@schema/validation min_len=3
+nickname: ""
+`
+	dataValuesYAML := `#@data/values
+---
+port: 443
+replicas: 4
+hosts:
+- example.com
+- example.org
+level: debug
+`
+	templateYAML := `#@ load("@ytt:data", "data")
+---
+port: #@ data.values.port
+replicas: #@ data.values.replicas
+hosts: #@ data.values.hosts
+level: #@ data.values.level
+nickname: #@ data.values.nickname
+`
+	expected := `port: 443
+replicas: 4
+hosts:
+- example.com
+- example.org
+level: debug
+nickname: null
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(dataValuesYAML))),
+		files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+	})
+
+	assertSucceeds(t, filesToProcess, expected, opts)
+}
+
+func TestSchemaValidation_Reports_all_violations_of_final_DataValues(t *testing.T) {
+	opts := cmdtpl.NewOptions()
+
+	t.Run("of declarative rules", func(t *testing.T) {
+		schemaYAML := `#@data/values-schema
+---
+#@schema/validation min=1, max=65535
+port: 8080
+#@schema/validation min_len=1, max_len=2
+hosts:
+#@schema/validation regex="^[a-z.]+$"
+- example.com
+#@schema/validation one_of=["debug", "info"]
+level: info
+`
+		dataValuesYAML := `#@data/values
+---
+port: 0
+hosts:
+- example.com
+- EXAMPLE.org
+- example.net
+level: warn
+`
+		expectedErr := `
+One or more data values failed validation
+=========================================
+
+values.yml:
+    |
+  3 | port: 0
+    |
+
+    = found: 0
+    = expected: a value greater or equal to 1 (by schema.yml:3)
+
+schema.yml:
+    |
+  6 | hosts:
+    |
+
+    = found: length 3
+    = expected: length less than or equal to 2 (by schema.yml:5)
+
+values.yml:
+    |
+  6 | - EXAMPLE.org
+    |
+
+    = found: EXAMPLE.org
+    = expected: a string matching regular expression '^[a-z.]+$' (by schema.yml:7)
+
+values.yml:
+    |
+  8 | level: warn
+    |
+
+    = found: warn
+    = expected: one of ["debug", "info"] (by schema.yml:9)
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(dataValuesYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("of custom rules", func(t *testing.T) {
+		schemaYAML := `#@data/values-schema
+---
+#@schema/validation ("must be even", lambda v: v % 2 == 0)
+replicas: 2
+#@schema/validation ("must have a name", lambda v: v["name"] != "")
+owner:
+  name: ""
+`
+		dataValuesYAML := `#@data/values
+---
+replicas: 3
+`
+		expectedErr := `
+One or more data values failed validation
+=========================================
+
+values.yml:
+    |
+  3 | replicas: 3
+    |
+
+    = found: 3
+    = expected: must be even (by schema.yml:3)
+
+schema.yml:
+    |
+  6 | owner:
+    |
+
+    = found: map
+    = expected: must have a name (by schema.yml:5)
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(dataValuesYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("only once all data values have been overlaid", func(t *testing.T) {
+		schemaYAML := `#@data/values-schema
+---
+#@schema/validation min=1
+port: 8080
+`
+		dataValuesYAML := `#@data/values
+---
+port: 0
+`
+		templateYAML := `#@ load("@ytt:data", "data")
+---
+port: #@ data.values.port
+`
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("values.yml", []byte(dataValuesYAML))),
+			files.MustNewFileFromSource(files.NewBytesSource("template.yml", []byte(templateYAML))),
+		})
+
+		opts := cmdtpl.NewOptions()
+		opts.DataValuesFlags = cmdtpl.DataValuesFlags{KVsFromYAML: []string{"port=80"}}
+
+		assertSucceeds(t, filesToProcess, "port: 80\n", opts)
+	})
+}
+
+func TestSchemaValidation_When_invalid_reports_error(t *testing.T) {
+	opts := cmdtpl.NewOptions()
+
+	t.Run("when no rules are given", func(t *testing.T) {
+		schemaYAML := `#@data/values-schema
+---
+#@schema/validation
+port: 8080
+`
+		expectedErr := `
+Invalid schema
+==============
+
+syntax error in @schema/validation annotation
+schema.yml:
+    |
+  3 | #@schema/validation
+  4 | port: 8080
+    |
+
+    = found: missing rules in @schema/validation (by schema.yml:3)
+    = expected: one or more rules
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when a rule is not a (description, function) tuple", func(t *testing.T) {
+		schemaYAML := `#@data/values-schema
+---
+#@schema/validation lambda v: v > 0
+port: 8080
+`
+		expectedErr := `
+    = found: rule must be a tuple of (description, function), but was function (by schema.yml:3)
+    = expected: valid rules
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when a keyword argument is unknown", func(t *testing.T) {
+		schemaYAML := `#@data/values-schema
+---
+#@schema/validation minimum=1
+port: 8080
+`
+		expectedErr := `
+    = found: unknown keyword argument 'minimum' (by schema.yml:3)
+    = expected: valid rules
+    = hint: a rule is a tuple of a description and a function, e.g.: ("is even", lambda v: v % 2 == 0).
+    = hint: supported keyword arguments are min, max, min_len, max_len, one_of, regex.
+`
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+	t.Run("when regex is not a valid regular expression", func(t *testing.T) {
+		schemaYAML := `#@data/values-schema
+---
+#@schema/validation regex="[a-z"
+name: ""
+`
+		expectedErr := "= found: 'regex' is not a valid regular expression"
+
+		filesToProcess := files.NewSortedFiles([]*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+		})
+
+		assertFails(t, filesToProcess, expectedErr, opts)
+	})
+}
+
+func TestSchemaValidation_Inspect_exports_declarative_rules(t *testing.T) {
+	opts := cmdtpl.NewOptions()
+	opts.DataValuesFlags.InspectSchema = true
+	opts.RegularFilesSourceOpts.OutputType.Types = []string{"openapi-v3"}
+
+	schemaYAML := `#@data/values-schema
+---
+#@schema/validation ("must be even", lambda v: v % 2 == 0), min=1, max=65535
+port: 8080
+#@schema/validation min_len=1, max_len=3
+hosts:
+#@schema/validation regex="^[a-z.]+$", max_len=253
+- ""
+#@schema/validation one_of=["debug", "info"]
+level: info
+`
+	expected := `openapi: 3.0.0
+info:
+  version: 0.1.0
+  title: Schema for data values, generated by ytt
+paths: {}
+components:
+  schemas:
+    dataValues:
+      type: object
+      additionalProperties: false
+      properties:
+        port:
+          type: integer
+          default: 8080
+          minimum: 1
+          maximum: 65535
+        hosts:
+          type: array
+          items:
+            type: string
+            default: ""
+            maxLength: 253
+            pattern: ^[a-z.]+$
+          default: []
+          minItems: 1
+          maxItems: 3
+        level:
+          type: string
+          default: info
+          enum:
+          - debug
+          - info
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("schema.yml", []byte(schemaYAML))),
+	})
+
+	assertSucceedsDocSet(t, filesToProcess, expected, opts)
+}
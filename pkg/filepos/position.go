@@ -5,8 +5,12 @@ package filepos
 
 import (
 	"fmt"
+	"strings"
 )
 
+// RedactedValue is displayed in place of a sensitive value
+const RedactedValue = "(redacted)"
+
 type Position struct {
 	lineNum    *int // 1 based
 	file       string
@@ -53,6 +57,25 @@ func (p *Position) GetLine() string {
 	return p.line
 }
 
+// GetRedactedLine is the source line with the value it sets (if any) redacted; the key (if any) is retained
+// (e.g. "key: value", "key=value", "- value").
+func (p *Position) GetRedactedLine() string {
+	line := p.line
+	trimmed := strings.TrimLeft(line, " ")
+	indent := line[:len(line)-len(trimmed)]
+	switch {
+	case strings.HasPrefix(trimmed, "- "):
+		return indent + "- " + RedactedValue
+	case strings.Contains(trimmed, ": "):
+		return indent + trimmed[:strings.Index(trimmed, ": ")+2] + RedactedValue
+	case strings.HasSuffix(trimmed, ":"):
+		return line
+	case strings.Contains(trimmed, "="):
+		return indent + trimmed[:strings.Index(trimmed, "=")+1] + RedactedValue
+	}
+	return indent + RedactedValue
+}
+
 func (p *Position) AsString() string {
 	return "line " + p.AsCompactString()
 }
@@ -27,14 +27,25 @@ const (
 	TypeStarlark
 )
 
+// PatchType is the format of a file of patches (i.e. applied to other documents as if it were overlays)
+type PatchType int
+
+const (
+	PatchTypeNone PatchType = iota
+	PatchTypeJSON
+	PatchTypeStrategicMerge
+)
+
 type File struct {
 	src     Source
 	relPath string
 
-	markedRelPath   *string
-	markedType      *Type
-	markedTemplate  *bool
-	markedForOutput *bool
+	markedRelPath     *string
+	markedType        *Type
+	markedTemplate    *bool
+	markedForOutput   *bool
+	markedPatchType   *PatchType
+	markedPatchTarget *string
 
 	order int // lowest comes first; 0 is used to indicate unsorted
 }
@@ -219,6 +230,25 @@ func (r *File) IsForOutput() bool {
 	return r.isTemplate()
 }
 
+func (r *File) MarkPatchType(t PatchType) { r.markedPatchType = &t }
+
+func (r *File) PatchType() PatchType {
+	if r.markedPatchType != nil {
+		return *r.markedPatchType
+	}
+	return PatchTypeNone
+}
+
+// MarkPatchTarget selects the documents to which the patches in this file apply (e.g. "kind=Deployment,name=web")
+func (r *File) MarkPatchTarget(target string) { r.markedPatchTarget = &target }
+
+func (r *File) PatchTarget() string {
+	if r.markedPatchTarget != nil {
+		return *r.markedPatchTarget
+	}
+	return ""
+}
+
 func (r *File) MarkTemplate(template bool) { r.markedTemplate = &template }
 
 func (r *File) IsTemplate() bool {
@@ -5,6 +5,9 @@ package schema
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/k14s/starlark-go/starlark"
 	"github.com/k14s/ytt/pkg/filepos"
 	"github.com/k14s/ytt/pkg/template"
 	"github.com/k14s/ytt/pkg/template/core"
@@ -16,7 +19,14 @@ const (
 	AnnotationType         template.AnnotationName = "schema/type"
 	AnnotationDefault      template.AnnotationName = "schema/default"
 	AnnotationDescription  template.AnnotationName = "schema/desc"
+	AnnotationValidation   template.AnnotationName = "schema/validation"
+	AnnotationDeprecated   template.AnnotationName = "schema/deprecated"
+	AnnotationSensitive    template.AnnotationName = "schema/sensitive"
 	TypeAnnotationKwargAny string                  = "any"
+	// TypeAnnotationKwargMapOf declares a map with arbitrary keys, the values of which have the type of the given example
+	TypeAnnotationKwargMapOf string = "map_of"
+	// TypeAnnotationKwargOneOf declares a value that may be of any one of the types of the given examples
+	TypeAnnotationKwargOneOf string = "one_of"
 )
 
 type Annotation interface {
@@ -25,9 +35,11 @@ type Annotation interface {
 }
 
 type TypeAnnotation struct {
-	any  bool
-	node yamlmeta.Node
-	pos  *filepos.Position
+	any   bool
+	mapOf yamlmeta.Type
+	oneOf []yamlmeta.Type
+	node  yamlmeta.Node
+	pos   *filepos.Position
 }
 
 type NullableAnnotation struct {
@@ -47,6 +59,23 @@ type DescriptionAnnotation struct {
 	pos         *filepos.Position
 }
 
+// ValidationAnnotation is a wrapper for the rules provided via @schema/validation annotation
+type ValidationAnnotation struct {
+	validation *Validation
+	pos        *filepos.Position
+}
+
+// DeprecatedAnnotation marks a data value as going away (along with the reason or alternative to use)
+type DeprecatedAnnotation struct {
+	message string
+	pos     *filepos.Position
+}
+
+// SensitiveAnnotation marks a data value as secret (i.e. its value is redacted wherever it would be displayed)
+type SensitiveAnnotation struct {
+	pos *filepos.Position
+}
+
 // NewTypeAnnotation checks the keyword argument provided via @schema/type annotation, and returns wrapper for the annotated node.
 func NewTypeAnnotation(ann template.NodeAnnotation, node yamlmeta.Node) (*TypeAnnotation, error) {
 	if len(ann.Kwargs) == 0 {
@@ -56,7 +85,8 @@ func NewTypeAnnotation(ann template.NodeAnnotation, node yamlmeta.Node) (*TypeAn
 			description:  fmt.Sprintf("expected @%v annotation to have keyword argument and value", AnnotationType),
 			expected:     "valid keyword argument and value",
 			found:        fmt.Sprintf("missing keyword argument and value (by %s)", ann.Position.AsCompactString()),
-			hints:        []string{fmt.Sprintf("Supported key-value pairs are '%v=True', '%v=False'", TypeAnnotationKwargAny, TypeAnnotationKwargAny)},
+			hints: []string{fmt.Sprintf("Supported key-value pairs are '%v=True', '%v=False', '%v=<example value>', '%v=[<example value>, ...]'",
+				TypeAnnotationKwargAny, TypeAnnotationKwargAny, TypeAnnotationKwargMapOf, TypeAnnotationKwargOneOf)},
 		}
 	}
 	typeAnn := &TypeAnnotation{node: node, pos: ann.Position}
@@ -76,11 +106,41 @@ func NewTypeAnnotation(ann template.NodeAnnotation, node yamlmeta.Node) (*TypeAn
 					description:  "unknown @schema/type annotation keyword argument",
 					expected:     "starlark.Bool",
 					found:        fmt.Sprintf("%T (by %s)", kwarg[1], ann.Position.AsCompactString()),
-					hints:        []string{fmt.Sprintf("Supported kwargs are '%v'", TypeAnnotationKwargAny)},
+					hints:        []string{fmt.Sprintf("Supported kwargs are '%v', '%v', '%v'", TypeAnnotationKwargAny, TypeAnnotationKwargMapOf, TypeAnnotationKwargOneOf)},
 				}
 			}
 			typeAnn.any = isAnyType
 
+		case TypeAnnotationKwargMapOf:
+			valuesType, err := newMapOfValuesType(kwarg[1], ann.Position)
+			if err != nil {
+				return nil, schemaAssertionError{
+					annPositions: []*filepos.Position{ann.Position},
+					position:     node.GetPosition(),
+					description:  fmt.Sprintf("invalid @%v annotation keyword argument '%v'", AnnotationType, TypeAnnotationKwargMapOf),
+					expected:     "an example value (of the type of each value in the map)",
+					found:        fmt.Sprintf("%s (by %s)", err, ann.Position.AsCompactString()),
+					hints:        []string{fmt.Sprintf("e.g. '%v=\"\"' for a map of strings, '%v={\"cpu\": 1}' for a map of maps.", TypeAnnotationKwargMapOf, TypeAnnotationKwargMapOf)},
+				}
+			}
+			typeAnn.mapOf = valuesType
+
+		case TypeAnnotationKwargOneOf:
+			alternatives, err := newOneOfAlternatives(kwarg[1], ann.Position)
+			if err != nil {
+				return nil, schemaAssertionError{
+					annPositions: []*filepos.Position{ann.Position},
+					position:     node.GetPosition(),
+					description:  fmt.Sprintf("invalid @%v annotation keyword argument '%v'", AnnotationType, TypeAnnotationKwargOneOf),
+					expected:     "a list of at least two example values (one for each alternative)",
+					found:        fmt.Sprintf("%s (by %s)", err, ann.Position.AsCompactString()),
+					hints: []string{
+						fmt.Sprintf("e.g. '%v=[\"\", {\"repo\": \"\", \"tag\": \"\"}]' for either a string or a map.", TypeAnnotationKwargOneOf),
+						fmt.Sprintf("to also allow null, annotate with @%v.", AnnotationNullable)},
+				}
+			}
+			typeAnn.oneOf = alternatives
+
 		default:
 			return nil, schemaAssertionError{
 				annPositions: []*filepos.Position{ann.Position},
@@ -88,13 +148,64 @@ func NewTypeAnnotation(ann template.NodeAnnotation, node yamlmeta.Node) (*TypeAn
 				description:  "unknown @schema/type annotation keyword argument",
 				expected:     "A valid kwarg",
 				found:        fmt.Sprintf("%s (by %s)", argName, ann.Position.AsCompactString()),
-				hints:        []string{fmt.Sprintf("Supported kwargs are '%v'", TypeAnnotationKwargAny)},
+				hints:        []string{fmt.Sprintf("Supported kwargs are '%v', '%v', '%v'", TypeAnnotationKwargAny, TypeAnnotationKwargMapOf, TypeAnnotationKwargOneOf)},
 			}
 		}
 	}
 	return typeAnn, nil
 }
 
+// newMapOfValuesType infers the type of the values in an open map from the example value given to map_of=
+func newMapOfValuesType(example starlark.Value, pos *filepos.Position) (yamlmeta.Type, error) {
+	if example == starlark.None {
+		return nil, fmt.Errorf("None, which implies no type")
+	}
+	val, err := core.NewStarlarkValue(example).AsGoValue()
+	if err != nil {
+		return nil, err
+	}
+	valuesType, err := inferTypeFromValue(yamlmeta.NewASTFromInterfaceWithPosition(val, pos), pos)
+	if err != nil {
+		return nil, err
+	}
+	return valuesType, nil
+}
+
+// newOneOfAlternatives infers the type of each alternative from the example values given to one_of=
+func newOneOfAlternatives(examples starlark.Value, pos *filepos.Position) ([]yamlmeta.Type, error) {
+	var exampleList []starlark.Value
+	switch typedExamples := examples.(type) {
+	case *starlark.List:
+		for i := 0; i < typedExamples.Len(); i++ {
+			exampleList = append(exampleList, typedExamples.Index(i))
+		}
+	case starlark.Tuple:
+		exampleList = typedExamples
+	default:
+		return nil, fmt.Errorf("%s", examples.Type())
+	}
+	if len(exampleList) < 2 {
+		return nil, fmt.Errorf("%d example value(s)", len(exampleList))
+	}
+
+	var alternatives []yamlmeta.Type
+	for _, example := range exampleList {
+		if example == starlark.None {
+			return nil, fmt.Errorf("None, which implies no type")
+		}
+		val, err := core.NewStarlarkValue(example).AsGoValue()
+		if err != nil {
+			return nil, err
+		}
+		altType, err := inferTypeFromValue(yamlmeta.NewASTFromInterfaceWithPosition(val, pos), pos)
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, altType)
+	}
+	return alternatives, nil
+}
+
 // NewNullableAnnotation checks that there are no arguments, and returns wrapper for the annotated node.
 func NewNullableAnnotation(ann template.NodeAnnotation, node yamlmeta.Node) (*NullableAnnotation, error) {
 	if len(ann.Kwargs) != 0 {
@@ -196,11 +307,107 @@ func NewDescriptionAnnotation(ann template.NodeAnnotation, pos *filepos.Position
 	return &DescriptionAnnotation{strVal, ann.Position}, nil
 }
 
+// NewDeprecatedAnnotation validates the value from the AnnotationDeprecated, and returns the value
+func NewDeprecatedAnnotation(ann template.NodeAnnotation, pos *filepos.Position) (*DeprecatedAnnotation, error) {
+	if len(ann.Kwargs) != 0 {
+		return nil, schemaAssertionError{
+			annPositions: []*filepos.Position{ann.Position},
+			position:     pos,
+			description:  fmt.Sprintf("syntax error in @%v annotation", AnnotationDeprecated),
+			expected:     fmt.Sprintf("string"),
+			found:        fmt.Sprintf("keyword argument in @%v (by %v)", AnnotationDeprecated, ann.Position.AsCompactString()),
+			hints:        []string{"this annotation only accepts one argument: a string explaining what to use instead."},
+		}
+	}
+	switch numArgs := len(ann.Args); {
+	case numArgs == 0:
+		return nil, schemaAssertionError{
+			annPositions: []*filepos.Position{ann.Position},
+			position:     pos,
+			description:  fmt.Sprintf("syntax error in @%v annotation", AnnotationDeprecated),
+			expected:     fmt.Sprintf("string"),
+			found:        fmt.Sprintf("missing value in @%v (by %v)", AnnotationDeprecated, ann.Position.AsCompactString()),
+			hints:        []string{"explain what to use instead, e.g. @schema/deprecated \"use 'image.repo' instead\"."},
+		}
+	case numArgs > 1:
+		return nil, schemaAssertionError{
+			annPositions: []*filepos.Position{ann.Position},
+			position:     pos,
+			description:  fmt.Sprintf("syntax error in @%v annotation", AnnotationDeprecated),
+			expected:     fmt.Sprintf("string"),
+			found:        fmt.Sprintf("%v values in @%v (by %v)", numArgs, AnnotationDeprecated, ann.Position.AsCompactString()),
+		}
+	}
+
+	strVal, err := core.NewStarlarkValue(ann.Args[0]).AsString()
+	if err != nil {
+		return nil, schemaAssertionError{
+			annPositions: []*filepos.Position{ann.Position},
+			position:     pos,
+			description:  fmt.Sprintf("syntax error in @%v annotation", AnnotationDeprecated),
+			expected:     fmt.Sprintf("string"),
+			found:        fmt.Sprintf("Non-string value in @%v (by %v)", AnnotationDeprecated, ann.Position.AsCompactString()),
+		}
+	}
+	return &DeprecatedAnnotation{strVal, ann.Position}, nil
+}
+
+// NewSensitiveAnnotation checks that there are no arguments, and returns wrapper for the annotated node.
+func NewSensitiveAnnotation(ann template.NodeAnnotation, pos *filepos.Position) (*SensitiveAnnotation, error) {
+	if len(ann.Args) != 0 || len(ann.Kwargs) != 0 {
+		return nil, schemaAssertionError{
+			annPositions: []*filepos.Position{ann.Position},
+			position:     pos,
+			description:  fmt.Sprintf("syntax error in @%v annotation", AnnotationSensitive),
+			expected:     "no arguments",
+			found:        fmt.Sprintf("%v argument(s) in @%v (by %v)", len(ann.Args)+len(ann.Kwargs), AnnotationSensitive, ann.Position.AsCompactString()),
+		}
+	}
+	return &SensitiveAnnotation{ann.Position}, nil
+}
+
+// NewValidationAnnotation checks the rules provided via @schema/validation annotation, and returns wrapper for those rules.
+func NewValidationAnnotation(ann template.NodeAnnotation, pos *filepos.Position) (*ValidationAnnotation, error) {
+	if len(ann.Args) == 0 && len(ann.Kwargs) == 0 {
+		return nil, schemaAssertionError{
+			annPositions: []*filepos.Position{ann.Position},
+			position:     pos,
+			description:  fmt.Sprintf("syntax error in @%v annotation", AnnotationValidation),
+			expected:     "one or more rules",
+			found:        fmt.Sprintf("missing rules in @%v (by %v)", AnnotationValidation, ann.Position.AsCompactString()),
+			hints: []string{
+				"a rule is a tuple of a description and a function, e.g.: (\"is even\", lambda v: v % 2 == 0).",
+				fmt.Sprintf("supported keyword arguments are %s.", strings.Join(validationKwargNames, ", "))},
+		}
+	}
+
+	validation, err := NewValidation(ann.Args, ann.Kwargs, ann.Position)
+	if err != nil {
+		return nil, schemaAssertionError{
+			annPositions: []*filepos.Position{ann.Position},
+			position:     pos,
+			description:  fmt.Sprintf("syntax error in @%v annotation", AnnotationValidation),
+			expected:     "valid rules",
+			found:        fmt.Sprintf("%s (by %v)", err, ann.Position.AsCompactString()),
+			hints: []string{
+				"a rule is a tuple of a description and a function, e.g.: (\"is even\", lambda v: v % 2 == 0).",
+				fmt.Sprintf("supported keyword arguments are %s.", strings.Join(validationKwargNames, ", "))},
+		}
+	}
+	return &ValidationAnnotation{validation, ann.Position}, nil
+}
+
 // NewTypeFromAnn returns type information given by annotation.
 func (t *TypeAnnotation) NewTypeFromAnn() (yamlmeta.Type, error) {
 	if t.any {
 		return &AnyType{defaultValue: t.node.GetValues()[0], Position: t.node.GetPosition()}, nil
 	}
+	if t.mapOf != nil {
+		return &MapOfType{ValuesType: t.mapOf, Position: t.node.GetPosition()}, nil
+	}
+	if t.oneOf != nil {
+		return &OneOfType{Alternatives: t.oneOf, Position: t.node.GetPosition()}, nil
+	}
 	return nil, nil
 }
 
@@ -223,6 +430,11 @@ func (d *DescriptionAnnotation) NewTypeFromAnn() (yamlmeta.Type, error) {
 	return nil, nil
 }
 
+// NewTypeFromAnn returns type information given by annotation. ValidationAnnotation has no type information.
+func (v *ValidationAnnotation) NewTypeFromAnn() (yamlmeta.Type, error) {
+	return nil, nil
+}
+
 // GetPosition returns position of the source comment used to create this annotation.
 func (n *NullableAnnotation) GetPosition() *filepos.Position {
 	return n.pos
@@ -243,6 +455,31 @@ func (d *DescriptionAnnotation) GetPosition() *filepos.Position {
 	return d.pos
 }
 
+// GetPosition returns position of the source comment used to create this annotation.
+func (v *ValidationAnnotation) GetPosition() *filepos.Position {
+	return v.pos
+}
+
+// NewTypeFromAnn returns type information given by annotation. DeprecatedAnnotation has no type information.
+func (d *DeprecatedAnnotation) NewTypeFromAnn() (yamlmeta.Type, error) {
+	return nil, nil
+}
+
+// GetPosition returns position of the source comment used to create this annotation.
+func (d *DeprecatedAnnotation) GetPosition() *filepos.Position {
+	return d.pos
+}
+
+// NewTypeFromAnn returns type information given by annotation. SensitiveAnnotation has no type information.
+func (s *SensitiveAnnotation) NewTypeFromAnn() (yamlmeta.Type, error) {
+	return nil, nil
+}
+
+// GetPosition returns position of the source comment used to create this annotation.
+func (s *SensitiveAnnotation) GetPosition() *filepos.Position {
+	return s.pos
+}
+
 func (t *TypeAnnotation) IsAny() bool {
 	return t.any
 }
@@ -298,6 +535,42 @@ func collectDocumentationAnnotations(node yamlmeta.Node) ([]Annotation, error) {
 	return anns, nil
 }
 
+// collectValidationAnnotation provides the rules (if any) that values of the node must satisfy
+func collectValidationAnnotation(node yamlmeta.Node) (*Validation, error) {
+	ann, err := processOptionalAnnotation(node, AnnotationValidation, nil)
+	if err != nil {
+		return nil, err
+	}
+	if validationAnn, ok := ann.(*ValidationAnnotation); ok {
+		return validationAnn.validation, nil
+	}
+	return nil, nil
+}
+
+// collectDeprecatedAnnotation provides the deprecation notice (if any) of the node
+func collectDeprecatedAnnotation(node yamlmeta.Node) (*DeprecatedAnnotation, error) {
+	ann, err := processOptionalAnnotation(node, AnnotationDeprecated, nil)
+	if err != nil {
+		return nil, err
+	}
+	if deprecatedAnn, ok := ann.(*DeprecatedAnnotation); ok {
+		return deprecatedAnn, nil
+	}
+	return nil, nil
+}
+
+// collectSensitiveAnnotation provides the @schema/sensitive annotation (if any) of the node
+func collectSensitiveAnnotation(node yamlmeta.Node) (*SensitiveAnnotation, error) {
+	ann, err := processOptionalAnnotation(node, AnnotationSensitive, nil)
+	if err != nil {
+		return nil, err
+	}
+	if sensitiveAnn, ok := ann.(*SensitiveAnnotation); ok {
+		return sensitiveAnn, nil
+	}
+	return nil, nil
+}
+
 func processOptionalAnnotation(node yamlmeta.Node, optionalAnnotation template.AnnotationName, effectiveType yamlmeta.Type) (Annotation, error) {
 	nodeAnnotations := template.NewAnnotations(node)
 
@@ -346,6 +619,40 @@ func processOptionalAnnotation(node yamlmeta.Node, optionalAnnotation template.A
 				return nil, err
 			}
 			return descAnn, nil
+		case AnnotationValidation:
+			validationAnn, err := NewValidationAnnotation(ann, node.GetPosition())
+			if err != nil {
+				return nil, err
+			}
+			return validationAnn, nil
+		case AnnotationDeprecated:
+			if _, isMapItem := node.(*yamlmeta.MapItem); !isMapItem {
+				return nil, NewSchemaError(fmt.Sprintf("Invalid schema - @%v not supported on %s", AnnotationDeprecated, node.DisplayName()),
+					schemaAssertionError{
+						annPositions: []*filepos.Position{ann.Position},
+						position:     node.GetPosition(),
+						hints:        []string{"only keys of a map (i.e. data values themselves) can be deprecated."},
+					})
+			}
+			deprecatedAnn, err := NewDeprecatedAnnotation(ann, node.GetPosition())
+			if err != nil {
+				return nil, err
+			}
+			return deprecatedAnn, nil
+		case AnnotationSensitive:
+			if _, isMapItem := node.(*yamlmeta.MapItem); !isMapItem {
+				return nil, NewSchemaError(fmt.Sprintf("Invalid schema - @%v not supported on %s", AnnotationSensitive, node.DisplayName()),
+					schemaAssertionError{
+						annPositions: []*filepos.Position{ann.Position},
+						position:     node.GetPosition(),
+						hints:        []string{"only keys of a map (i.e. data values themselves) can be sensitive."},
+					})
+			}
+			sensitiveAnn, err := NewSensitiveAnnotation(ann, node.GetPosition())
+			if err != nil {
+				return nil, err
+			}
+			return sensitiveAnn, nil
 		}
 	}
 
@@ -398,6 +705,18 @@ func getTypeFromAnnotations(anns []Annotation, pos *filepos.Position) (yamlmeta.
 	if err != nil {
 		return nil, err
 	}
+
+	// a nullable open map (or union) remains an open map (or union)
+	if nullType, ok := typeFromAnn.(*NullType); ok {
+		for _, ann := range annsCopy {
+			if typeAnn, ok := ann.(*TypeAnnotation); ok && (typeAnn.mapOf != nil || typeAnn.oneOf != nil) {
+				nullType.ValueType, err = typeAnn.NewTypeFromAnn()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
 	return typeFromAnn, nil
 }
 
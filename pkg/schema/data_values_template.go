@@ -0,0 +1,160 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// DataValuesTemplate holds the document type used for creating a starter data values file
+type DataValuesTemplate struct {
+	docType *DocumentType
+	libRef  string
+}
+
+// NewDataValuesTemplate creates an instance of DataValuesTemplate based on the given DocumentType.
+// When `libRef` is not empty, the data values document is targeted at that library (i.e. via @library/ref).
+func NewDataValuesTemplate(docType *DocumentType, libRef string) *DataValuesTemplate {
+	return &DataValuesTemplate{docType, libRef}
+}
+
+// AsBytes renders a ready-to-edit data values document (as YAML) containing every data value described by
+// `docType` set to its default. Descriptions and type information are included as comments.
+func (d *DataValuesTemplate) AsBytes() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if d.libRef != "" {
+		buf.WriteString(fmt.Sprintf("#@library/ref %q\n", d.libRef))
+	}
+	buf.WriteString("#@data/values\n---\n")
+
+	valueType := d.docType.GetValueType()
+	if _, isAny := valueType.(*AnyType); isAny {
+		buf.WriteString("#! No data values are declared in schema.\n")
+		return buf.Bytes(), nil
+	}
+	mapType, isMap := valueType.(*MapType)
+	if !isMap {
+		return nil, fmt.Errorf("Expected data values schema to describe a map, but was %s", valueType.String())
+	}
+	if len(mapType.Items) == 0 {
+		buf.WriteString("{}\n")
+		return buf.Bytes(), nil
+	}
+	err := d.writeMap(buf, mapType, "")
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *DataValuesTemplate) writeMap(buf *bytes.Buffer, mapType *MapType, indent string) error {
+	for _, item := range mapType.Items {
+		valueType, nullable := d.unwrapNullable(item.GetValueType())
+
+		d.writeComment(buf, indent, item.GetValueType().GetDescription())
+		if nullable {
+			d.writeComment(buf, indent, "type: "+d.typeHint(valueType)+" (nullable)")
+		} else {
+			d.writeComment(buf, indent, "type: "+d.typeHint(valueType))
+		}
+
+		if !item.IsDeprecated() {
+			err := d.writeItem(buf, item, valueType, nullable, indent)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// deprecated data values are listed, but not set (which would produce a warning)
+		d.writeComment(buf, indent, "DEPRECATED: "+item.GetDeprecationMessage())
+		itemBuf := &bytes.Buffer{}
+		err := d.writeItem(itemBuf, item, valueType, nullable, "")
+		if err != nil {
+			return err
+		}
+		d.writeComment(buf, indent, itemBuf.String())
+	}
+	return nil
+}
+
+func (d *DataValuesTemplate) writeItem(buf *bytes.Buffer, item *MapItemType, valueType yamlmeta.Type, nullable bool, indent string) error {
+	// only maps that are sure to have keys are expanded: all other values are rendered whole
+	if nestedMap, isMap := valueType.(*MapType); isMap && !nullable && len(nestedMap.Items) > 0 {
+		key, err := d.asYAML(item.Key)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(indent + strings.TrimSpace(key) + ":\n")
+		return d.writeMap(buf, nestedMap, indent+"  ")
+	}
+
+	defaultItem := item.GetDefaultValue().(*yamlmeta.MapItem)
+
+	// data values files merge into collections: a non-empty default is to be replaced by what is given instead
+	if d.isNonEmptyCollection(defaultItem.Value) {
+		buf.WriteString(indent + "#@overlay/replace\n")
+	}
+
+	itemYAML, err := d.asYAML(&yamlmeta.Map{Items: []*yamlmeta.MapItem{defaultItem}})
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(itemYAML, "\n"), "\n") {
+		buf.WriteString(indent + line)
+	}
+	buf.WriteString("\n")
+	return nil
+}
+
+// writeComment writes `text` as a ytt comment (i.e. one that is ignored when the file is used as data values)
+func (d *DataValuesTemplate) writeComment(buf *bytes.Buffer, indent, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		buf.WriteString(strings.TrimRight(indent+"#! "+line, " ") + "\n")
+	}
+}
+
+func (d *DataValuesTemplate) unwrapNullable(typ yamlmeta.Type) (yamlmeta.Type, bool) {
+	if nullType, ok := typ.(*NullType); ok {
+		return nullType.GetValueType(), true
+	}
+	return typ, false
+}
+
+func (d *DataValuesTemplate) isNonEmptyCollection(val interface{}) bool {
+	switch typedVal := val.(type) {
+	case *yamlmeta.Array:
+		return len(typedVal.Items) > 0
+	case *yamlmeta.Map:
+		return len(typedVal.Items) > 0
+	}
+	return false
+}
+
+func (d *DataValuesTemplate) typeHint(typ yamlmeta.Type) string {
+	if arrayType, ok := typ.(*ArrayType); ok {
+		itemType, nullable := d.unwrapNullable(arrayType.GetValueType().GetValueType())
+		if nullable {
+			return fmt.Sprintf("array of (nullable) %s", d.typeHint(itemType))
+		}
+		return fmt.Sprintf("array of %s", d.typeHint(itemType))
+	}
+	return typ.String()
+}
+
+func (d *DataValuesTemplate) asYAML(val interface{}) (string, error) {
+	bs, err := (&yamlmeta.Document{Value: val}).AsYAMLBytes()
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
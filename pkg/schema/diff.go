@@ -0,0 +1,235 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/k14s/starlark-go/starlark"
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// Change describes a single difference between two versions of a data values schema
+type Change struct {
+	Path        string
+	Description string
+	// Breaking indicates that data values accepted by the old schema might be rejected (or mean something else) in the new
+	Breaking    bool
+	OldPosition *filepos.Position
+	NewPosition *filepos.Position
+}
+
+// Diff compares an old and a new version of a data values schema, listing the changes a consumer would notice.
+func Diff(oldDocType, newDocType *DocumentType) []Change {
+	d := &schemaDiff{thread: &starlark.Thread{Name: "schema-diff"}}
+	d.compare("", oldDocType.GetValueType(), newDocType.GetValueType())
+	return d.changes
+}
+
+type schemaDiff struct {
+	changes []Change
+	thread  *starlark.Thread
+}
+
+func (d *schemaDiff) compare(path string, oldType, newType yamlmeta.Type) {
+	oldValueType, oldNullable := d.unwrapNullable(oldType)
+	newValueType, newNullable := d.unwrapNullable(newType)
+
+	_, newIsAny := newValueType.(*AnyType)
+	switch {
+	case oldNullable && !newNullable && !newIsAny:
+		d.add(path, "is no longer nullable", true, oldType, newType)
+	case !oldNullable && newNullable:
+		d.add(path, "is now nullable", false, oldType, newType)
+	}
+
+	oldMap, oldIsMap := oldValueType.(*MapType)
+	newMap, newIsMap := newValueType.(*MapType)
+	if oldIsMap && newIsMap {
+		d.compareMaps(path, oldMap, newMap)
+		return
+	}
+
+	if !d.accepts(newValueType, oldValueType) {
+		d.add(path, fmt.Sprintf("type narrowed from %s to %s", d.typeName(oldValueType), d.typeName(newValueType)), true, oldType, newType)
+		return
+	}
+	if d.typeName(oldValueType) != d.typeName(newValueType) {
+		d.add(path, fmt.Sprintf("type widened from %s to %s", d.typeName(oldValueType), d.typeName(newValueType)), false, oldType, newType)
+	}
+
+	oldArray, oldIsArray := oldValueType.(*ArrayType)
+	newArray, newIsArray := newValueType.(*ArrayType)
+	if oldIsArray && newIsArray {
+		d.compare(path+"[]", oldArray.GetValueType().GetValueType(), newArray.GetValueType().GetValueType())
+	}
+	oldMapOf, oldIsMapOf := oldValueType.(*MapOfType)
+	newMapOf, newIsMapOf := newValueType.(*MapOfType)
+	if oldIsMapOf && newIsMapOf {
+		d.compare(path+".*", oldMapOf.GetValueType(), newMapOf.GetValueType())
+	}
+}
+
+func (d *schemaDiff) compareMaps(path string, oldMap, newMap *MapType) {
+	for _, oldItem := range oldMap.Items {
+		itemPath := d.itemPath(path, oldItem.Key)
+		newItem := d.findItem(newMap, oldItem.Key)
+		if newItem == nil {
+			d.add(itemPath, "was removed", true, oldItem, nil)
+			continue
+		}
+		d.compare(itemPath, oldItem.GetValueType(), newItem.GetValueType())
+
+		oldDefault, oldHasDefault := d.defaultValue(oldItem)
+		newDefault, newHasDefault := d.defaultValue(newItem)
+		if oldHasDefault && newHasDefault && oldDefault != newDefault {
+			d.add(itemPath, fmt.Sprintf("default changed from %s to %s", oldDefault, newDefault), true, oldItem, newItem)
+		}
+	}
+
+	for _, newItem := range newMap.Items {
+		if d.findItem(oldMap, newItem.Key) != nil {
+			continue
+		}
+		itemPath := d.itemPath(path, newItem.Key)
+		// a data value that must be set (i.e. whose default is not valid) is required of every consumer
+		if newItem.GetValidation() != nil {
+			defaultVal := newItem.GetDefaultValue().(*yamlmeta.MapItem).Value
+			if violations := newItem.GetValidation().Validate(defaultVal, newItem.GetDefinitionPosition(), newItem.IsSensitive(), d.thread); len(violations) > 0 {
+				d.add(itemPath, "was added, without a valid default (i.e. must be set)", true, nil, newItem)
+				continue
+			}
+		}
+		d.add(itemPath, "was added", false, nil, newItem)
+	}
+}
+
+// accepts determines whether every value of `oldType` is also a value of `newType`
+func (d *schemaDiff) accepts(newType, oldType yamlmeta.Type) bool {
+	oldType, oldNullable := d.unwrapNullable(oldType)
+	newType, newNullable := d.unwrapNullable(newType)
+
+	if _, newIsAny := newType.(*AnyType); newIsAny {
+		return true
+	}
+	if oldNullable && !newNullable {
+		return false
+	}
+	if oldOneOf, oldIsOneOf := oldType.(*OneOfType); oldIsOneOf {
+		for _, oldAlt := range oldOneOf.Alternatives {
+			if !d.accepts(newType, oldAlt) {
+				return false
+			}
+		}
+		return true
+	}
+	if newOneOf, newIsOneOf := newType.(*OneOfType); newIsOneOf {
+		for _, newAlt := range newOneOf.Alternatives {
+			if d.accepts(newAlt, oldType) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch typedOld := oldType.(type) {
+	case *ScalarType:
+		typedNew, ok := newType.(*ScalarType)
+		if !ok {
+			return false
+		}
+		_, oldIsInt := typedOld.ValueType.(int)
+		_, newIsFloat := typedNew.ValueType.(float64)
+		return typedOld.String() == typedNew.String() || (oldIsInt && newIsFloat)
+	case *ArrayType:
+		typedNew, ok := newType.(*ArrayType)
+		return ok && d.accepts(typedNew.GetValueType().GetValueType(), typedOld.GetValueType().GetValueType())
+	case *MapOfType:
+		typedNew, ok := newType.(*MapOfType)
+		return ok && d.accepts(typedNew.GetValueType(), typedOld.GetValueType())
+	case *MapType:
+		switch typedNew := newType.(type) {
+		case *MapType:
+			for _, oldItem := range typedOld.Items {
+				newItem := d.findItem(typedNew, oldItem.Key)
+				if newItem == nil || !d.accepts(newItem.GetValueType(), oldItem.GetValueType()) {
+					return false
+				}
+			}
+			return true
+		case *MapOfType:
+			for _, oldItem := range typedOld.Items {
+				if !d.accepts(typedNew.GetValueType(), oldItem.GetValueType()) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// defaultValue renders the default of a map item (as JSON); maps have none of their own: their keys have defaults.
+func (d *schemaDiff) defaultValue(item *MapItemType) (string, bool) {
+	if _, isMap := item.GetValueType().(*MapType); isMap {
+		return "", false
+	}
+	val := yamlmeta.NewGoFromAST(item.GetDefaultValue().(*yamlmeta.MapItem).Value)
+	bs, err := json.Marshal(orderedmap.Conversion{val}.AsUnorderedStringMaps())
+	if err != nil {
+		return fmt.Sprintf("%v", val), true
+	}
+	return string(bs), true
+}
+
+func (d *schemaDiff) findItem(mapType *MapType, key interface{}) *MapItemType {
+	for _, item := range mapType.Items {
+		if item.Key == key {
+			return item
+		}
+	}
+	return nil
+}
+
+func (d *schemaDiff) itemPath(path string, key interface{}) string {
+	if path == "" {
+		return fmt.Sprintf("%v", key)
+	}
+	return fmt.Sprintf("%s.%v", path, key)
+}
+
+func (d *schemaDiff) unwrapNullable(typ yamlmeta.Type) (yamlmeta.Type, bool) {
+	if nullType, ok := typ.(*NullType); ok {
+		return nullType.GetValueType(), true
+	}
+	return typ, false
+}
+
+func (d *schemaDiff) typeName(typ yamlmeta.Type) string {
+	if arrayType, ok := typ.(*ArrayType); ok {
+		itemType, nullable := d.unwrapNullable(arrayType.GetValueType().GetValueType())
+		if nullable {
+			return fmt.Sprintf("array of (nullable) %s", d.typeName(itemType))
+		}
+		return fmt.Sprintf("array of %s", d.typeName(itemType))
+	}
+	return typ.String()
+}
+
+func (d *schemaDiff) add(path, desc string, breaking bool, oldType, newType yamlmeta.Type) {
+	if path == "" {
+		path = "(root)"
+	}
+	change := Change{Path: path, Description: desc, Breaking: breaking}
+	if oldType != nil {
+		change.OldPosition = oldType.GetDefinitionPosition()
+	}
+	if newType != nil {
+		change.NewPosition = newType.GetDefinitionPosition()
+	}
+	d.changes = append(d.changes, change)
+}
@@ -60,11 +60,11 @@ func NewSchemaError(summary string, errs ...error) error {
 			failures = append(failures, assertionFailure{
 				Description: typeCheckAssertionErr.description,
 				FileName:    typeCheckAssertionErr.position.GetFile(),
-				Positions:   createPosInfo(typeCheckAssertionErr.annPositions, typeCheckAssertionErr.position),
+				Positions:   createPosInfo(typeCheckAssertionErr.annPositions, typeCheckAssertionErr.position, typeCheckAssertionErr.sensitive),
 				FilePos:     typeCheckAssertionErr.position.AsIntString(),
 				FromMemory:  typeCheckAssertionErr.position.FromMemory(),
 				SourceName:  "Data value calculated",
-				Source:      typeCheckAssertionErr.position.GetLine(),
+				Source:      sourceLine(typeCheckAssertionErr.position, typeCheckAssertionErr.sensitive),
 				Expected:    typeCheckAssertionErr.expected,
 				Found:       typeCheckAssertionErr.found,
 				Hints:       typeCheckAssertionErr.hints,
@@ -93,20 +93,48 @@ func NewMismatchedTypeAssertionError(foundType yamlmeta.TypeWithValues, expected
 	}
 
 	return schemaAssertionError{
-		position: foundType.GetPosition(),
-		expected: fmt.Sprintf("%s (by %s)", expectedTypeString, expectedType.GetDefinitionPosition().AsCompactString()),
-		found:    foundType.ValueTypeAsString(),
+		position:  foundType.GetPosition(),
+		sensitive: yamlmeta.IsSensitive(foundType),
+		expected:  fmt.Sprintf("%s (by %s)", expectedTypeString, expectedType.GetDefinitionPosition().AsCompactString()),
+		found:     foundType.ValueTypeAsString(),
 		// TODO: remove this hint once we can report if mistyped value came from annotation
 		hints: []string{fmt.Sprintf("is the default value set using @%v?", AnnotationDefault)},
 	}
 }
 
+// NewMismatchedAlternativesAssertionError generates a schema assertion error for a value that is none of the
+// alternatives of `expectedType`, explaining (via hints) why each alternative did not match.
+func NewMismatchedAlternativesAssertionError(foundType yamlmeta.TypeWithValues, expectedType *OneOfType, altViolations [][]error) error {
+	var hints []string
+	for i, violations := range altViolations {
+		reason := "did not match"
+		if len(violations) > 0 {
+			if assertionErr, ok := violations[0].(schemaAssertionError); ok {
+				reason = fmt.Sprintf("found %s", assertionErr.found)
+				if assertionErr.description != "" {
+					reason = fmt.Sprintf("%s (found: %s; expected: %s)", assertionErr.description, assertionErr.found, assertionErr.expected)
+				}
+			}
+		}
+		hints = append(hints, fmt.Sprintf("not %s: %s", expectedType.Alternatives[i].String(), reason))
+	}
+
+	return schemaAssertionError{
+		position:  foundType.GetPosition(),
+		sensitive: yamlmeta.IsSensitive(foundType),
+		expected:  fmt.Sprintf("%s (by %s)", expectedType.String(), expectedType.GetDefinitionPosition().AsCompactString()),
+		found:     foundType.ValueTypeAsString(),
+		hints:     hints,
+	}
+}
+
 // NewUnexpectedKeyAssertionError generates a schema assertion error including the context (and hints) needed to report it to the user
 func NewUnexpectedKeyAssertionError(found *yamlmeta.MapItem, definition *filepos.Position, allowedKeys []string) error {
 	key := fmt.Sprintf("%v", found.Key)
 	err := schemaAssertionError{
 		description: "Given data value is not declared in schema",
 		position:    found.GetPosition(),
+		sensitive:   found.Sensitive,
 		found:       key,
 	}
 	sort.Strings(allowedKeys)
@@ -148,6 +176,7 @@ type schemaAssertionError struct {
 	error
 	annPositions []*filepos.Position
 	position     *filepos.Position
+	sensitive    bool // the value at position is not to be displayed
 	description  string
 	expected     string
 	found        string
@@ -160,7 +189,7 @@ type posInfo struct {
 	SkipLines bool
 }
 
-func createPosInfo(annPosList []*filepos.Position, nodePos *filepos.Position) []posInfo {
+func createPosInfo(annPosList []*filepos.Position, nodePos *filepos.Position, nodeSensitive bool) []posInfo {
 	sort.SliceStable(annPosList, func(i, j int) bool {
 		if !annPosList[i].IsKnown() {
 			return true
@@ -178,11 +207,23 @@ func createPosInfo(annPosList []*filepos.Position, nodePos *filepos.Position) []
 		if i > 0 {
 			skipLines = !p.IsNextTo(allPositions[i-1])
 		}
-		positionsInfo = append(positionsInfo, posInfo{Pos: p.AsIntString(), Source: p.GetLine(), SkipLines: skipLines})
+		source := p.GetLine()
+		if i == len(allPositions)-1 {
+			source = sourceLine(p, nodeSensitive)
+		}
+		positionsInfo = append(positionsInfo, posInfo{Pos: p.AsIntString(), Source: source, SkipLines: skipLines})
 	}
 	return positionsInfo
 }
 
+// sourceLine is the line at `pos`; if the value set there is `sensitive`, that value is redacted.
+func sourceLine(pos *filepos.Position, sensitive bool) string {
+	if sensitive {
+		return pos.GetRedactedLine()
+	}
+	return pos.GetLine()
+}
+
 func (e schemaError) Error() string {
 	maxFilePos := 0
 	for _, hunk := range e.AssertionFailures {
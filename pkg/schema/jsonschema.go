@@ -0,0 +1,189 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"fmt"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// JSONSchemaDialect identifies the version of JSON Schema in which documents are generated
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchemaDocument holds the document type used for creating a JSON Schema document
+type JSONSchemaDocument struct {
+	docType *DocumentType
+}
+
+// NewJSONSchemaDocument creates an instance of a JSONSchemaDocument based on the given DocumentType
+func NewJSONSchemaDocument(docType *DocumentType) *JSONSchemaDocument {
+	return &JSONSchemaDocument{docType}
+}
+
+// AsDocument generates a new AST of this JSON Schema (draft 2020-12) document, describing the type information
+// contained in `docType`.
+func (j *JSONSchemaDocument) AsDocument() (*yamlmeta.Document, error) {
+	properties, err := j.calculateProperties(j.docType)
+	if err != nil {
+		return nil, err
+	}
+
+	header := []*yamlmeta.MapItem{
+		{Key: "$schema", Value: JSONSchemaDialect},
+		{Key: "title", Value: "Schema for data values, generated by ytt"},
+	}
+	properties.Items = append(header, properties.Items...)
+
+	return &yamlmeta.Document{Value: properties}, nil
+}
+
+func (j *JSONSchemaDocument) calculateProperties(schemaVal interface{}) (*yamlmeta.Map, error) {
+	switch typedValue := schemaVal.(type) {
+	case *DocumentType:
+		properties, err := j.calculatePropertiesWithValidation(typedValue.GetValueType(), typedValue.GetValidation())
+		if err != nil {
+			return nil, err
+		}
+		return properties, nil
+	case *MapType:
+		var properties []*yamlmeta.MapItem
+		for _, i := range typedValue.Items {
+			itemProperties, err := j.calculatePropertiesWithValidation(i.GetValueType(), i.GetValidation())
+			if err != nil {
+				return nil, err
+			}
+			if i.IsDeprecated() {
+				itemProperties.Items = append(itemProperties.Items, &yamlmeta.MapItem{Key: deprecatedProp, Value: true})
+			}
+			properties = append(properties, &yamlmeta.MapItem{Key: i.Key, Value: itemProperties})
+		}
+		property := yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: typeProp, Value: "object"},
+			{Key: "additionalProperties", Value: false},
+		}}
+		if typedValue.GetDescription() != "" {
+			property.Items = append(property.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
+		}
+		property.Items = append(property.Items, &yamlmeta.MapItem{Key: "properties", Value: &yamlmeta.Map{Items: properties}})
+		return &property, nil
+	case *MapOfType:
+		valuesProperties, err := j.calculateProperties(typedValue.GetValueType())
+		if err != nil {
+			return nil, err
+		}
+		property := yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: typeProp, Value: "object"},
+			{Key: "additionalProperties", Value: valuesProperties},
+		}}
+		if typedValue.GetDescription() != "" {
+			property.Items = append(property.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
+		}
+		property.Items = append(property.Items, &yamlmeta.MapItem{Key: defaultProp, Value: typedValue.GetDefaultValue()})
+		return &property, nil
+	case *OneOfType:
+		alternatives := &yamlmeta.Array{}
+		for _, alt := range typedValue.Alternatives {
+			altProperties, err := j.calculateProperties(alt)
+			if err != nil {
+				return nil, err
+			}
+			alternatives.Items = append(alternatives.Items, &yamlmeta.ArrayItem{Value: altProperties})
+		}
+		property := yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: "oneOf", Value: alternatives},
+		}}
+		if typedValue.GetDescription() != "" {
+			property.Items = append(property.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
+		}
+		property.Items = append(property.Items, &yamlmeta.MapItem{Key: defaultProp, Value: typedValue.GetDefaultValue()})
+		return &property, nil
+	case *ArrayType:
+		valueType := typedValue.GetValueType().(*ArrayItemType)
+		properties, err := j.calculatePropertiesWithValidation(valueType.GetValueType(), valueType.GetValidation())
+		if err != nil {
+			return nil, err
+		}
+		property := yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: typeProp, Value: "array"},
+		}}
+		if typedValue.GetDescription() != "" {
+			property.Items = append(property.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
+		}
+		property.Items = append(property.Items, []*yamlmeta.MapItem{
+			{Key: "items", Value: properties},
+			{Key: defaultProp, Value: typedValue.GetDefaultValue()},
+		}...)
+		return &property, nil
+	case *ScalarType:
+		property := yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: typeProp, Value: j.jsonSchemaTypeFor(typedValue)},
+			{Key: defaultProp, Value: typedValue.GetDefaultValue()},
+		}}
+		if typedValue.GetDescription() != "" {
+			property.Items = append(property.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
+		}
+		return &property, nil
+	case *NullType:
+		properties, err := j.calculateProperties(typedValue.GetValueType())
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range properties.Items {
+			if item.Key == typeProp {
+				// JSON Schema has no "nullable"; instead, "null" is one of the allowed types
+				item.Value = &yamlmeta.Array{Items: []*yamlmeta.ArrayItem{{Value: item.Value}, {Value: "null"}}}
+			}
+			if alternatives, isOneOf := item.Value.(*yamlmeta.Array); isOneOf && item.Key == "oneOf" {
+				alternatives.Items = append(alternatives.Items, &yamlmeta.ArrayItem{Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+					{Key: typeProp, Value: "null"},
+				}}})
+			}
+		}
+		if typedValue.GetDescription() != "" {
+			properties.Items = append(properties.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
+		}
+		return properties, nil
+	case *AnyType:
+		// without a "type" keyword, any value is permitted
+		properties := &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: defaultProp, Value: typedValue.GetDefaultValue()},
+		}}
+		if typedValue.GetDescription() != "" {
+			properties.Items = append(properties.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
+		}
+		return properties, nil
+	default:
+		panic(fmt.Sprintf("Unrecognized type %T", schemaVal))
+	}
+}
+
+// calculatePropertiesWithValidation is calculateProperties of `valueType` along with the rules of `validation`
+func (j *JSONSchemaDocument) calculatePropertiesWithValidation(valueType yamlmeta.Type, validation *Validation) (*yamlmeta.Map, error) {
+	properties, err := j.calculateProperties(valueType)
+	if err != nil {
+		return nil, err
+	}
+	validationProps, err := validationProperties(validation, valueType)
+	if err != nil {
+		return nil, err
+	}
+	properties.Items = append(properties.Items, validationProps...)
+	return properties, nil
+}
+
+func (j *JSONSchemaDocument) jsonSchemaTypeFor(astType *ScalarType) string {
+	switch astType.ValueType.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case int:
+		return "integer"
+	case bool:
+		return "boolean"
+	default:
+		panic(fmt.Sprintf("Unrecognized type: %T", astType.ValueType))
+	}
+}
@@ -0,0 +1,728 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/template/core"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// keywords for which ytt schema has no equivalent
+var jsonSchemaUnsupportedKeywords = []string{
+	"additionalItems", "allOf", "contains", "dependencies", "dependentRequired", "dependentSchemas",
+	"deprecated", "discriminator", "else", "if", "maxContains", "minContains", "not", "patternProperties",
+	"prefixItems", "propertyNames", "then", "unevaluatedItems", "unevaluatedProperties", "uniqueItems",
+}
+
+// formats of numbers that do not constrain the value beyond its type
+var jsonSchemaNumericFormats = map[string]bool{"float": true, "double": true, "int32": true, "int64": true}
+
+// JSONSchemaImporter converts a JSON Schema (or the schema within an OpenAPI v3 document) into an equivalent
+// ytt data values schema. Constructs that have no exact equivalent are approximated, each noted as a warning.
+type JSONSchemaImporter struct {
+	root     *orderedmap.Map
+	refStack []string
+	warnings []string
+}
+
+// NewJSONSchemaImporter creates an importer of the JSON Schema (or OpenAPI v3) document contained in `doc`.
+func NewJSONSchemaImporter(doc *yamlmeta.Document) (*JSONSchemaImporter, error) {
+	root, ok := doc.AsInterface().(*orderedmap.Map)
+	if !ok {
+		return nil, fmt.Errorf("Expected a JSON Schema or OpenAPI v3 document to be a map, but was %s",
+			doc.ValueTypeAsString())
+	}
+	return &JSONSchemaImporter{root: root}, nil
+}
+
+// Warnings lists the constructs that could not be imported exactly (available after AsDocumentSet()).
+func (i *JSONSchemaImporter) Warnings() []string {
+	return i.warnings
+}
+
+// AsDocumentSet generates the AST of a data values schema document equivalent to the imported JSON Schema.
+func (i *JSONSchemaImporter) AsDocumentSet() (*yamlmeta.DocumentSet, error) {
+	i.warnings = nil
+
+	rootSchema, err := i.rootSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	value, anns := i.importSchema(rootSchema, "", nil, false)
+	switch value.(type) {
+	case *yamlmeta.Map, map[string]interface{}:
+	case nil:
+		// any value is permitted (which includes a map)
+		value = map[string]interface{}{}
+	default:
+		return nil, fmt.Errorf("Expected the root schema to describe an object, but it does not; " +
+			"data values are always a map")
+	}
+
+	if node, isNode := value.(yamlmeta.Node); isNode {
+		i.wholeFloatsAsExpressions(node)
+	}
+
+	schemaDoc := &yamlmeta.Document{
+		Value:    value,
+		Position: filepos.NewUnknownPosition(),
+		Comments: newAnnotationComments(append([]string{"@data/values-schema"}, anns...)),
+	}
+	return &yamlmeta.DocumentSet{
+		Items: []*yamlmeta.Document{
+			// like parsed YAML, content that starts with '---' is preceded by an empty document
+			{Value: nil, Position: filepos.NewUnknownPosition()},
+			schemaDoc,
+		},
+		Position: filepos.NewUnknownPosition(),
+	}, nil
+}
+
+func (i *JSONSchemaImporter) rootSchema() (*orderedmap.Map, error) {
+	if _, isOpenAPI := i.root.Get("openapi"); !isOpenAPI {
+		return i.root, nil
+	}
+
+	schemas, found := i.lookupPointer("#/components/schemas")
+	schemasMap, ok := schemas.(*orderedmap.Map)
+	if !found || !ok || schemasMap.Len() == 0 {
+		return nil, fmt.Errorf("Expected OpenAPI document to contain a schema in 'components.schemas', but found none")
+	}
+	if dataValues, found := schemasMap.Get("dataValues"); found {
+		if dataValuesMap, ok := dataValues.(*orderedmap.Map); ok {
+			return dataValuesMap, nil
+		}
+	}
+	if schemasMap.Len() != 1 {
+		return nil, fmt.Errorf("Expected OpenAPI document to contain either a 'dataValues' schema or exactly one schema " +
+			"in 'components.schemas', but found several")
+	}
+	only, _ := schemasMap.Get(schemasMap.Keys()[0])
+	onlyMap, ok := only.(*orderedmap.Map)
+	if !ok {
+		return nil, fmt.Errorf("Expected schema in 'components.schemas' to be a map")
+	}
+	return onlyMap, nil
+}
+
+// importSchema converts `schemaVal` into the value of a schema node along with the annotations for that node.
+// `parentDefault` is the default of this node as given by the default of its enclosing object (if any).
+func (i *JSONSchemaImporter) importSchema(schemaVal interface{}, path string, parentDefault interface{}, hasParentDefault bool) (interface{}, []string) {
+	schema, ok := schemaVal.(*orderedmap.Map)
+	if !ok {
+		if allowed, isBool := schemaVal.(bool); !isBool || !allowed {
+			i.warnf(path, "expected a schema, but was %v; any value is permitted instead", schemaVal)
+		}
+		return i.anyValue(parentDefault), []string{"@schema/type any=True"}
+	}
+
+	if ref, found := schema.Get("$ref"); found {
+		refStr := fmt.Sprintf("%v", ref)
+		for _, seen := range i.refStack {
+			if seen == refStr {
+				i.warnf(path, "recursive reference '%s' is not supported; any value is permitted instead", refStr)
+				return i.anyValue(parentDefault), i.withDesc(schema, []string{"@schema/type any=True"})
+			}
+		}
+		referenced, found := i.lookupPointer(refStr)
+		referencedMap, ok := referenced.(*orderedmap.Map)
+		if !found || !ok {
+			i.warnf(path, "could not resolve reference '%s' (only references within the document are supported); "+
+				"any value is permitted instead", refStr)
+			return i.anyValue(parentDefault), i.withDesc(schema, []string{"@schema/type any=True"})
+		}
+
+		i.refStack = append(i.refStack, refStr)
+		defer func() { i.refStack = i.refStack[:len(i.refStack)-1] }()
+
+		// keywords alongside a reference refine (and take precedence over) the referenced schema
+		refinements := mergeSchemas(schema, orderedmap.NewMap())
+		refinements.Delete("$ref")
+		return i.importSchema(mergeSchemas(referencedMap, refinements), path, parentDefault, hasParentDefault)
+	}
+
+	for _, unionKeyword := range []string{"anyOf", "oneOf"} {
+		alternatives, found := schema.Get(unionKeyword)
+		if !found {
+			continue
+		}
+		schema = mergeSchemas(schema, orderedmap.NewMap())
+		schema.Delete(unionKeyword)
+
+		altList, _ := alternatives.([]interface{})
+		var nonNullAlts []interface{}
+		for _, alt := range altList {
+			if i.isNullSchema(alt) {
+				schema.Set("nullable", true)
+			} else {
+				nonNullAlts = append(nonNullAlts, alt)
+			}
+		}
+		switch len(nonNullAlts) {
+		case 0:
+		case 1:
+			if altMap, ok := nonNullAlts[0].(*orderedmap.Map); ok {
+				return i.importSchema(mergeSchemas(altMap, schema), path, parentDefault, hasParentDefault)
+			}
+		default:
+			i.warnf(path, "'%s' with more than one (non-null) alternative is not supported; any value is permitted instead", unionKeyword)
+			defaultVal, hasDefault := effectiveDefault(schema, parentDefault, hasParentDefault)
+			return i.importAny(schema, path, defaultVal, hasDefault)
+		}
+	}
+
+	i.warnUnsupported(schema, path)
+
+	nullable := false
+	if isNullable, found := schema.Get("nullable"); found && isNullable == true {
+		nullable = true
+	}
+
+	var types []string
+	switch typedType := getOrNil(schema, "type").(type) {
+	case string:
+		types = append(types, typedType)
+	case []interface{}:
+		for _, t := range typedType {
+			types = append(types, fmt.Sprintf("%v", t))
+		}
+	case nil:
+		if _, found := schema.Get("properties"); found {
+			types = append(types, "object")
+		} else if _, found := schema.Get("items"); found {
+			types = append(types, "array")
+		}
+	}
+	var nonNullTypes []string
+	for _, t := range types {
+		if t == "null" {
+			nullable = true
+		} else {
+			nonNullTypes = append(nonNullTypes, t)
+		}
+	}
+
+	defaultVal, hasDefault := effectiveDefault(schema, parentDefault, hasParentDefault)
+
+	if len(nonNullTypes) != 1 {
+		if len(nonNullTypes) > 1 {
+			i.warnf(path, "a value of more than one type (%s) is not supported; any value is permitted instead",
+				strings.Join(nonNullTypes, ", "))
+		}
+		return i.importAny(schema, path, defaultVal, hasDefault)
+	}
+
+	var value interface{}
+	var anns []string
+
+	switch nonNullTypes[0] {
+	case "object":
+		properties, _ := getOrNil(schema, "properties").(*orderedmap.Map)
+		additionalProperties, hasAdditionalProperties := schema.Get("additionalProperties")
+		if properties == nil || properties.Len() == 0 {
+			if valuesSchema, isSchema := additionalProperties.(*orderedmap.Map); isSchema {
+				return i.importMapOf(schema, valuesSchema, path, nullable, defaultVal, hasDefault)
+			}
+			if !hasAdditionalProperties || additionalProperties != false {
+				i.warnf(path, "an object without declared properties (i.e. a map of arbitrary keys) is not supported; "+
+					"any value is permitted instead")
+				return i.importAny(schema, path, defaultVal, hasDefault)
+			}
+			properties = orderedmap.NewMap()
+		} else if hasAdditionalProperties && additionalProperties != false {
+			i.warnf(path, "'additionalProperties' is not supported; only the declared properties are permitted")
+		}
+
+		defaultMap, _ := defaultVal.(*orderedmap.Map)
+		if hasDefault && defaultVal != nil && defaultMap == nil {
+			i.warnf(path, "default '%v' is not an object; ignored", defaultVal)
+		}
+		if defaultMap != nil {
+			for _, key := range defaultMap.Keys() {
+				if _, found := properties.Get(key); !found {
+					i.warnf(path, "default includes '%v' which is not a declared property; ignored", key)
+				}
+			}
+		}
+
+		valueMap := &yamlmeta.Map{Position: filepos.NewUnknownPosition()}
+		properties.Iterate(func(key, propSchema interface{}) {
+			var propDefault interface{}
+			var hasPropDefault bool
+			if defaultMap != nil {
+				propDefault, hasPropDefault = defaultMap.Get(key)
+			}
+			propValue, propAnns := i.importSchema(propSchema, joinKeyPath(path, key), propDefault, hasPropDefault)
+			valueMap.Items = append(valueMap.Items, &yamlmeta.MapItem{
+				Key:      key,
+				Value:    propValue,
+				Position: filepos.NewUnknownPosition(),
+				Comments: newAnnotationComments(propAnns),
+			})
+		})
+		value = valueMap
+		if len(valueMap.Items) == 0 {
+			value = map[string]interface{}{} // printed as "{}"
+		}
+
+		if nullable {
+			anns = append(anns, "@schema/nullable")
+			if defaultMap != nil {
+				i.warnf(path, "default of a nullable object is always null; ignored")
+			}
+		}
+
+	case "array":
+		items, hasItems := schema.Get("items")
+		if _, isTuple := items.([]interface{}); isTuple {
+			i.warnf(path, "'items' as a list of schemas (i.e. a tuple) is not supported; any item is permitted instead")
+			hasItems = false
+		}
+		var itemValue interface{}
+		var itemAnns []string
+		if hasItems {
+			itemValue, itemAnns = i.importSchema(items, path+"[]", nil, false)
+		} else {
+			itemAnns = []string{"@schema/type any=True"}
+		}
+		value = &yamlmeta.Array{
+			Items: []*yamlmeta.ArrayItem{{
+				Value:    itemValue,
+				Position: filepos.NewUnknownPosition(),
+				Comments: newAnnotationComments(itemAnns),
+			}},
+			Position: filepos.NewUnknownPosition(),
+		}
+
+		if nullable {
+			anns = append(anns, "@schema/nullable")
+		}
+		switch typedDefault := defaultVal.(type) {
+		case []interface{}:
+			// the default of an array in ytt schema is empty unless set otherwise
+			if len(typedDefault) > 0 || nullable {
+				anns = append(anns, "@schema/default "+starlarkLiteral(typedDefault))
+			}
+		case nil:
+			if hasDefault && !nullable {
+				i.warnf(path, "default is null, but null is not permitted; ignored")
+			}
+		default:
+			i.warnf(path, "default '%v' is not an array; ignored", defaultVal)
+		}
+
+	case "string", "integer", "number", "boolean":
+		value = zeroValueFor(nonNullTypes[0])
+		hasNonNullDefault := false
+		if hasDefault && defaultVal != nil {
+			if converted, ok := convertScalarDefault(nonNullTypes[0], defaultVal); ok {
+				value = converted
+				hasNonNullDefault = true
+			} else {
+				i.warnf(path, "default '%v' is not of type %s; ignored", defaultVal, nonNullTypes[0])
+			}
+		} else if hasDefault && !nullable {
+			i.warnf(path, "default is null, but null is not permitted; ignored")
+		}
+
+		if format, found := schema.Get("format"); found {
+			if nonNullTypes[0] == "string" || !jsonSchemaNumericFormats[fmt.Sprintf("%v", format)] {
+				i.warnf(path, "'format' is not supported; ignored")
+			}
+		}
+
+		if nullable {
+			anns = append(anns, "@schema/nullable")
+			// a nullable value defaults to null, unless otherwise specified
+			if hasNonNullDefault {
+				anns = append(anns, "@schema/default "+starlarkLiteral(value))
+			}
+		}
+
+	default:
+		i.warnf(path, "type '%s' is not supported; any value is permitted instead", nonNullTypes[0])
+		return i.importAny(schema, path, defaultVal, hasDefault)
+	}
+
+	anns = append(anns, i.validationAnnotations(schema, path)...)
+	return value, i.withDesc(schema, anns)
+}
+
+// wholeFloatsAsExpressions retains the type of whole numbers that are floats (e.g. 1.0): as plain YAML,
+// they would be output (and so inferred) as integers. Such values are instead given as template expressions
+// (e.g. "ratio: #@ 1.0").
+func (i *JSONSchemaImporter) wholeFloatsAsExpressions(node yamlmeta.Node) {
+	for _, child := range node.GetValues() {
+		childNode, isNode := child.(yamlmeta.Node)
+		if !isNode {
+			continue
+		}
+		switch typedChild := childNode.(type) {
+		case *yamlmeta.MapItem:
+			typedChild.Comments = i.wholeFloatAsExpression(typedChild, typedChild.Comments)
+		case *yamlmeta.ArrayItem:
+			typedChild.Comments = i.wholeFloatAsExpression(typedChild, typedChild.Comments)
+		}
+		i.wholeFloatsAsExpressions(childNode)
+	}
+}
+
+func (i *JSONSchemaImporter) wholeFloatAsExpression(item yamlmeta.Node, comments []*yamlmeta.Comment) []*yamlmeta.Comment {
+	floatVal, isFloat := item.GetValues()[0].(float64)
+	if !isFloat || floatVal != math.Trunc(floatVal) || math.IsInf(floatVal, 0) {
+		return comments
+	}
+	// an expression is only output on the same line as its node when both positions are known
+	for _, comment := range comments {
+		comment.Position = filepos.NewPosition(1)
+	}
+	item.SetPosition(filepos.NewPosition(2))
+	item.SetValue(nil)
+	return append(comments, &yamlmeta.Comment{Data: "@ " + strconv.FormatFloat(floatVal, 'f', 1, 64), Position: filepos.NewPosition(2)})
+}
+
+// importMapOf yields a node that accepts a map of arbitrary keys, each value conforming to `valuesSchema`.
+func (i *JSONSchemaImporter) importMapOf(schema, valuesSchema *orderedmap.Map, path string, nullable bool, defaultVal interface{}, hasDefault bool) (interface{}, []string) {
+	valuesPath := joinKeyPath(path, "*")
+	example, exampleAnns := i.importSchema(valuesSchema, valuesPath, nil, false)
+	for _, ann := range exampleAnns {
+		if strings.HasPrefix(ann, "@schema/type any=True") {
+			// values of any type: not different from a value of any type
+			return i.importAny(schema, path, defaultVal, hasDefault)
+		}
+	}
+	if len(exampleAnns) > 0 || i.hasAnnotations(example) {
+		i.warnf(valuesPath, "annotations (e.g. descriptions, validations) cannot be expressed on the values of a map; ignored")
+	}
+
+	anns := []string{fmt.Sprintf("@%s %s=%s", AnnotationType, TypeAnnotationKwargMapOf, starlarkLiteral(i.goValue(example)))}
+	var value interface{} = map[string]interface{}{} // printed as "{}"
+
+	switch typedDefault := defaultVal.(type) {
+	case *orderedmap.Map:
+		if nullable {
+			i.warnf(path, "default of a nullable object is always null; ignored")
+		} else {
+			value = i.anyValue(typedDefault)
+		}
+	case nil:
+		if hasDefault && !nullable {
+			i.warnf(path, "default is null, but null is not permitted; ignored")
+		}
+	default:
+		i.warnf(path, "default '%v' is not an object; ignored", defaultVal)
+	}
+
+	if nullable {
+		anns = append(anns, "@schema/nullable")
+	}
+	anns = append(anns, i.validationAnnotations(schema, path)...)
+	return value, i.withDesc(schema, anns)
+}
+
+// hasAnnotations reports whether any node within `val` carries annotations.
+func (i *JSONSchemaImporter) hasAnnotations(val interface{}) bool {
+	switch typedVal := val.(type) {
+	case *yamlmeta.Map:
+		for _, item := range typedVal.Items {
+			if len(item.Comments) > 0 || i.hasAnnotations(item.Value) {
+				return true
+			}
+		}
+	case *yamlmeta.Array:
+		for _, item := range typedVal.Items {
+			if len(item.Comments) > 0 || i.hasAnnotations(item.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// goValue converts an imported value into its Go equivalent (suitable for rendering as a Starlark literal).
+func (i *JSONSchemaImporter) goValue(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case *yamlmeta.Map:
+		result := orderedmap.NewMap()
+		for _, item := range typedVal.Items {
+			result.Set(item.Key, i.goValue(item.Value))
+		}
+		return result
+	case *yamlmeta.Array:
+		result := []interface{}{}
+		for _, item := range typedVal.Items {
+			result = append(result, i.goValue(item.Value))
+		}
+		return result
+	case map[string]interface{}:
+		return orderedmap.NewMap()
+	default:
+		return val
+	}
+}
+
+// importAny yields a node that accepts any value, defaulting to the schema's default.
+func (i *JSONSchemaImporter) importAny(schema *orderedmap.Map, path string, defaultVal interface{}, hasDefault bool) (interface{}, []string) {
+	if !hasDefault {
+		defaultVal = nil
+	}
+	anns := append([]string{"@schema/type any=True"}, i.validationAnnotations(schema, path)...)
+	return i.anyValue(defaultVal), i.withDesc(schema, anns)
+}
+
+func (i *JSONSchemaImporter) anyValue(defaultVal interface{}) interface{} {
+	switch typedVal := defaultVal.(type) {
+	case *orderedmap.Map:
+		if typedVal.Len() == 0 {
+			return map[string]interface{}{} // printed as "{}"
+		}
+		result := &yamlmeta.Map{Position: filepos.NewUnknownPosition()}
+		typedVal.Iterate(func(k, v interface{}) {
+			result.Items = append(result.Items, &yamlmeta.MapItem{Key: k, Value: i.anyValue(v), Position: filepos.NewUnknownPosition()})
+		})
+		return result
+	case []interface{}:
+		if len(typedVal) == 0 {
+			return []interface{}{} // printed as "[]"
+		}
+		result := &yamlmeta.Array{Position: filepos.NewUnknownPosition()}
+		for _, v := range typedVal {
+			result.Items = append(result.Items, &yamlmeta.ArrayItem{Value: i.anyValue(v), Position: filepos.NewUnknownPosition()})
+		}
+		return result
+	default:
+		return defaultVal
+	}
+}
+
+// validationAnnotations maps validation keywords to an equivalent @schema/validation annotation
+func (i *JSONSchemaImporter) validationAnnotations(schema *orderedmap.Map, path string) []string {
+	var rules []string
+	var kwargs []string
+
+	for _, kw := range []struct{ keyword, kwarg string }{
+		{"minimum", ValidationKwargMin},
+		{"maximum", ValidationKwargMax},
+		{"minLength", ValidationKwargMinLen},
+		{"minItems", ValidationKwargMinLen},
+		{"minProperties", ValidationKwargMinLen},
+		{"maxLength", ValidationKwargMaxLen},
+		{"maxItems", ValidationKwargMaxLen},
+		{"maxProperties", ValidationKwargMaxLen},
+	} {
+		val, found := schema.Get(kw.keyword)
+		if !found {
+			continue
+		}
+		if exclusive, found := schema.Get("exclusiveM" + kw.keyword[1:]); found && exclusive == true {
+			// prior to draft 6, "exclusiveMinimum"/"exclusiveMaximum" are flags that modify "minimum"/"maximum"
+			continue
+		}
+		kwargs = append(kwargs, fmt.Sprintf("%s=%s", kw.kwarg, starlarkLiteral(val)))
+	}
+
+	for _, kw := range []struct{ keyword, bound, op, desc string }{
+		{"exclusiveMinimum", "minimum", ">", "greater than"},
+		{"exclusiveMaximum", "maximum", "<", "less than"},
+	} {
+		val, found := schema.Get(kw.keyword)
+		if !found || val == false {
+			continue
+		}
+		if val == true {
+			val, found = schema.Get(kw.bound)
+			if !found {
+				continue
+			}
+		}
+		rules = append(rules, fmt.Sprintf(`("a value %s %v", lambda v: v %s %s)`, kw.desc, val, kw.op, starlarkLiteral(val)))
+	}
+
+	if multipleOf, found := schema.Get("multipleOf"); found {
+		rules = append(rules, fmt.Sprintf(`("a multiple of %v", lambda v: v %% %s == 0)`, multipleOf, starlarkLiteral(multipleOf)))
+	}
+
+	var allowed []interface{}
+	if enum, found := schema.Get("enum"); found {
+		allowed, _ = enum.([]interface{})
+	}
+	if constVal, found := schema.Get("const"); found {
+		allowed = []interface{}{constVal}
+	}
+	if allowed != nil {
+		kwargs = append(kwargs, fmt.Sprintf("%s=%s", ValidationKwargOneOf, starlarkLiteral(allowed)))
+	}
+
+	if pattern, found := schema.Get("pattern"); found {
+		kwargs = append(kwargs, fmt.Sprintf("%s=%s", ValidationKwargRegex, starlarkLiteral(pattern)))
+	}
+
+	args := append(rules, kwargs...)
+	if len(args) == 0 {
+		return nil
+	}
+	return []string{"@schema/validation " + strings.Join(args, ", ")}
+}
+
+func (i *JSONSchemaImporter) warnUnsupported(schema *orderedmap.Map, path string) {
+	var found []string
+	for _, keyword := range jsonSchemaUnsupportedKeywords {
+		if _, ok := schema.Get(keyword); ok {
+			found = append(found, keyword)
+		}
+	}
+	for _, key := range schema.Keys() {
+		if keyStr, ok := key.(string); ok && strings.HasPrefix(keyStr, "x-") {
+			found = append(found, keyStr)
+		}
+	}
+	sort.Strings(found)
+	for _, keyword := range found {
+		i.warnf(path, "'%s' is not supported; ignored", keyword)
+	}
+}
+
+func (i *JSONSchemaImporter) withDesc(schema *orderedmap.Map, anns []string) []string {
+	if desc, found := schema.Get("description"); found {
+		return append([]string{"@schema/desc " + starlarkLiteral(fmt.Sprintf("%v", desc))}, anns...)
+	}
+	return anns
+}
+
+func (i *JSONSchemaImporter) isNullSchema(schemaVal interface{}) bool {
+	schema, ok := schemaVal.(*orderedmap.Map)
+	if !ok {
+		return false
+	}
+	if ref, found := schema.Get("$ref"); found {
+		referenced, _ := i.lookupPointer(fmt.Sprintf("%v", ref))
+		return i.isNullSchema(referenced)
+	}
+	switch typedType := getOrNil(schema, "type").(type) {
+	case string:
+		return typedType == "null"
+	case []interface{}:
+		return len(typedType) == 1 && typedType[0] == "null"
+	}
+	return false
+}
+
+// lookupPointer resolves a JSON Pointer (as a URI fragment) against the document being imported.
+func (i *JSONSchemaImporter) lookupPointer(pointer string) (interface{}, bool) {
+	if !strings.HasPrefix(pointer, "#") {
+		return nil, false
+	}
+	var current interface{} = i.root
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "#"), "/")[1:] {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		currentMap, ok := current.(*orderedmap.Map)
+		if !ok {
+			return nil, false
+		}
+		current, ok = currentMap.Get(token)
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func (i *JSONSchemaImporter) warnf(path string, format string, args ...interface{}) {
+	if path == "" {
+		path = "(root)"
+	}
+	i.warnings = append(i.warnings, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+// mergeSchemas produces a new schema having the keywords of both; those of `overrides` take precedence.
+func mergeSchemas(base, overrides *orderedmap.Map) *orderedmap.Map {
+	merged := orderedmap.NewMap()
+	base.Iterate(func(k, v interface{}) { merged.Set(k, v) })
+	overrides.Iterate(func(k, v interface{}) { merged.Set(k, v) })
+	return merged
+}
+
+// effectiveDefault is the default given by the enclosing object (if any), otherwise that of the schema itself.
+func effectiveDefault(schema *orderedmap.Map, parentDefault interface{}, hasParentDefault bool) (interface{}, bool) {
+	if hasParentDefault {
+		return parentDefault, true
+	}
+	return schema.Get("default")
+}
+
+func getOrNil(m *orderedmap.Map, key string) interface{} {
+	val, _ := m.Get(key)
+	return val
+}
+
+func joinKeyPath(path string, key interface{}) string {
+	if path == "" {
+		return fmt.Sprintf("%v", key)
+	}
+	return fmt.Sprintf("%s.%v", path, key)
+}
+
+func zeroValueFor(jsonSchemaType string) interface{} {
+	switch jsonSchemaType {
+	case "string":
+		return ""
+	case "integer":
+		return 0
+	case "number":
+		return 0.0
+	case "boolean":
+		return false
+	default:
+		panic(fmt.Sprintf("Unrecognized type: %s", jsonSchemaType))
+	}
+}
+
+func convertScalarDefault(jsonSchemaType string, val interface{}) (interface{}, bool) {
+	switch jsonSchemaType {
+	case "string":
+		str, ok := val.(string)
+		return str, ok
+	case "boolean":
+		b, ok := val.(bool)
+		return b, ok
+	case "integer":
+		switch typedVal := val.(type) {
+		case int:
+			return typedVal, true
+		case float64:
+			if typedVal == float64(int(typedVal)) {
+				return int(typedVal), true
+			}
+		}
+	case "number":
+		switch typedVal := val.(type) {
+		case int:
+			return float64(typedVal), true
+		case float64:
+			return typedVal, true
+		}
+	}
+	return nil, false
+}
+
+func starlarkLiteral(val interface{}) string {
+	return core.NewGoValue(val).AsStarlarkValue().String()
+}
+
+func newAnnotationComments(anns []string) []*yamlmeta.Comment {
+	var comments []*yamlmeta.Comment
+	for _, ann := range anns {
+		comments = append(comments, &yamlmeta.Comment{Data: ann, Position: filepos.NewUnknownPosition()})
+	}
+	return comments
+}
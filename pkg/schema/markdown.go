@@ -0,0 +1,173 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// MarkdownDocs holds the document type used for creating reference documentation (in Markdown) of data values
+type MarkdownDocs struct {
+	docType *DocumentType
+}
+
+// markdownDocsRow describes a single data value in the reference documentation
+type markdownDocsRow struct {
+	path        string
+	typeName    string
+	defaultVal  string
+	nullable    bool
+	description string
+	source      string
+}
+
+// NewMarkdownDocs creates an instance of MarkdownDocs based on the given DocumentType
+func NewMarkdownDocs(docType *DocumentType) *MarkdownDocs {
+	return &MarkdownDocs{docType}
+}
+
+// AsBytes renders reference documentation of the data values described by `docType`:
+// a table for each map (starting with the root), listing every key within that map.
+func (m *MarkdownDocs) AsBytes() []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("# Data Values Reference\n")
+
+	valueType, _ := m.unwrapNullable(m.docType.GetValueType())
+	if _, isMap := valueType.(*MapType); !isMap {
+		buf.WriteString("\nNo data values are declared in schema.\n")
+		return buf.Bytes()
+	}
+	m.writeMap(buf, valueType.(*MapType), "")
+	return buf.Bytes()
+}
+
+func (m *MarkdownDocs) writeMap(buf *bytes.Buffer, mapType *MapType, path string) {
+	if path != "" {
+		buf.WriteString(fmt.Sprintf("\n## `%s`\n", path))
+	}
+	if mapType.GetDescription() != "" {
+		buf.WriteString("\n" + mapType.GetDescription() + "\n")
+	}
+	if len(mapType.Items) == 0 {
+		buf.WriteString("\nNo keys are declared in this map.\n")
+		return
+	}
+
+	buf.WriteString("\n| Key | Type | Default | Nullable | Description | Source |\n")
+	buf.WriteString("|-----|------|---------|----------|-------------|--------|\n")
+
+	var nestedMaps []*MapType
+	var nestedPaths []string
+
+	for _, item := range mapType.Items {
+		itemPath := fmt.Sprintf("%v", item.Key)
+		if path != "" {
+			itemPath = path + "." + itemPath
+		}
+		valueType, nullable := m.unwrapNullable(item.GetValueType())
+		row := markdownDocsRow{
+			path:        itemPath,
+			typeName:    m.typeName(valueType),
+			nullable:    nullable,
+			description: item.GetValueType().GetDescription(),
+			source:      item.GetDefinitionPosition().AsCompactString(),
+		}
+		if item.IsDeprecated() {
+			row.description = strings.TrimSpace("**Deprecated:** " + item.GetDeprecationMessage() + "\n" + row.description)
+		}
+		row.defaultVal = m.defaultValue(valueType, item.GetDefaultValue().(*yamlmeta.MapItem).Value)
+		m.writeRow(buf, row)
+
+		// maps (including those within arrays and open maps) are documented in a table of their own
+	unwrap:
+		for {
+			switch typedType := valueType.(type) {
+			case *ArrayType:
+				valueType, _ = m.unwrapNullable(typedType.GetValueType().GetValueType())
+				itemPath += "[]"
+			case *MapOfType:
+				valueType, _ = m.unwrapNullable(typedType.GetValueType())
+				itemPath += ".*"
+			default:
+				break unwrap
+			}
+		}
+		if nestedMap, isMap := valueType.(*MapType); isMap {
+			nestedMaps = append(nestedMaps, nestedMap)
+			nestedPaths = append(nestedPaths, itemPath)
+		}
+		if oneOfType, isOneOf := valueType.(*OneOfType); isOneOf {
+			for _, alt := range oneOfType.Alternatives {
+				if nestedMap, isMap := alt.(*MapType); isMap {
+					nestedMaps = append(nestedMaps, nestedMap)
+					nestedPaths = append(nestedPaths, itemPath)
+				}
+			}
+		}
+	}
+
+	for i, nestedMap := range nestedMaps {
+		m.writeMap(buf, nestedMap, nestedPaths[i])
+	}
+}
+
+func (m *MarkdownDocs) writeRow(buf *bytes.Buffer, row markdownDocsRow) {
+	nullable := "no"
+	if row.nullable {
+		nullable = "yes"
+	}
+	defaultVal := ""
+	if row.defaultVal != "" {
+		defaultVal = "`" + row.defaultVal + "`"
+	}
+	cells := []string{"`" + row.path + "`", row.typeName, defaultVal, nullable, row.description, row.source}
+	for i, cell := range cells {
+		cells[i] = m.escape(cell)
+	}
+	buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+}
+
+func (m *MarkdownDocs) unwrapNullable(typ yamlmeta.Type) (yamlmeta.Type, bool) {
+	if nullType, ok := typ.(*NullType); ok {
+		return nullType.GetValueType(), true
+	}
+	return typ, false
+}
+
+func (m *MarkdownDocs) typeName(typ yamlmeta.Type) string {
+	if arrayType, ok := typ.(*ArrayType); ok {
+		itemType, nullable := m.unwrapNullable(arrayType.GetValueType().GetValueType())
+		if nullable {
+			return fmt.Sprintf("array of (nullable) %s", m.typeName(itemType))
+		}
+		return fmt.Sprintf("array of %s", m.typeName(itemType))
+	}
+	return typ.String()
+}
+
+// defaultValue renders the default `val` of a value (as JSON); maps have none of their own (unless null):
+// their keys have defaults.
+func (m *MarkdownDocs) defaultValue(typ yamlmeta.Type, defaultVal interface{}) string {
+	if _, isMap := typ.(*MapType); isMap && defaultVal != nil {
+		return ""
+	}
+	val := yamlmeta.NewGoFromAST(defaultVal)
+	bs, err := json.Marshal(orderedmap.Conversion{val}.AsUnorderedStringMaps())
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	return string(bs)
+}
+
+// escape makes `text` fit within a single cell of a Markdown table
+func (m *MarkdownDocs) escape(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.ReplaceAll(strings.TrimSpace(text), "\n", "<br>")
+}
@@ -6,6 +6,8 @@ package schema
 import (
 	"fmt"
 
+	"github.com/k14s/starlark-go/starlark"
+	"github.com/k14s/ytt/pkg/template/core"
 	"github.com/k14s/ytt/pkg/yamlmeta"
 )
 
@@ -15,6 +17,7 @@ const (
 	defaultProp     = "default"
 	nullableProp    = "nullable"
 	descriptionProp = "description"
+	deprecatedProp  = "deprecated"
 )
 
 // OpenAPIDocument holds the document type used for creating an OpenAPI document
@@ -29,8 +32,11 @@ func NewOpenAPIDocument(docType *DocumentType) *OpenAPIDocument {
 
 // AsDocument generates a new AST of this OpenAPI v3.0.x document, populating the `schemas:` section with the
 // type information contained in `docType`.
-func (o *OpenAPIDocument) AsDocument() *yamlmeta.Document {
-	openAPIProperties := o.calculateProperties(o.docType)
+func (o *OpenAPIDocument) AsDocument() (*yamlmeta.Document, error) {
+	openAPIProperties, err := o.calculateProperties(o.docType)
+	if err != nil {
+		return nil, err
+	}
 
 	return &yamlmeta.Document{Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
 		{Key: "openapi", Value: "3.0.0"},
@@ -44,17 +50,28 @@ func (o *OpenAPIDocument) AsDocument() *yamlmeta.Document {
 				{Key: "dataValues", Value: openAPIProperties},
 			}}},
 		}}},
-	}}}
+	}}}, nil
 }
 
-func (o *OpenAPIDocument) calculateProperties(schemaVal interface{}) *yamlmeta.Map {
+func (o *OpenAPIDocument) calculateProperties(schemaVal interface{}) (*yamlmeta.Map, error) {
 	switch typedValue := schemaVal.(type) {
 	case *DocumentType:
-		return o.calculateProperties(typedValue.GetValueType())
+		properties, err := o.calculatePropertiesWithValidation(typedValue.GetValueType(), typedValue.GetValidation())
+		if err != nil {
+			return nil, err
+		}
+		return properties, nil
 	case *MapType:
 		var properties []*yamlmeta.MapItem
 		for _, i := range typedValue.Items {
-			mi := yamlmeta.MapItem{Key: i.Key, Value: o.calculateProperties(i.GetValueType())}
+			itemProperties, err := o.calculatePropertiesWithValidation(i.GetValueType(), i.GetValidation())
+			if err != nil {
+				return nil, err
+			}
+			if i.IsDeprecated() {
+				itemProperties.Items = append(itemProperties.Items, &yamlmeta.MapItem{Key: deprecatedProp, Value: true})
+			}
+			mi := yamlmeta.MapItem{Key: i.Key, Value: itemProperties}
 			properties = append(properties, &mi)
 		}
 		property := yamlmeta.Map{Items: []*yamlmeta.MapItem{
@@ -66,10 +83,44 @@ func (o *OpenAPIDocument) calculateProperties(schemaVal interface{}) *yamlmeta.M
 			property.Items = append(property.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
 		}
 		property.Items = append(property.Items, &yamlmeta.MapItem{Key: "properties", Value: &yamlmeta.Map{Items: properties}})
-		return &property
+		return &property, nil
+	case *MapOfType:
+		valuesProperties, err := o.calculateProperties(typedValue.GetValueType())
+		if err != nil {
+			return nil, err
+		}
+		property := yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: typeProp, Value: "object"},
+			{Key: "additionalProperties", Value: valuesProperties},
+		}}
+		if typedValue.GetDescription() != "" {
+			property.Items = append(property.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
+		}
+		property.Items = append(property.Items, &yamlmeta.MapItem{Key: defaultProp, Value: typedValue.GetDefaultValue()})
+		return &property, nil
+	case *OneOfType:
+		alternatives := &yamlmeta.Array{}
+		for _, alt := range typedValue.Alternatives {
+			altProperties, err := o.calculateProperties(alt)
+			if err != nil {
+				return nil, err
+			}
+			alternatives.Items = append(alternatives.Items, &yamlmeta.ArrayItem{Value: altProperties})
+		}
+		property := yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: "oneOf", Value: alternatives},
+		}}
+		if typedValue.GetDescription() != "" {
+			property.Items = append(property.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
+		}
+		property.Items = append(property.Items, &yamlmeta.MapItem{Key: defaultProp, Value: typedValue.GetDefaultValue()})
+		return &property, nil
 	case *ArrayType:
 		valueType := typedValue.GetValueType().(*ArrayItemType)
-		properties := o.calculateProperties(valueType.GetValueType())
+		properties, err := o.calculatePropertiesWithValidation(valueType.GetValueType(), valueType.GetValidation())
+		if err != nil {
+			return nil, err
+		}
 		property := yamlmeta.Map{Items: []*yamlmeta.MapItem{
 			{Key: typeProp, Value: "array"},
 		}}
@@ -83,7 +134,7 @@ func (o *OpenAPIDocument) calculateProperties(schemaVal interface{}) *yamlmeta.M
 		}
 
 		property.Items = append(property.Items, items...)
-		return &property
+		return &property, nil
 	case *ScalarType:
 		typeString := o.openAPITypeFor(typedValue)
 		defaultVal := typedValue.GetDefaultValue()
@@ -97,14 +148,17 @@ func (o *OpenAPIDocument) calculateProperties(schemaVal interface{}) *yamlmeta.M
 		if typedValue.GetDescription() != "" {
 			property.Items = append(property.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
 		}
-		return &property
+		return &property, nil
 	case *NullType:
-		properties := o.calculateProperties(typedValue.GetValueType())
+		properties, err := o.calculateProperties(typedValue.GetValueType())
+		if err != nil {
+			return nil, err
+		}
 		properties.Items = append(properties.Items, &yamlmeta.MapItem{Key: nullableProp, Value: true})
 		if typedValue.GetDescription() != "" {
 			properties.Items = append(properties.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
 		}
-		return properties
+		return properties, nil
 	case *AnyType:
 		properties := &yamlmeta.Map{Items: []*yamlmeta.MapItem{
 			{Key: nullableProp, Value: true},
@@ -113,12 +167,90 @@ func (o *OpenAPIDocument) calculateProperties(schemaVal interface{}) *yamlmeta.M
 		if typedValue.GetDescription() != "" {
 			properties.Items = append(properties.Items, &yamlmeta.MapItem{Key: descriptionProp, Value: typedValue.GetDescription()})
 		}
-		return properties
+		return properties, nil
 	default:
 		panic(fmt.Sprintf("Unrecognized type %T", schemaVal))
 	}
 }
 
+// calculatePropertiesWithValidation is calculateProperties of `valueType` along with the rules of `validation`
+func (o *OpenAPIDocument) calculatePropertiesWithValidation(valueType yamlmeta.Type, validation *Validation) (*yamlmeta.Map, error) {
+	properties, err := o.calculateProperties(valueType)
+	if err != nil {
+		return nil, err
+	}
+	validationProps, err := validationProperties(validation, valueType)
+	if err != nil {
+		return nil, err
+	}
+	properties.Items = append(properties.Items, validationProps...)
+	return properties, nil
+}
+
+// validationProperties translates the declarative rules of `validation` into their OpenAPI (and JSON Schema)
+// equivalents. Rules given as functions have no such equivalent and are omitted.
+func validationProperties(validation *Validation, valueType yamlmeta.Type) ([]*yamlmeta.MapItem, error) {
+	if validation == nil {
+		return nil, nil
+	}
+	if nullType, ok := valueType.(*NullType); ok {
+		valueType = nullType.GetValueType()
+	}
+
+	minLenProp, maxLenProp := "minLength", "maxLength"
+	switch valueType.(type) {
+	case *ArrayType:
+		minLenProp, maxLenProp = "minItems", "maxItems"
+	case *MapType, *MapOfType:
+		minLenProp, maxLenProp = "minProperties", "maxProperties"
+	}
+
+	var properties []*yamlmeta.MapItem
+	if validation.min != nil {
+		min, err := validationRuleValue("min", validation.min)
+		if err != nil {
+			return nil, err
+		}
+		properties = append(properties, &yamlmeta.MapItem{Key: "minimum", Value: min})
+	}
+	if validation.max != nil {
+		max, err := validationRuleValue("max", validation.max)
+		if err != nil {
+			return nil, err
+		}
+		properties = append(properties, &yamlmeta.MapItem{Key: "maximum", Value: max})
+	}
+	if validation.minLen != nil {
+		properties = append(properties, &yamlmeta.MapItem{Key: minLenProp, Value: *validation.minLen})
+	}
+	if validation.maxLen != nil {
+		properties = append(properties, &yamlmeta.MapItem{Key: maxLenProp, Value: *validation.maxLen})
+	}
+	if validation.oneOf != nil {
+		enum := &yamlmeta.Array{}
+		for _, val := range validation.oneOf {
+			goVal, err := validationRuleValue("one_of", val)
+			if err != nil {
+				return nil, err
+			}
+			enum.Items = append(enum.Items, &yamlmeta.ArrayItem{Value: yamlmeta.NewASTFromInterfaceWithNoPosition(goVal)})
+		}
+		properties = append(properties, &yamlmeta.MapItem{Key: "enum", Value: enum})
+	}
+	if validation.regex != nil {
+		properties = append(properties, &yamlmeta.MapItem{Key: "pattern", Value: validation.regex.String()})
+	}
+	return properties, nil
+}
+
+func validationRuleValue(rule string, val starlark.Value) (interface{}, error) {
+	goVal, err := core.NewStarlarkValue(val).AsGoValue()
+	if err != nil {
+		return nil, fmt.Errorf("Converting value of validation rule '%s' (in @%s): %s", rule, AnnotationValidation, err)
+	}
+	return goVal, nil
+}
+
 func (o *OpenAPIDocument) openAPITypeFor(astType *ScalarType) string {
 	switch astType.ValueType.(type) {
 	case string:
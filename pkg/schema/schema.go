@@ -82,7 +82,22 @@ func NewDocumentType(doc *yamlmeta.Document) (*DocumentType, error) {
 
 	typeOfValue.SetDefaultValue(defaultValue)
 
-	return &DocumentType{Source: doc, Position: doc.Position, ValueType: typeOfValue, defaultValue: defaultValue}, nil
+	validation, err := getValidation(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// only map items can be deprecated (or sensitive): this reports the annotation's misuse
+	_, err = getDeprecation(doc)
+	if err != nil {
+		return nil, err
+	}
+	_, err = getSensitivity(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DocumentType{Source: doc, Position: doc.Position, ValueType: typeOfValue, defaultValue: defaultValue, validation: validation}, nil
 }
 
 func NewMapType(m *yamlmeta.Map) (*MapType, error) {
@@ -112,7 +127,23 @@ func NewMapItemType(item *yamlmeta.MapItem) (*MapItemType, error) {
 
 	typeOfValue.SetDefaultValue(defaultValue)
 
-	return &MapItemType{Key: item.Key, ValueType: typeOfValue, defaultValue: defaultValue, Position: item.Position}, nil
+	validation, err := getValidation(item)
+	if err != nil {
+		return nil, err
+	}
+
+	deprecation, err := getDeprecation(item)
+	if err != nil {
+		return nil, err
+	}
+
+	sensitivity, err := getSensitivity(item)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MapItemType{Key: item.Key, ValueType: typeOfValue, defaultValue: defaultValue, Position: item.Position,
+		validation: validation, deprecation: deprecation, sensitivity: sensitivity}, nil
 }
 
 func NewArrayType(a *yamlmeta.Array) (*ArrayType, error) {
@@ -146,7 +177,22 @@ func NewArrayItemType(item *yamlmeta.ArrayItem) (*ArrayItemType, error) {
 
 	typeOfValue.SetDefaultValue(defaultValue)
 
-	return &ArrayItemType{ValueType: typeOfValue, defaultValue: defaultValue, Position: item.GetPosition()}, nil
+	validation, err := getValidation(item)
+	if err != nil {
+		return nil, err
+	}
+
+	// only map items can be deprecated (or sensitive): this reports the annotation's misuse
+	_, err = getDeprecation(item)
+	if err != nil {
+		return nil, err
+	}
+	_, err = getSensitivity(item)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArrayItemType{ValueType: typeOfValue, defaultValue: defaultValue, Position: item.GetPosition(), validation: validation}, nil
 }
 
 func getType(node yamlmeta.Node) (yamlmeta.Type, error) {
@@ -197,13 +243,42 @@ func getValue(node yamlmeta.Node, t yamlmeta.Type) (interface{}, error) {
 		}
 	}
 
-	if _, ok := t.(*AnyType); ok {
+	switch typedType := t.(type) {
+	case *AnyType:
 		return node.GetValues()[0], nil
+	case *MapOfType:
+		return typedType.checkedDefaultValue(node.GetValues()[0])
+	case *OneOfType:
+		return typedType.checkedDefaultValue(node.GetValues()[0])
 	}
 
 	return t.GetDefaultValue(), nil
 }
 
+func getValidation(node yamlmeta.Node) (*Validation, error) {
+	validation, err := collectValidationAnnotation(node)
+	if err != nil {
+		return nil, NewSchemaError("Invalid schema", err)
+	}
+	return validation, nil
+}
+
+func getDeprecation(node yamlmeta.Node) (*DeprecatedAnnotation, error) {
+	deprecation, err := collectDeprecatedAnnotation(node)
+	if err != nil {
+		return nil, NewSchemaError("Invalid schema", err)
+	}
+	return deprecation, nil
+}
+
+func getSensitivity(node yamlmeta.Node) (*SensitiveAnnotation, error) {
+	sensitivity, err := collectSensitiveAnnotation(node)
+	if err != nil {
+		return nil, NewSchemaError("Invalid schema", err)
+	}
+	return sensitivity, nil
+}
+
 // getValueFromAnn extracts the value from the annotation and validates its type
 func getValueFromAnn(defaultAnn *DefaultAnnotation, t yamlmeta.Type) (interface{}, error) {
 	var typeCheck yamlmeta.TypeCheck
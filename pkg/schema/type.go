@@ -5,6 +5,7 @@ package schema
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/k14s/ytt/pkg/filepos"
 	"github.com/k14s/ytt/pkg/yamlmeta"
@@ -13,6 +14,8 @@ import (
 var _ yamlmeta.Type = (*DocumentType)(nil)
 var _ yamlmeta.Type = (*MapType)(nil)
 var _ yamlmeta.Type = (*MapItemType)(nil)
+var _ yamlmeta.Type = (*MapOfType)(nil)
+var _ yamlmeta.Type = (*OneOfType)(nil)
 var _ yamlmeta.Type = (*ArrayType)(nil)
 var _ yamlmeta.Type = (*ArrayItemType)(nil)
 var _ yamlmeta.Type = (*AnyType)(nil)
@@ -24,17 +27,37 @@ type DocumentType struct {
 	ValueType    yamlmeta.Type // typically one of: MapType, ArrayType, ScalarType
 	Position     *filepos.Position
 	defaultValue interface{}
+	validation   *Validation
 }
 type MapType struct {
 	Items       []*MapItemType
 	Position    *filepos.Position
 	description string
 }
+
+// MapOfType is a map with arbitrary keys, each value of which is of ValuesType (see @schema/type map_of=...)
+type MapOfType struct {
+	ValuesType   yamlmeta.Type
+	Position     *filepos.Position
+	defaultValue interface{}
+	description  string
+}
+
+// OneOfType is a value that is of any one of the Alternatives (see @schema/type one_of=[...])
+type OneOfType struct {
+	Alternatives []yamlmeta.Type
+	Position     *filepos.Position
+	defaultValue interface{}
+	description  string
+}
 type MapItemType struct {
 	Key          interface{} // usually a string
 	ValueType    yamlmeta.Type
 	Position     *filepos.Position
 	defaultValue interface{}
+	validation   *Validation
+	deprecation  *DeprecatedAnnotation
+	sensitivity  *SensitiveAnnotation
 }
 type ArrayType struct {
 	ItemsType    yamlmeta.Type
@@ -46,6 +69,7 @@ type ArrayItemType struct {
 	ValueType    yamlmeta.Type
 	Position     *filepos.Position
 	defaultValue interface{}
+	validation   *Validation
 }
 type ScalarType struct {
 	ValueType    interface{}
@@ -529,6 +553,39 @@ func (n *NullType) SetDescription(desc string) {
 	n.description = desc
 }
 
+// GetValidation provides the rules (if any) that the value of this document must satisfy
+func (t *DocumentType) GetValidation() *Validation {
+	return t.validation
+}
+
+// GetValidation provides the rules (if any) that the value of this map item must satisfy
+func (t *MapItemType) GetValidation() *Validation {
+	return t.validation
+}
+
+// IsDeprecated indicates whether this map item is going away (see @schema/deprecated)
+func (t *MapItemType) IsDeprecated() bool {
+	return t.deprecation != nil
+}
+
+// IsSensitive indicates whether the value of this map item is a secret (see @schema/sensitive)
+func (t *MapItemType) IsSensitive() bool {
+	return t.sensitivity != nil
+}
+
+// GetDeprecationMessage provides the reason this map item is deprecated (or what to use instead)
+func (t *MapItemType) GetDeprecationMessage() string {
+	if t.deprecation == nil {
+		return ""
+	}
+	return t.deprecation.message
+}
+
+// GetValidation provides the rules (if any) that the value of this array item must satisfy
+func (a *ArrayItemType) GetValidation() *Validation {
+	return a.validation
+}
+
 func (m *MapType) AllowsKey(key interface{}) bool {
 	for _, item := range m.Items {
 		if item.Key == key {
@@ -548,3 +605,188 @@ func (m *MapType) AllowedKeys() []string {
 
 	return keysAsString
 }
+
+// AssignTypeTo assigns this type to the map, and a MapItemType (having this map's ValuesType) to each of its items
+func (m *MapOfType) AssignTypeTo(typeable yamlmeta.Typeable) (chk yamlmeta.TypeCheck) {
+	mapNode, ok := typeable.(*yamlmeta.Map)
+	if !ok {
+		chk.Violations = append(chk.Violations, NewMismatchedTypeAssertionError(typeable, m))
+		return
+	}
+	typeable.SetType(m)
+	for _, mapItem := range mapNode.Items {
+		childCheck := m.itemTypeFor(mapItem.Key).AssignTypeTo(mapItem)
+		chk.Violations = append(chk.Violations, childCheck.Violations...)
+	}
+	return
+}
+
+func (m *MapOfType) itemTypeFor(key interface{}) *MapItemType {
+	return &MapItemType{Key: key, ValueType: m.ValuesType, Position: m.ValuesType.GetDefinitionPosition(),
+		defaultValue: m.ValuesType.GetDefaultValue()}
+}
+
+// GetValueType provides the type of each value in the map
+func (m *MapOfType) GetValueType() yamlmeta.Type {
+	return m.ValuesType
+}
+
+// GetDefaultValue provides the default value (an empty map, unless set otherwise)
+func (m *MapOfType) GetDefaultValue() interface{} {
+	if node, ok := m.defaultValue.(yamlmeta.Node); ok {
+		return node.DeepCopyAsInterface()
+	}
+	return &yamlmeta.Map{Position: m.Position}
+}
+
+// SetDefaultValue sets the default value to `val`
+func (m *MapOfType) SetDefaultValue(val interface{}) {
+	m.defaultValue = val
+}
+
+// checkedDefaultValue confirms that `val` (the map given in schema) is of this type, returning a copy of it.
+func (m *MapOfType) checkedDefaultValue(val interface{}) (interface{}, error) {
+	node, ok := val.(yamlmeta.Node)
+	if !ok {
+		return nil, NewSchemaError("Invalid schema - value is wrong type",
+			NewMismatchedTypeAssertionError(&yamlmeta.Scalar{Value: val, Position: m.Position}, m))
+	}
+	defaultValue := node.DeepCopyAsNode()
+	chk := m.AssignTypeTo(defaultValue.(yamlmeta.Typeable))
+	if !chk.HasViolations() {
+		chk = defaultValue.Check()
+	}
+	if chk.HasViolations() {
+		return nil, NewSchemaError("Invalid schema - value is wrong type", chk.Violations...)
+	}
+	return node.DeepCopyAsInterface(), nil
+}
+
+// CheckType confirms that the node is a map (the type of each value is checked by its MapItemType)
+func (m *MapOfType) CheckType(node yamlmeta.TypeWithValues) (chk yamlmeta.TypeCheck) {
+	if _, ok := node.(*yamlmeta.Map); !ok {
+		chk.Violations = append(chk.Violations, NewMismatchedTypeAssertionError(node, m))
+	}
+	return
+}
+
+// GetDefinitionPosition provides the file position
+func (m *MapOfType) GetDefinitionPosition() *filepos.Position {
+	return m.Position
+}
+
+func (m *MapOfType) String() string {
+	return fmt.Sprintf("map of %s", m.ValuesType.String())
+}
+
+// GetDescription provides descriptive information
+func (m *MapOfType) GetDescription() string {
+	return m.description
+}
+
+// SetDescription sets the description of the type
+func (m *MapOfType) SetDescription(desc string) {
+	m.description = desc
+}
+
+// AssignTypeTo assigns to the node the first alternative that it matches; failing that, reports every alternative tried.
+func (o *OneOfType) AssignTypeTo(typeable yamlmeta.Typeable) (chk yamlmeta.TypeCheck) {
+	var altViolations [][]error
+	for _, alt := range o.Alternatives {
+		altCheck := o.tryAlternative(alt, typeable)
+		if !altCheck.HasViolations() {
+			return alt.AssignTypeTo(typeable)
+		}
+		altViolations = append(altViolations, altCheck.Violations)
+	}
+	chk.Violations = append(chk.Violations, NewMismatchedAlternativesAssertionError(typeable, o, altViolations))
+	return
+}
+
+// tryAlternative reports whether the node would be of the type `alt`, without modifying the node.
+func (o *OneOfType) tryAlternative(alt yamlmeta.Type, typeable yamlmeta.Typeable) yamlmeta.TypeCheck {
+	node, ok := typeable.(yamlmeta.Node)
+	if !ok {
+		return alt.AssignTypeTo(typeable)
+	}
+	nodeCopy := node.DeepCopyAsNode()
+	chk := alt.AssignTypeTo(nodeCopy.(yamlmeta.Typeable))
+	if chk.HasViolations() {
+		return chk
+	}
+	return nodeCopy.Check()
+}
+
+// CheckType confirms that the (scalar) value is of at least one of the alternatives
+func (o *OneOfType) CheckType(node yamlmeta.TypeWithValues) (chk yamlmeta.TypeCheck) {
+	var altViolations [][]error
+	for _, alt := range o.Alternatives {
+		altCheck := alt.CheckType(node)
+		if !altCheck.HasViolations() {
+			return
+		}
+		altViolations = append(altViolations, altCheck.Violations)
+	}
+	chk.Violations = append(chk.Violations, NewMismatchedAlternativesAssertionError(node, o, altViolations))
+	return
+}
+
+// checkedDefaultValue confirms that `val` (the value given in schema) is of one of the alternatives, returning a copy of it.
+func (o *OneOfType) checkedDefaultValue(val interface{}) (interface{}, error) {
+	var chk yamlmeta.TypeCheck
+	if node, ok := val.(yamlmeta.Node); ok {
+		defaultValue := node.DeepCopyAsNode()
+		chk = o.AssignTypeTo(defaultValue.(yamlmeta.Typeable))
+		if !chk.HasViolations() {
+			chk = defaultValue.Check()
+		}
+		val = node.DeepCopyAsInterface()
+	} else {
+		chk = o.CheckType(&yamlmeta.Scalar{Value: val, Position: o.Position})
+	}
+	if chk.HasViolations() {
+		return nil, NewSchemaError("Invalid schema - value is wrong type", chk.Violations...)
+	}
+	return val, nil
+}
+
+// GetValueType provides this type: the type of the value is only known once it is matched to an alternative
+func (o *OneOfType) GetValueType() yamlmeta.Type {
+	return o
+}
+
+// GetDefaultValue provides the default value (the value given in schema, unless set otherwise)
+func (o *OneOfType) GetDefaultValue() interface{} {
+	if node, ok := o.defaultValue.(yamlmeta.Node); ok {
+		return node.DeepCopyAsInterface()
+	}
+	return o.defaultValue
+}
+
+// SetDefaultValue sets the default value to `val`
+func (o *OneOfType) SetDefaultValue(val interface{}) {
+	o.defaultValue = val
+}
+
+// GetDefinitionPosition provides the file position
+func (o *OneOfType) GetDefinitionPosition() *filepos.Position {
+	return o.Position
+}
+
+func (o *OneOfType) String() string {
+	var alts []string
+	for _, alt := range o.Alternatives {
+		alts = append(alts, alt.String())
+	}
+	return fmt.Sprintf("one of (%s)", strings.Join(alts, ", "))
+}
+
+// GetDescription provides descriptive information
+func (o *OneOfType) GetDescription() string {
+	return o.description
+}
+
+// SetDescription sets the description of the type
+func (o *OneOfType) SetDescription(desc string) {
+	o.description = desc
+}
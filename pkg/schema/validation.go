@@ -0,0 +1,288 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/k14s/starlark-go/starlark"
+	"github.com/k14s/starlark-go/syntax"
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/template/core"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	"github.com/k14s/ytt/pkg/yamltemplate"
+)
+
+// keyword arguments accepted by @schema/validation
+const (
+	ValidationKwargMin    string = "min"
+	ValidationKwargMax    string = "max"
+	ValidationKwargMinLen string = "min_len"
+	ValidationKwargMaxLen string = "max_len"
+	ValidationKwargOneOf  string = "one_of"
+	ValidationKwargRegex  string = "regex"
+)
+
+var validationKwargNames = []string{ValidationKwargMin, ValidationKwargMax, ValidationKwargMinLen,
+	ValidationKwargMaxLen, ValidationKwargOneOf, ValidationKwargRegex}
+
+// Validation holds the rules that a data value must satisfy, as declared via @schema/validation
+type Validation struct {
+	rules    []validationRule
+	min      starlark.Value
+	max      starlark.Value
+	minLen   *int64
+	maxLen   *int64
+	oneOf    []starlark.Value
+	regex    *regexp.Regexp
+	position *filepos.Position
+}
+
+// validationRule is a custom predicate (a Starlark function) along with the description of what it asserts
+type validationRule struct {
+	msg       string
+	assertion starlark.Callable
+}
+
+// NewValidation builds a Validation from the positional (custom rules) and keyword (declarative rules) arguments
+// of a @schema/validation annotation.
+func NewValidation(args starlark.Tuple, kwargs []starlark.Tuple, pos *filepos.Position) (*Validation, error) {
+	validation := &Validation{position: pos}
+
+	for _, arg := range args {
+		rule, ok := arg.(starlark.Tuple)
+		if !ok || len(rule) != 2 {
+			return nil, fmt.Errorf("rule must be a tuple of (description, function), but was %s", arg.Type())
+		}
+		msg, err := core.NewStarlarkValue(rule[0]).AsString()
+		if err != nil {
+			return nil, fmt.Errorf("rule description %s", err)
+		}
+		assertion, ok := rule[1].(starlark.Callable)
+		if !ok {
+			return nil, fmt.Errorf("rule '%s' expected a function, but was %s", msg, rule[1].Type())
+		}
+		validation.rules = append(validation.rules, validationRule{msg, assertion})
+	}
+
+	for _, kwarg := range kwargs {
+		kwargName, err := core.NewStarlarkValue(kwarg[0]).AsString()
+		if err != nil {
+			return nil, err
+		}
+
+		switch kwargName {
+		case ValidationKwargMin:
+			if !isNumber(kwarg[1]) {
+				return nil, fmt.Errorf("'%s' expected a number, but was %s", kwargName, kwarg[1].Type())
+			}
+			validation.min = kwarg[1]
+		case ValidationKwargMax:
+			if !isNumber(kwarg[1]) {
+				return nil, fmt.Errorf("'%s' expected a number, but was %s", kwargName, kwarg[1].Type())
+			}
+			validation.max = kwarg[1]
+		case ValidationKwargMinLen:
+			minLen, err := core.NewStarlarkValue(kwarg[1]).AsInt64()
+			if err != nil {
+				return nil, fmt.Errorf("'%s' expected an int, but was %s", kwargName, kwarg[1].Type())
+			}
+			validation.minLen = &minLen
+		case ValidationKwargMaxLen:
+			maxLen, err := core.NewStarlarkValue(kwarg[1]).AsInt64()
+			if err != nil {
+				return nil, fmt.Errorf("'%s' expected an int, but was %s", kwargName, kwarg[1].Type())
+			}
+			validation.maxLen = &maxLen
+		case ValidationKwargOneOf:
+			iterable, ok := kwarg[1].(starlark.Iterable)
+			if !ok {
+				return nil, fmt.Errorf("'%s' expected a list, but was %s", kwargName, kwarg[1].Type())
+			}
+			iter := iterable.Iterate()
+			var val starlark.Value
+			for iter.Next(&val) {
+				validation.oneOf = append(validation.oneOf, val)
+			}
+			iter.Done()
+		case ValidationKwargRegex:
+			pattern, err := core.NewStarlarkValue(kwarg[1]).AsString()
+			if err != nil {
+				return nil, fmt.Errorf("'%s' expected a string, but was %s", kwargName, kwarg[1].Type())
+			}
+			validation.regex, err = regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("'%s' is not a valid regular expression: %s", kwargName, err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown keyword argument '%s'", kwargName)
+		}
+	}
+
+	return validation, nil
+}
+
+// Validate checks `value` against every rule, returning one violation for each rule that was not satisfied.
+// Null values are not validated (nullability is a matter of type). Violations of a `sensitive` value do not
+// include that value.
+func (v *Validation) Validate(value interface{}, pos *filepos.Position, sensitive bool, thread *starlark.Thread) []error {
+	if value == nil {
+		return nil
+	}
+
+	var violations []error
+	fail := func(expected, found string) {
+		violations = append(violations, schemaAssertionError{
+			position:  pos,
+			sensitive: sensitive,
+			expected:  fmt.Sprintf("%s (by %s)", expected, v.position.AsCompactString()),
+			found:     found,
+		})
+	}
+
+	starlarkVal := yamltemplate.NewGoValueWithYAML(value).AsStarlarkValue()
+
+	if v.min != nil {
+		ok, err := starlark.Compare(syntax.GE, starlarkVal, v.min)
+		if err != nil || !ok {
+			fail(fmt.Sprintf("a value greater or equal to %s", v.min), v.describe(value, sensitive))
+		}
+	}
+	if v.max != nil {
+		ok, err := starlark.Compare(syntax.LE, starlarkVal, v.max)
+		if err != nil || !ok {
+			fail(fmt.Sprintf("a value less than or equal to %s", v.max), v.describe(value, sensitive))
+		}
+	}
+	if v.minLen != nil || v.maxLen != nil {
+		length := starlark.Len(starlarkVal)
+		if v.minLen != nil && int64(length) < *v.minLen {
+			fail(fmt.Sprintf("length greater or equal to %d", *v.minLen), v.describeLen(value, sensitive, length))
+		}
+		if v.maxLen != nil && (length < 0 || int64(length) > *v.maxLen) {
+			fail(fmt.Sprintf("length less than or equal to %d", *v.maxLen), v.describeLen(value, sensitive, length))
+		}
+	}
+	if v.oneOf != nil {
+		found := false
+		for _, allowed := range v.oneOf {
+			if eq, err := starlark.Equal(starlarkVal, allowed); err == nil && eq {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail(fmt.Sprintf("one of %s", starlark.NewList(v.oneOf)), v.describe(value, sensitive))
+		}
+	}
+	if v.regex != nil {
+		str, isString := value.(string)
+		if !isString || !v.regex.MatchString(str) {
+			fail(fmt.Sprintf("a string matching regular expression '%s'", v.regex), v.describe(value, sensitive))
+		}
+	}
+
+	for _, rule := range v.rules {
+		result, err := starlark.Call(thread, rule.assertion, starlark.Tuple{starlarkVal}, nil)
+		if err != nil {
+			reason := err.Error()
+			if sensitive {
+				// an error raised by the rule may well quote the value
+				reason = "rule function failed"
+			}
+			fail(rule.msg, fmt.Sprintf("%s (%s)", v.describe(value, sensitive), reason))
+			continue
+		}
+		passed, err := core.NewStarlarkValue(result).AsBool()
+		if err != nil {
+			fail(rule.msg, fmt.Sprintf("%s (rule function must return a bool, but returned %s)", v.describe(value, sensitive), result.Type()))
+			continue
+		}
+		if !passed {
+			fail(rule.msg, v.describe(value, sensitive))
+		}
+	}
+
+	return violations
+}
+
+// GetPosition returns position of the @schema/validation annotation that declared these rules.
+func (v *Validation) GetPosition() *filepos.Position {
+	return v.position
+}
+
+func (v *Validation) describe(value interface{}, sensitive bool) string {
+	if node, ok := value.(yamlmeta.TypeWithValues); ok {
+		return node.ValueTypeAsString()
+	}
+	if sensitive {
+		return filepos.RedactedValue
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func (v *Validation) describeLen(value interface{}, sensitive bool, length int) string {
+	if length < 0 {
+		return fmt.Sprintf("%s (which has no length)", v.describe(value, sensitive))
+	}
+	return fmt.Sprintf("length %d", length)
+}
+
+func isNumber(val starlark.Value) bool {
+	switch val.(type) {
+	case starlark.Int, starlark.Float:
+		return true
+	}
+	return false
+}
+
+// ValidateDataValues checks each value in `doc` against the rules given by its (previously assigned) type.
+// All violations are collected and reported together.
+func ValidateDataValues(doc *yamlmeta.Document) error {
+	thread := &starlark.Thread{Name: "schema-validation"}
+
+	violations := validateNode(doc, thread)
+	if len(violations) > 0 {
+		return NewSchemaError("One or more data values failed validation", violations...)
+	}
+	return nil
+}
+
+func validateNode(node yamlmeta.Node, thread *starlark.Thread) []error {
+	var violations []error
+	var validation *Validation
+
+	switch typedNode := node.(type) {
+	case *yamlmeta.Document:
+		if docType, ok := typedNode.Type.(*DocumentType); ok {
+			validation = docType.validation
+		}
+	case *yamlmeta.MapItem:
+		if itemType, ok := typedNode.Type.(*MapItemType); ok {
+			validation = itemType.validation
+		}
+	case *yamlmeta.ArrayItem:
+		if itemType, ok := typedNode.Type.(*ArrayItemType); ok {
+			validation = itemType.validation
+		}
+	}
+
+	if validation != nil {
+		value := node.GetValues()[0]
+		pos := node.GetPosition()
+		// when a collection was replaced, its own position points at where the value was set
+		if valueNode, ok := value.(yamlmeta.Node); ok && valueNode.GetPosition().IsKnown() {
+			pos = valueNode.GetPosition()
+		}
+		violations = append(violations, validation.Validate(value, pos, yamlmeta.IsSensitive(node), thread)...)
+	}
+
+	for _, child := range node.GetValues() {
+		if childNode, ok := child.(yamlmeta.Node); ok {
+			violations = append(violations, validateNode(childNode, thread)...)
+		}
+	}
+	return violations
+}
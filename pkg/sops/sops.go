@@ -0,0 +1,457 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sops decrypts YAML (and JSON) documents encrypted with SOPS (https://github.com/mozilla/sops),
+// using locally supplied age or PGP keys. Decrypted values are only ever held in memory.
+package sops
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"filippo.io/age"
+	ageArmor "filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgpArmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+const (
+	metadataKey = "sops"
+	// as does SOPS, when a file does not specify which values are encrypted
+	defaultUnencryptedSuffix = "_unencrypted"
+)
+
+var (
+	encryptedValueRegexp = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.+),iv:(.+),tag:(.+),type:(.+)\]$`)
+	// master keys that are held by a remote service (rather than supplied locally)
+	remoteMasterKeyKinds = []string{"kms", "gcp_kms", "azure_kv", "hc_vault"}
+)
+
+// Keys are the locally supplied keys with which to decrypt the data key of SOPS encrypted documents.
+type Keys struct {
+	ageIdentities []age.Identity
+	pgpKeyRing    openpgp.EntityList
+}
+
+// AddAgeIdentities adds the age identities (i.e. "AGE-SECRET-KEY-..." lines) in `contents`.
+func (k *Keys) AddAgeIdentities(contents []byte) error {
+	ids, err := age.ParseIdentities(bytes.NewReader(contents))
+	if err != nil {
+		return fmt.Errorf("Parsing age identities: %s", err)
+	}
+	k.ageIdentities = append(k.ageIdentities, ids...)
+	return nil
+}
+
+// AddPGPKeys adds the PGP private keys (armored or binary, not protected by passphrase) in `contents`.
+func (k *Keys) AddPGPKeys(contents []byte) error {
+	keyRing, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(contents))
+	if err != nil {
+		keyRing, err = openpgp.ReadKeyRing(bytes.NewReader(contents))
+		if err != nil {
+			return fmt.Errorf("Parsing PGP keys: %s", err)
+		}
+	}
+	for _, entity := range keyRing {
+		if entity.PrivateKey == nil {
+			return fmt.Errorf("Expected PGP key '%X' to include its private key", entity.PrimaryKey.Fingerprint)
+		}
+	}
+	k.pgpKeyRing = append(k.pgpKeyRing, keyRing...)
+	return nil
+}
+
+// IsEncrypted indicates whether `docs` (i.e. those of one file) were encrypted by SOPS.
+func IsEncrypted(docs []*yamlmeta.Document) bool {
+	for _, doc := range docs {
+		if _, found := findMetadata(doc); found {
+			return true
+		}
+	}
+	return false
+}
+
+// Decrypt replaces each encrypted value in `docs` (i.e. those of one file) with its plaintext, marking each such
+// value as sensitive, and drops the SOPS metadata. The integrity of the (decrypted) file is verified.
+func Decrypt(docs []*yamlmeta.Document, keys *Keys) error {
+	meta, err := extractMetadata(docs)
+	if err != nil {
+		return err
+	}
+
+	dataKey, err := meta.dataKey(keys)
+	if err != nil {
+		return err
+	}
+
+	// as does SOPS, authenticate all values (in order) across all documents
+	macHash := sha512.New()
+	for _, doc := range docs {
+		err := meta.decryptNode(doc.Value, nil, dataKey, macHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	mac, err := decryptValue(meta.mac, dataKey, meta.lastModified)
+	if err != nil {
+		return fmt.Errorf("Decrypting MAC: %s", err)
+	}
+	if mac != fmt.Sprintf("%X", macHash.Sum(nil)) {
+		return fmt.Errorf("Expected MAC to match contents (was the file modified after being encrypted?)")
+	}
+	return nil
+}
+
+type metadata struct {
+	lastModified     string
+	mac              string
+	macOnlyEncrypted bool
+	ageKeys          []masterKey
+	pgpKeys          []masterKey
+
+	// at most one of these (i.e. the rule determining which values are encrypted) is set
+	unencryptedSuffix string
+	encryptedSuffix   string
+	unencryptedRegex  *regexp.Regexp
+	encryptedRegex    *regexp.Regexp
+}
+
+type masterKey struct {
+	ID           string
+	EncryptedKey string
+}
+
+func findMetadata(doc *yamlmeta.Document) (*yamlmeta.Map, bool) {
+	docMap, isMap := doc.Value.(*yamlmeta.Map)
+	if !isMap {
+		return nil, false
+	}
+	for _, item := range docMap.Items {
+		if item.Key == metadataKey {
+			metaMap, isMap := item.Value.(*yamlmeta.Map)
+			if isMap && hasKey(metaMap, "mac") {
+				return metaMap, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// extractMetadata reads SOPS metadata (held by each document), removing it from the documents
+func extractMetadata(docs []*yamlmeta.Document) (metadata, error) {
+	var meta metadata
+	var metaMap *yamlmeta.Map
+
+	for _, doc := range docs {
+		found, isFound := findMetadata(doc)
+		if !isFound {
+			return metadata{}, fmt.Errorf("Expected each document to include SOPS metadata (key '%s')", metadataKey)
+		}
+		if metaMap == nil {
+			metaMap = found
+		}
+		docMap := doc.Value.(*yamlmeta.Map)
+		var items []*yamlmeta.MapItem
+		for _, item := range docMap.Items {
+			if item.Key != metadataKey {
+				items = append(items, item)
+			}
+		}
+		docMap.Items = items
+	}
+
+	for _, item := range metaMap.Items {
+		switch item.Key {
+		case "lastmodified":
+			meta.lastModified = fmt.Sprintf("%v", item.Value)
+		case "mac":
+			meta.mac = fmt.Sprintf("%v", item.Value)
+		case "mac_only_encrypted":
+			meta.macOnlyEncrypted = item.Value == true
+		case "key_groups":
+			if arr, isArr := item.Value.(*yamlmeta.Array); isArr && len(arr.Items) > 0 {
+				return metadata{}, fmt.Errorf("Decrypting files encrypted for key groups (i.e. with Shamir's secret sharing) is not supported")
+			}
+		case "age":
+			meta.ageKeys = masterKeys(item.Value, "recipient")
+		case "pgp":
+			meta.pgpKeys = masterKeys(item.Value, "fp")
+		case "unencrypted_suffix":
+			meta.unencryptedSuffix = fmt.Sprintf("%v", item.Value)
+		case "encrypted_suffix":
+			meta.encryptedSuffix = fmt.Sprintf("%v", item.Value)
+		case "unencrypted_regex", "encrypted_regex":
+			re, err := regexp.Compile(fmt.Sprintf("%v", item.Value))
+			if err != nil {
+				return metadata{}, fmt.Errorf("Parsing '%s': %s", item.Key, err)
+			}
+			if item.Key == "unencrypted_regex" {
+				meta.unencryptedRegex = re
+			} else {
+				meta.encryptedRegex = re
+			}
+		}
+	}
+
+	rules := 0
+	for _, isSet := range []bool{meta.unencryptedSuffix != "", meta.encryptedSuffix != "", meta.unencryptedRegex != nil, meta.encryptedRegex != nil} {
+		if isSet {
+			rules++
+		}
+	}
+	switch {
+	case rules > 1:
+		return metadata{}, fmt.Errorf("Expected at most one of unencrypted_suffix, encrypted_suffix, unencrypted_regex or encrypted_regex")
+	case rules == 0:
+		meta.unencryptedSuffix = defaultUnencryptedSuffix
+	}
+	return meta, nil
+}
+
+// isEncrypted indicates whether values at `path` are encrypted, as determined (as does SOPS) by any of its keys
+func (m metadata) isEncrypted(path []string) bool {
+	anyKey := func(matches func(string) bool) bool {
+		for _, key := range path {
+			if matches(key) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case m.unencryptedSuffix != "":
+		return !anyKey(func(key string) bool { return strings.HasSuffix(key, m.unencryptedSuffix) })
+	case m.encryptedSuffix != "":
+		return anyKey(func(key string) bool { return strings.HasSuffix(key, m.encryptedSuffix) })
+	case m.unencryptedRegex != nil:
+		return !anyKey(m.unencryptedRegex.MatchString)
+	case m.encryptedRegex != nil:
+		return anyKey(m.encryptedRegex.MatchString)
+	}
+	return true
+}
+
+func masterKeys(val interface{}, idKey string) []masterKey {
+	var result []masterKey
+	arr, isArr := val.(*yamlmeta.Array)
+	if !isArr {
+		return nil
+	}
+	for _, arrItem := range arr.Items {
+		keyMap, isMap := arrItem.Value.(*yamlmeta.Map)
+		if !isMap {
+			continue
+		}
+		var key masterKey
+		for _, item := range keyMap.Items {
+			switch item.Key {
+			case idKey:
+				key.ID = fmt.Sprintf("%v", item.Value)
+			case "enc":
+				key.EncryptedKey = fmt.Sprintf("%v", item.Value)
+			}
+		}
+		result = append(result, key)
+	}
+	return result
+}
+
+func hasKey(m *yamlmeta.Map, key string) bool {
+	for _, item := range m.Items {
+		if item.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// dataKey decrypts the key with which values were encrypted, using the first supplied key it was encrypted for
+func (m metadata) dataKey(keys *Keys) ([]byte, error) {
+	var errs []string
+
+	if len(keys.ageIdentities) > 0 {
+		for _, key := range m.ageKeys {
+			decrypted, err := age.Decrypt(ageArmor.NewReader(strings.NewReader(key.EncryptedKey)), keys.ageIdentities...)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("age recipient '%s': %s", key.ID, err))
+				continue
+			}
+			return ioutil.ReadAll(decrypted)
+		}
+	}
+
+	if len(keys.pgpKeyRing) > 0 {
+		for _, key := range m.pgpKeys {
+			block, err := pgpArmor.Decode(strings.NewReader(key.EncryptedKey))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("PGP key '%s': %s", key.ID, err))
+				continue
+			}
+			msg, err := openpgp.ReadMessage(block.Body, keys.pgpKeyRing, nil, nil)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("PGP key '%s': %s", key.ID, err))
+				continue
+			}
+			return ioutil.ReadAll(msg.UnverifiedBody)
+		}
+	}
+
+	var encryptedFor []string
+	for _, key := range m.ageKeys {
+		encryptedFor = append(encryptedFor, "age recipient "+key.ID)
+	}
+	for _, key := range m.pgpKeys {
+		encryptedFor = append(encryptedFor, "PGP key "+key.ID)
+	}
+	errMsg := fmt.Sprintf("Expected one of supplied age or PGP keys to decrypt the data key (encrypted for: %s)", strings.Join(encryptedFor, ", "))
+	if len(encryptedFor) == 0 {
+		errMsg = fmt.Sprintf("Expected file to be encrypted for age or PGP keys (keys of kinds %s are not supported)", strings.Join(remoteMasterKeyKinds, ", "))
+	}
+	if len(errs) > 0 {
+		errMsg += fmt.Sprintf(": %s", strings.Join(errs, "; "))
+	}
+	return nil, fmt.Errorf("%s", errMsg)
+}
+
+func (m metadata) decryptNode(node interface{}, path []string, dataKey []byte, macHash hash.Hash) error {
+	switch typedNode := node.(type) {
+	case *yamlmeta.Map:
+		for _, item := range typedNode.Items {
+			key, isString := item.Key.(string)
+			if !isString {
+				key = fmt.Sprintf("%v", item.Key)
+			}
+			_, err := m.decryptItem(item, append(append([]string{}, path...), key), dataKey, macHash)
+			if err != nil {
+				return err
+			}
+		}
+	case *yamlmeta.Array:
+		// (as does SOPS) array items are authenticated by the path to their array
+		var items []*yamlmeta.ArrayItem
+		for _, item := range typedNode.Items {
+			isComment, err := m.decryptItem(item, path, dataKey, macHash)
+			if err != nil {
+				return err
+			}
+			if !isComment {
+				items = append(items, item)
+			}
+		}
+		typedNode.Items = items
+	}
+	return nil
+}
+
+// decryptItem decrypts the value of `item`, indicating whether it holds (what was) a YAML comment rather than a value:
+// SOPS stores comments within arrays as array items.
+func (m metadata) decryptItem(item yamlmeta.Node, path []string, dataKey []byte, macHash hash.Hash) (bool, error) {
+	val := item.GetValues()[0]
+
+	switch typedVal := val.(type) {
+	case *yamlmeta.Map, *yamlmeta.Array:
+		return false, m.decryptNode(typedVal, path, dataKey, macHash)
+	case nil:
+		// (as does SOPS) null values are neither encrypted nor authenticated
+		return false, nil
+	}
+
+	if !m.isEncrypted(path) {
+		if !m.macOnlyEncrypted {
+			macHash.Write(valueAsBytes(val))
+		}
+		return false, nil
+	}
+
+	encrypted, isString := val.(string)
+	matches := encryptedValueRegexp.FindStringSubmatch(encrypted)
+	if !isString || matches == nil {
+		return false, fmt.Errorf("Expected value of '%s' (%s) to be encrypted", strings.Join(path, "."), item.GetPosition().AsCompactString())
+	}
+	if matches[4] == "comment" {
+		// (as does SOPS) comments are not authenticated
+		return true, nil
+	}
+
+	plaintext, err := decryptValue(encrypted, dataKey, strings.Join(path, ":")+":")
+	if err != nil {
+		return false, fmt.Errorf("Decrypting value of '%s' (%s): %s", strings.Join(path, "."), item.GetPosition().AsCompactString(), err)
+	}
+	macHash.Write(valueAsBytes(plaintext))
+	item.SetValue(plaintext)
+	yamlmeta.MarkSensitive(item)
+	return false, nil
+}
+
+// decryptValue decrypts a value of the form "ENC[AES256_GCM,data:...,iv:...,tag:...,type:...]",
+// parsing it into its original type.
+func decryptValue(encrypted string, dataKey []byte, additionalData string) (interface{}, error) {
+	matches := encryptedValueRegexp.FindStringSubmatch(encrypted)
+	if matches == nil {
+		return nil, fmt.Errorf("Expected encrypted value to be of the form ENC[AES256_GCM,data:...,iv:...,tag:...,type:...]")
+	}
+
+	var parts [3][]byte
+	for i := range parts {
+		decoded, err := base64.StdEncoding.DecodeString(matches[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("Decoding encrypted value: %s", err)
+		}
+		parts[i] = decoded
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, fmt.Errorf("Authenticating encrypted value: %s", err)
+	}
+
+	switch valType := matches[4]; valType {
+	case "str", "bytes":
+		return string(plaintext), nil
+	case "int":
+		return strconv.Atoi(string(plaintext))
+	case "float":
+		return strconv.ParseFloat(string(plaintext), 64)
+	case "bool":
+		return strconv.ParseBool(strings.ToLower(string(plaintext)))
+	default:
+		return nil, fmt.Errorf("Unknown type of encrypted value '%s'", valType)
+	}
+}
+
+// valueAsBytes serializes (plaintext) values as SOPS does to compute the MAC
+func valueAsBytes(val interface{}) []byte {
+	switch typedVal := val.(type) {
+	case string:
+		return []byte(typedVal)
+	case float64:
+		return []byte(strconv.FormatFloat(typedVal, 'f', -1, 64))
+	case bool:
+		if typedVal {
+			return []byte("True")
+		}
+		return []byte("False")
+	default:
+		return []byte(fmt.Sprintf("%v", typedVal))
+	}
+}
@@ -84,7 +84,7 @@ func (e StarlarkValue) asInterface(val starlark.Value) (interface{}, error) {
 		if ok {
 			return i2, nil
 		}
-		panic("not sure how to get int") // TODO
+		return nil, fmt.Errorf("integer %s is out of range for conversion to go value", typedVal)
 
 	case starlark.Float:
 		return float64(typedVal), nil
@@ -105,7 +105,7 @@ func (e StarlarkValue) asInterface(val starlark.Value) (interface{}, error) {
 		return e.itearableAsInterface(typedVal)
 
 	default:
-		panic(fmt.Sprintf("unknown type %T for conversion to go value", val))
+		return nil, fmt.Errorf("unknown type %T for conversion to go value", val)
 	}
 }
 
@@ -23,19 +23,25 @@ const (
 type DataValues struct {
 	Doc         *yamlmeta.Document
 	AfterLibMod bool
-	used        bool
+	// Profile names the profile this document belongs to (see --data-values-profile); empty if it always applies
+	Profile string
+	// AppliedProfiles lists the profiles whose documents were applied (only known for the result of pre-processing)
+	AppliedProfiles []string
+	// Provenance records the sources that set each data value (only known for the result of pre-processing)
+	Provenance *DataValuesProvenance
+	used       bool
 
 	originalLibRef []ref.LibraryRef
 	libRef         []ref.LibraryRef
 }
 
 func NewDataValues(doc *yamlmeta.Document) (*DataValues, error) {
-	libRef, afterLibMod, err := parseDVAnnotations(ref.LibraryRefExtractor{}, doc)
+	libRef, afterLibMod, profile, err := parseDVAnnotations(ref.LibraryRefExtractor{}, doc)
 	if err != nil {
 		return nil, err
 	}
 
-	return &DataValues{Doc: doc, AfterLibMod: afterLibMod, libRef: libRef, originalLibRef: libRef}, nil
+	return &DataValues{Doc: doc, AfterLibMod: afterLibMod, Profile: profile, libRef: libRef, originalLibRef: libRef}, nil
 }
 
 func NewEmptyDataValues() *DataValues {
@@ -60,14 +66,14 @@ func NewDataValuesWithLib(libRefs ExtractLibRefs, doc *yamlmeta.Document, libRef
 		return nil, err
 	}
 
-	libRefsFromAnnotation, afterLibMod, err := parseDVAnnotations(libRefs, doc)
+	libRefsFromAnnotation, afterLibMod, profile, err := parseDVAnnotations(libRefs, doc)
 	if err != nil {
 		return nil, err
 	} else if len(libRefsFromAnnotation) > 0 {
 		panic(fmt.Sprintf("Library was provided as arg as well as with %s annotation", AnnotationLibraryRef))
 	}
 
-	return &DataValues{Doc: doc, AfterLibMod: afterLibMod, libRef: libRefsFromStr, originalLibRef: libRefsFromStr}, nil
+	return &DataValues{Doc: doc, AfterLibMod: afterLibMod, Profile: profile, libRef: libRefsFromStr, originalLibRef: libRefsFromStr}, nil
 }
 
 func NewDataValuesWithOptionalLib(doc *yamlmeta.Document, libRefStr string) (*DataValues, error) {
@@ -90,6 +96,11 @@ func (dvd *DataValues) Desc() string {
 		strings.Join(desc, dvsLibrarySep), dvd.Doc.Position.AsString())
 }
 
+// Redactor conceals the values of sensitive data values (see @schema/sensitive and --data-value-sensitive)
+func (dvd *DataValues) Redactor() *yamlmeta.Redactor {
+	return yamlmeta.NewRedactor(dvd.Doc)
+}
+
 func (dvd *DataValues) IntendedForAnotherLibrary() bool { return len(dvd.libRef) > 0 }
 
 func (dvd *DataValues) UsedInLibrary(expectedRefPiece ref.LibraryRef) *DataValues {
@@ -109,37 +120,45 @@ func (dvd *DataValues) UsedInLibrary(expectedRefPiece ref.LibraryRef) *DataValue
 func (dvd *DataValues) deepCopy() *DataValues {
 	var copiedPieces []ref.LibraryRef
 	copiedPieces = append(copiedPieces, dvd.libRef...)
-	return &DataValues{Doc: dvd.Doc.DeepCopy(), AfterLibMod: dvd.AfterLibMod,
+	return &DataValues{Doc: dvd.Doc.DeepCopy(), AfterLibMod: dvd.AfterLibMod, Profile: dvd.Profile,
 		libRef: copiedPieces, originalLibRef: dvd.originalLibRef}
 }
 
-func parseDVAnnotations(libRefs ExtractLibRefs, doc *yamlmeta.Document) ([]ref.LibraryRef, bool, error) {
+func parseDVAnnotations(libRefs ExtractLibRefs, doc *yamlmeta.Document) ([]ref.LibraryRef, bool, string, error) {
 	var afterLibMod bool
+	var profile string
 	anns := template.NewAnnotations(doc)
 
 	libRef, err := libRefs.FromAnnotation(anns)
 	if err != nil {
-		return nil, false, err
+		return nil, false, "", err
 	}
 
 	for _, kwarg := range anns.Kwargs(AnnotationDataValues) {
 		kwargName, err := core.NewStarlarkValue(kwarg[0]).AsString()
 		if err != nil {
-			return nil, false, err
+			return nil, false, "", err
 		}
 
 		switch kwargName {
 		case "after_library_module":
 			afterLibMod, err = core.NewStarlarkValue(kwarg[1]).AsBool()
 			if err != nil {
-				return nil, false, err
+				return nil, false, "", err
 			} else if len(libRef) == 0 {
-				return nil, false, fmt.Errorf("Annotation %s: Expected kwarg 'after_library_module' to be used with %s annotation",
+				return nil, false, "", fmt.Errorf("Annotation %s: Expected kwarg 'after_library_module' to be used with %s annotation",
 					AnnotationDataValues, AnnotationLibraryRef)
 			}
+		case "profile":
+			profile, err = core.NewStarlarkValue(kwarg[1]).AsString()
+			if err != nil {
+				return nil, false, "", err
+			} else if len(profile) == 0 {
+				return nil, false, "", fmt.Errorf("Annotation %s: Expected kwarg 'profile' to be a non-empty string", AnnotationDataValues)
+			}
 		default:
-			return nil, false, fmt.Errorf("Unknown kwarg %s for annotation %s", kwargName, AnnotationDataValues)
+			return nil, false, "", fmt.Errorf("Unknown kwarg %s for annotation %s", kwargName, AnnotationDataValues)
 		}
 	}
-	return libRef, afterLibMod, nil
+	return libRef, afterLibMod, profile, nil
 }
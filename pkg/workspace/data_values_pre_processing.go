@@ -5,39 +5,60 @@ package workspace
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/k14s/starlark-go/starlark"
+	"github.com/k14s/ytt/pkg/cmd/ui"
 	"github.com/k14s/ytt/pkg/schema"
+	"github.com/k14s/ytt/pkg/template"
 	"github.com/k14s/ytt/pkg/yamlmeta"
 	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
 )
 
+// AnnotationCoerceToSchemaType marks a data value given as a plain string (e.g. via --data-value), to be parsed
+// into the scalar type (if any) declared for it in schema.
+const AnnotationCoerceToSchemaType template.AnnotationName = "data/coerce-to-schema-type"
+
 type DataValuesPreProcessing struct {
 	valuesFiles           []*FileInLibrary
+	computedValuesFiles   []*FileInLibrary
 	valuesOverlays        []*DataValues
 	schema                Schema
 	loader                *TemplateLoader
+	ui                    ui.UI
 	IgnoreUnknownComments bool // TODO remove?
+
+	deprecatedAsErrors bool
+	valueResolvers     ValueResolvers
+	profiles           []string
 }
 
 func (o DataValuesPreProcessing) Apply() (*DataValues, []*DataValues, error) {
 	files := append([]*FileInLibrary{}, o.valuesFiles...)
+	computedFiles := append([]*FileInLibrary{}, o.computedValuesFiles...)
 
 	// Respect assigned file order for data values overlaying to succeed
 	SortFilesInLibrary(files)
+	SortFilesInLibrary(computedFiles)
 
-	dataValues, libraryDataValues, err := o.apply(files)
+	dataValues, libraryDataValues, err := o.apply(files, computedFiles)
 	if err != nil {
 		errMsg := "Overlaying data values (in following order: %s): %s"
-		return nil, nil, fmt.Errorf(errMsg, o.allFileDescs(files), err)
+		return nil, nil, fmt.Errorf(errMsg, o.allFileDescs(files, computedFiles), err)
+	}
+
+	// Validations apply to the final data values, so are checked only once all overlays have been applied
+	err = schema.ValidateDataValues(dataValues.Doc)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	return dataValues, libraryDataValues, nil
 }
 
-func (o DataValuesPreProcessing) apply(files []*FileInLibrary) (*DataValues, []*DataValues, error) {
-	allDvs, err := o.collectDataValuesDocs(files)
+func (o DataValuesPreProcessing) apply(files, computedFiles []*FileInLibrary) (*DataValues, []*DataValues, error) {
+	allDvs, appliedProfiles, err := o.collectDataValuesDocs(files)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -45,21 +66,34 @@ func (o DataValuesPreProcessing) apply(files []*FileInLibrary) (*DataValues, []*
 	// merge all Data Values YAML documents into one
 	var otherLibraryDVs []*DataValues
 	var resultDVsDoc *yamlmeta.Document
-	for _, dv := range allDvs {
+	provenance := NewDataValuesProvenance()
+	hasDefaults := o.schema.DefaultDataValues() != nil
+	for i, dv := range allDvs {
 		if dv.IntendedForAnotherLibrary() {
 			otherLibraryDVs = append(otherLibraryDVs, dv)
 			continue
 		}
 
 		if resultDVsDoc == nil {
+			provenance.Record(dv.Doc, i == 0 && hasDefaults)
 			resultDVsDoc = dv.Doc
 		} else {
+			o.coerceToSchemaTypes(dv.Doc.Value, resultDVsDoc.Type)
+			provenance.Record(dv.Doc, false)
+			err = o.checkDeprecatedUsage(dv.Doc.Value, resultDVsDoc.Type, "")
+			if err != nil {
+				return nil, nil, err
+			}
 			resultDVsDoc, err = o.overlay(resultDVsDoc, dv.Doc)
 			if err != nil {
 				return nil, nil, err
 			}
 		}
 		typeCheck := o.typeAndCheck(resultDVsDoc)
+		if len(typeCheck.Violations) > 0 && len(o.valueResolvers) > 0 {
+			// references are resolved once all overlays have been applied: only then are their values checked
+			typeCheck = o.typeAndCheck(o.withoutRefs(resultDVsDoc))
+		}
 		if len(typeCheck.Violations) > 0 {
 			return nil, nil, schema.NewSchemaError("One or more data values were invalid", typeCheck.Violations...)
 		}
@@ -68,27 +102,110 @@ func (o DataValuesPreProcessing) apply(files []*FileInLibrary) (*DataValues, []*
 	if resultDVsDoc == nil {
 		resultDVsDoc = newEmptyDataValuesDocument()
 	}
+
+	if len(o.valueResolvers) > 0 {
+		err = o.resolveRefs(resultDVsDoc.Value, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		typeCheck := o.typeAndCheck(resultDVsDoc)
+		if len(typeCheck.Violations) > 0 {
+			return nil, nil, schema.NewSchemaError("One or more data values were invalid", typeCheck.Violations...)
+		}
+	}
+
+	resultDVsDoc, err = o.applyComputed(resultDVsDoc, computedFiles, provenance)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	dataValues, err := NewDataValues(resultDVsDoc)
 	if err != nil {
 		return nil, nil, err
 	}
+	dataValues.Provenance = provenance
+	dataValues.AppliedProfiles = appliedProfiles
 	return dataValues, otherLibraryDVs, nil
 }
 
-func (o DataValuesPreProcessing) collectDataValuesDocs(files []*FileInLibrary) ([]*DataValues, error) {
+// collectDataValuesDocs orders data values documents as they are to be applied: schema defaults, then documents
+// from data values files, then those of the selected profiles (in the order profiles were given) and lastly those
+// given via flags. Documents of profiles that were not selected are dropped.
+func (o DataValuesPreProcessing) collectDataValuesDocs(files []*FileInLibrary) ([]*DataValues, []string, error) {
 	var allDvs []*DataValues
 	if defaults := o.schema.DefaultDataValues(); defaults != nil {
 		dv, err := NewDataValues(defaults)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		// o.schema has already been determined to be the schema for the current library.
 		// set the default data value libref to nil, signaling that it is for the current library.
 		dv.libRef = nil
 		allDvs = append(allDvs, dv)
 	}
+	profiledDvs := map[string][]*DataValues{}
+	for _, fileInLib := range files {
+		docs, err := o.extractDataValueDocs(fileInLib, o.loader, AnnotationDataValues)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Templating file '%s': %s", fileInLib.File.RelativePath(), err)
+		}
+		for _, doc := range docs {
+			dv, err := NewDataValues(doc)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(dv.Profile) > 0 {
+				profiledDvs[dv.Profile] = append(profiledDvs[dv.Profile], dv)
+				continue
+			}
+			allDvs = append(allDvs, dv)
+		}
+	}
+
+	var appliedProfiles []string
+	applied := map[string]bool{}
+	markApplied := func(profile string) {
+		if !applied[profile] {
+			applied[profile] = true
+			appliedProfiles = append(appliedProfiles, profile)
+		}
+	}
+
+	selected := map[string]bool{}
+	for _, profile := range o.profiles {
+		if selected[profile] {
+			continue
+		}
+		selected[profile] = true
+		if len(profiledDvs[profile]) > 0 {
+			allDvs = append(allDvs, profiledDvs[profile]...)
+			markApplied(profile)
+		}
+	}
+	for _, dv := range o.valuesOverlays {
+		if len(dv.Profile) > 0 {
+			if !selected[dv.Profile] {
+				continue
+			}
+			markApplied(dv.Profile)
+		}
+		allDvs = append(allDvs, dv)
+	}
+	return allDvs, appliedProfiles, nil
+}
+
+// applyComputed overlays the documents of computed data values files (i.e. @data/values-computed) onto the data
+// values, a file at a time. Each file is evaluated with data.values as they are once all prior files were applied.
+func (o DataValuesPreProcessing) applyComputed(resultDVsDoc *yamlmeta.Document, files []*FileInLibrary,
+	provenance *DataValuesProvenance) (*yamlmeta.Document, error) {
+
 	for _, fileInLib := range files {
-		docs, err := o.extractDataValueDocs(fileInLib)
+		values, err := NewDataValues(resultDVsDoc)
+		if err != nil {
+			return nil, err
+		}
+
+		docs, err := o.extractDataValueDocs(fileInLib, o.loader.withValues(values), AnnotationDataValuesComputed)
 		if err != nil {
 			return nil, fmt.Errorf("Templating file '%s': %s", fileInLib.File.RelativePath(), err)
 		}
@@ -97,15 +214,28 @@ func (o DataValuesPreProcessing) collectDataValuesDocs(files []*FileInLibrary) (
 			if err != nil {
 				return nil, err
 			}
-			allDvs = append(allDvs, dv)
+			if dv.IntendedForAnotherLibrary() {
+				return nil, fmt.Errorf("Expected computed data values (%s) to be for the current library, but was annotated with @%s",
+					doc.Position.AsCompactString(), AnnotationLibraryRef)
+			}
+
+			provenance.Record(doc, false)
+			resultDVsDoc, err = o.overlay(resultDVsDoc, doc)
+			if err != nil {
+				return nil, err
+			}
+			typeCheck := o.typeAndCheck(resultDVsDoc)
+			if len(typeCheck.Violations) > 0 {
+				return nil, schema.NewSchemaError("One or more data values were invalid", typeCheck.Violations...)
+			}
 		}
 	}
-	allDvs = append(allDvs, o.valuesOverlays...)
-	return allDvs, nil
+	return resultDVsDoc, nil
 }
 
 func (o DataValuesPreProcessing) typeAndCheck(dataValuesDoc *yamlmeta.Document) yamlmeta.TypeCheck {
 	chk := o.schema.AssignType(dataValuesDoc)
+	o.markSensitive(dataValuesDoc.Value)
 	if _, checkable := o.schema.(*schema.DocumentSchema); checkable {
 		if len(chk.Violations) > 0 {
 			return chk
@@ -115,7 +245,7 @@ func (o DataValuesPreProcessing) typeAndCheck(dataValuesDoc *yamlmeta.Document)
 	return chk
 }
 
-func (o DataValuesPreProcessing) allFileDescs(files []*FileInLibrary) string {
+func (o DataValuesPreProcessing) allFileDescs(files, computedFiles []*FileInLibrary) string {
 	var result []string
 	for _, fileInLib := range files {
 		result = append(result, fileInLib.File.RelativePath())
@@ -123,19 +253,24 @@ func (o DataValuesPreProcessing) allFileDescs(files []*FileInLibrary) string {
 	if len(o.valuesOverlays) > 0 {
 		result = append(result, "additional data values")
 	}
+	for _, fileInLib := range computedFiles {
+		result = append(result, fileInLib.File.RelativePath())
+	}
 	return strings.Join(result, ", ")
 }
 
-func (o DataValuesPreProcessing) extractDataValueDocs(fileInLib *FileInLibrary) ([]*yamlmeta.Document, error) {
+func (o DataValuesPreProcessing) extractDataValueDocs(fileInLib *FileInLibrary, loader *TemplateLoader,
+	annName template.AnnotationName) ([]*yamlmeta.Document, error) {
+
 	libraryCtx := LibraryExecutionContext{Current: fileInLib.Library, Root: NewRootLibrary(nil)}
 
-	_, resultDocSet, err := o.loader.EvalYAML(libraryCtx, fileInLib.File)
+	_, resultDocSet, err := loader.EvalYAML(libraryCtx, fileInLib.File)
 	if err != nil {
 		return nil, err
 	}
 
 	// Extract _all_ data values docs from the templated result
-	valuesDocs, nonValuesDocs, err := DocExtractor{resultDocSet}.Extract(AnnotationDataValues)
+	valuesDocs, nonValuesDocs, err := DocExtractor{resultDocSet}.Extract(annName)
 	if err != nil {
 		return nil, err
 	}
@@ -145,6 +280,9 @@ func (o DataValuesPreProcessing) extractDataValueDocs(fileInLib *FileInLibrary)
 		for _, doc := range nonValuesDocs {
 			if !doc.IsEmpty() {
 				errStr := "Expected data values file '%s' to only have data values documents"
+				if annName == AnnotationDataValuesComputed {
+					errStr = "Expected computed data values file '%s' to only have computed data values documents"
+				}
 				return nil, fmt.Errorf(errStr, fileInLib.File.RelativePath())
 			}
 		}
@@ -154,6 +292,9 @@ func (o DataValuesPreProcessing) extractDataValueDocs(fileInLib *FileInLibrary)
 }
 
 func (o DataValuesPreProcessing) overlay(dataValues, overlay *yamlmeta.Document) (*yamlmeta.Document, error) {
+	o.allowNewKeysInOpenMaps(dataValues.Value, overlay.Value)
+	o.replaceValuesOfOtherAlternatives(dataValues.Value, overlay.Value)
+
 	op := yttoverlay.Op{
 		Left:   &yamlmeta.DocumentSet{Items: []*yamlmeta.Document{dataValues}},
 		Right:  &yamlmeta.DocumentSet{Items: []*yamlmeta.Document{overlay}},
@@ -169,3 +310,346 @@ func (o DataValuesPreProcessing) overlay(dataValues, overlay *yamlmeta.Document)
 
 	return newLeft.(*yamlmeta.DocumentSet).Items[0], nil
 }
+
+// allowNewKeysInOpenMaps permits `overlay` to add keys to those maps in `dataValues` that have arbitrary keys
+// (i.e. are typed by @schema/type map_of=...). Otherwise, data values may only set keys that already exist.
+func (o DataValuesPreProcessing) allowNewKeysInOpenMaps(dataValues, overlay interface{}) {
+	dvsMap, isMap := dataValues.(*yamlmeta.Map)
+	overlayMap, isOverlayMap := overlay.(*yamlmeta.Map)
+	if !isMap || !isOverlayMap {
+		return
+	}
+	_, isOpenMap := dvsMap.Type.(*schema.MapOfType)
+
+	for _, overlayItem := range overlayMap.Items {
+		var existingItem *yamlmeta.MapItem
+		for _, item := range dvsMap.Items {
+			if item.Key == overlayItem.Key {
+				existingItem = item
+				break
+			}
+		}
+		if existingItem != nil {
+			o.allowNewKeysInOpenMaps(existingItem.Value, overlayItem.Value)
+			continue
+		}
+
+		anns := template.NewAnnotations(overlayItem)
+		if isOpenMap && !anns.Has(yttoverlay.AnnotationMatch) {
+			anns[yttoverlay.AnnotationMatch] = template.NodeAnnotation{
+				Kwargs: []starlark.Tuple{{
+					starlark.String(yttoverlay.MatchAnnotationKwargMissingOK),
+					starlark.Bool(true),
+				}},
+			}
+			overlayItem.SetAnnotations(anns)
+		}
+	}
+}
+
+// replaceValuesOfOtherAlternatives has `overlay` replace (rather than merge into) those values in `dataValues` that
+// are typed by @schema/type one_of=... where the new value is of a different kind (e.g. a map replacing a string).
+func (o DataValuesPreProcessing) replaceValuesOfOtherAlternatives(dataValues, overlay interface{}) {
+	dvsMap, isMap := dataValues.(*yamlmeta.Map)
+	overlayMap, isOverlayMap := overlay.(*yamlmeta.Map)
+	if !isMap || !isOverlayMap {
+		return
+	}
+
+	for _, overlayItem := range overlayMap.Items {
+		for _, item := range dvsMap.Items {
+			if item.Key != overlayItem.Key {
+				continue
+			}
+			anns := template.NewAnnotations(overlayItem)
+			if o.isOneOf(item.Type) && o.kindOf(item.Value) != o.kindOf(overlayItem.Value) &&
+				!anns.Has(yttoverlay.AnnotationMerge) && !anns.Has(yttoverlay.AnnotationRemove) {
+				anns[yttoverlay.AnnotationReplace] = template.NodeAnnotation{}
+				overlayItem.SetAnnotations(anns)
+			} else {
+				o.replaceValuesOfOtherAlternatives(item.Value, overlayItem.Value)
+			}
+			break
+		}
+	}
+}
+
+func (o DataValuesPreProcessing) isOneOf(itemType yamlmeta.Type) bool {
+	if itemType == nil {
+		return false
+	}
+	valueType := itemType.GetValueType()
+	if nullType, ok := valueType.(*schema.NullType); ok {
+		valueType = nullType.GetValueType()
+	}
+	_, isOneOf := valueType.(*schema.OneOfType)
+	return isOneOf
+}
+
+func (o DataValuesPreProcessing) kindOf(val interface{}) string {
+	switch val.(type) {
+	case *yamlmeta.Map:
+		return "map"
+	case *yamlmeta.Array:
+		return "array"
+	default:
+		return "scalar"
+	}
+}
+
+// checkDeprecatedUsage warns of (or, if so configured, fails on) each key in `overlay` that sets a data value
+// marked as deprecated in schema (i.e. whose item type, within `valueType`, carries @schema/deprecated).
+func (o DataValuesPreProcessing) checkDeprecatedUsage(overlay interface{}, valueType yamlmeta.Type, path string) error {
+	if valueType == nil {
+		return nil
+	}
+
+	switch typedOverlay := overlay.(type) {
+	case *yamlmeta.Map:
+		for _, overlayItem := range typedOverlay.Items {
+			itemPath := fmt.Sprintf("%v", overlayItem.Key)
+			if path != "" {
+				itemPath = path + "." + itemPath
+			}
+			if itemType := o.itemTypeOfKey(valueType, overlayItem.Key); itemType != nil && itemType.IsDeprecated() {
+				msg := fmt.Sprintf("Data value '%s' is deprecated (set by %s)", itemPath, overlayItem.Position.AsCompactString())
+				if itemType.GetDeprecationMessage() != "" {
+					msg += ": " + itemType.GetDeprecationMessage()
+				}
+				if o.deprecatedAsErrors {
+					return fmt.Errorf("%s", msg)
+				}
+				o.ui.Warnf("Warning: %s\n", msg)
+			}
+			err := o.checkDeprecatedUsage(overlayItem.Value, o.typeOfKey(valueType, overlayItem.Key), itemPath)
+			if err != nil {
+				return err
+			}
+		}
+	case *yamlmeta.Array:
+		// items are not referred to by index: an item of an overlay may be appended to or merged with any existing item
+		itemType := o.typeOfArrayItems(valueType)
+		for _, overlayItem := range typedOverlay.Items {
+			err := o.checkDeprecatedUsage(overlayItem.Value, itemType, path+"[]")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// markSensitive marks each value declared sensitive in schema (i.e. @schema/sensitive),
+// so that it is redacted wherever it would be displayed.
+func (o DataValuesPreProcessing) markSensitive(dataValues interface{}) {
+	node, isNode := dataValues.(yamlmeta.Node)
+	if !isNode {
+		return
+	}
+	if item, isItem := node.(*yamlmeta.MapItem); isItem {
+		if itemType, ok := item.Type.(*schema.MapItemType); ok && itemType.IsSensitive() {
+			yamlmeta.MarkSensitive(item)
+			return
+		}
+	}
+	for _, val := range node.GetValues() {
+		o.markSensitive(val)
+	}
+}
+
+// coerceToSchemaTypes parses those values in `overlay` given as plain strings (see AnnotationCoerceToSchemaType)
+// into the integer, float or boolean declared for them by `valueType`.
+func (o DataValuesPreProcessing) coerceToSchemaTypes(overlay interface{}, valueType yamlmeta.Type) {
+	if valueType == nil {
+		return
+	}
+
+	switch typedOverlay := overlay.(type) {
+	case *yamlmeta.Map:
+		for _, overlayItem := range typedOverlay.Items {
+			o.coerceItemToSchemaType(overlayItem, o.typeOfKey(valueType, overlayItem.Key))
+		}
+	case *yamlmeta.Array:
+		itemType := o.typeOfArrayItems(valueType)
+		for _, overlayItem := range typedOverlay.Items {
+			o.coerceItemToSchemaType(overlayItem, itemType)
+		}
+	}
+}
+
+func (o DataValuesPreProcessing) coerceItemToSchemaType(item yamlmeta.Node, itemType yamlmeta.Type) {
+	if itemType == nil {
+		return
+	}
+	val := item.GetValues()[0]
+	strVal, isString := val.(string)
+	if !isString || !template.NewAnnotations(item).Has(AnnotationCoerceToSchemaType) {
+		o.coerceToSchemaTypes(val, itemType)
+		return
+	}
+	item.SetValue(o.coerceToScalarType(strVal, itemType))
+}
+
+// coerceToScalarType parses `strVal` into the integer, float or boolean declared by `valueType` (if it is one of those).
+// Values that do not parse are left as strings: the type check that follows reports them (and their source).
+func (o DataValuesPreProcessing) coerceToScalarType(strVal string, valueType yamlmeta.Type) interface{} {
+	if nullType, ok := valueType.(*schema.NullType); ok {
+		valueType = nullType.GetValueType()
+	}
+	scalarType, isScalar := valueType.(*schema.ScalarType)
+	if !isScalar {
+		return strVal
+	}
+
+	switch scalarType.ValueType.(type) {
+	case int:
+		if intVal, err := strconv.Atoi(strVal); err == nil {
+			return intVal
+		}
+	case float64:
+		if floatVal, err := strconv.ParseFloat(strVal, 64); err == nil {
+			return floatVal
+		}
+	case bool:
+		// only the spellings YAML uses (unlike strconv.ParseBool(), which also accepts "1", "t", "TRUE", etc.)
+		switch strVal {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	}
+	return strVal
+}
+
+// resolveRefs replaces each data value that refers to a value held elsewhere (e.g. "ref+env://DB_PASSWORD") with
+// that value, parsed into the scalar type (if any) declared for it in schema. Resolved values are secrets by nature,
+// and so are marked sensitive.
+func (o DataValuesPreProcessing) resolveRefs(dataValues interface{}, path string) error {
+	node, isNode := dataValues.(yamlmeta.Node)
+	if !isNode {
+		return nil
+	}
+
+	for i, val := range node.GetValues() {
+		childPath := path
+		switch typedNode := node.(type) {
+		case *yamlmeta.MapItem:
+			childPath = fmt.Sprintf("%v", typedNode.Key)
+			if path != "" {
+				childPath = path + "." + childPath
+			}
+		case *yamlmeta.Array:
+			childPath = fmt.Sprintf("%s[%d]", path, i)
+		}
+
+		if !o.valueResolvers.IsRef(val) {
+			err := o.resolveRefs(val, childPath)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		resolved, err := o.valueResolvers.Resolve(val.(string))
+		if err != nil {
+			return fmt.Errorf("Resolving data value '%s' (set by %s): %s", childPath, node.GetPosition().AsCompactString(), err)
+		}
+		var valueType yamlmeta.Type
+		switch typedNode := node.(type) {
+		case *yamlmeta.MapItem:
+			valueType = typedNode.Type
+		case *yamlmeta.ArrayItem:
+			valueType = typedNode.Type
+		}
+		if valueType != nil {
+			valueType = valueType.GetValueType()
+		}
+		err = node.SetValue(o.coerceToScalarType(resolved, valueType))
+		if err != nil {
+			return err
+		}
+		yamlmeta.MarkSensitive(node)
+	}
+	return nil
+}
+
+// withoutRefs copies `doc`, dropping each item whose value refers to a value held elsewhere (i.e. yet to be resolved).
+func (o DataValuesPreProcessing) withoutRefs(doc *yamlmeta.Document) *yamlmeta.Document {
+	result := doc.DeepCopy()
+	o.dropRefs(result)
+	return result
+}
+
+func (o DataValuesPreProcessing) dropRefs(node yamlmeta.Node) {
+	switch typedNode := node.(type) {
+	case *yamlmeta.Map:
+		var items []*yamlmeta.MapItem
+		for _, item := range typedNode.Items {
+			if !o.valueResolvers.IsRef(item.Value) {
+				items = append(items, item)
+			}
+		}
+		typedNode.Items = items
+	case *yamlmeta.Array:
+		var items []*yamlmeta.ArrayItem
+		for _, item := range typedNode.Items {
+			if !o.valueResolvers.IsRef(item.Value) {
+				items = append(items, item)
+			}
+		}
+		typedNode.Items = items
+	}
+	for _, val := range node.GetValues() {
+		if childNode, ok := val.(yamlmeta.Node); ok {
+			o.dropRefs(childNode)
+		}
+	}
+}
+
+// typeOfArrayItems provides the type of the items in an array of type `arrayType` (nil, if not known).
+func (o DataValuesPreProcessing) typeOfArrayItems(arrayType yamlmeta.Type) yamlmeta.Type {
+	switch typedType := arrayType.(type) {
+	case *schema.NullType:
+		return o.typeOfArrayItems(typedType.GetValueType())
+	case *schema.ArrayType:
+		return typedType.ItemsType.GetValueType()
+	}
+	return nil
+}
+
+// itemTypeOfKey provides the type of the item at `key` in a map of type `mapType` (nil, if not declared in schema).
+func (o DataValuesPreProcessing) itemTypeOfKey(mapType yamlmeta.Type, key interface{}) *schema.MapItemType {
+	switch typedType := mapType.(type) {
+	case *schema.DocumentType:
+		return o.itemTypeOfKey(typedType.GetValueType(), key)
+	case *schema.NullType:
+		return o.itemTypeOfKey(typedType.GetValueType(), key)
+	case *schema.MapType:
+		for _, item := range typedType.Items {
+			if item.Key == key {
+				return item
+			}
+		}
+	}
+	return nil
+}
+
+// typeOfKey provides the type of the value at `key` in a map of type `mapType` (nil, if not known).
+func (o DataValuesPreProcessing) typeOfKey(mapType yamlmeta.Type, key interface{}) yamlmeta.Type {
+	switch typedType := mapType.(type) {
+	case *schema.DocumentType:
+		return o.typeOfKey(typedType.GetValueType(), key)
+	case *schema.NullType:
+		return o.typeOfKey(typedType.GetValueType(), key)
+	case *schema.MapType:
+		for _, item := range typedType.Items {
+			if item.Key == key {
+				return item.GetValueType()
+			}
+		}
+	case *schema.MapOfType:
+		return typedType.GetValueType()
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
+)
+
+// DataValueSource is a place that set a data value (e.g. a schema default, a data values file, a command line flag)
+type DataValueSource struct {
+	Position  *filepos.Position
+	Value     interface{}
+	IsDefault bool
+	Sensitive bool
+
+	seq int
+}
+
+// DataValuesProvenance records which sources set each data value, in the order in which they were applied.
+type DataValuesProvenance struct {
+	sources map[string][]DataValueSource
+	seq     int
+}
+
+// NewDataValuesProvenance creates an empty record of sources
+func NewDataValuesProvenance() *DataValuesProvenance {
+	return &DataValuesProvenance{sources: map[string][]DataValueSource{}}
+}
+
+// Record notes each value given in `doc` (a data values document about to be applied).
+// Maps are merged (rather than set), and so only their contents are recorded; unless they replace what was there.
+// Values without a position of their own (e.g. given via library.get(...).with_data_values()) are noted at the
+// position of `doc`.
+func (p *DataValuesProvenance) Record(doc *yamlmeta.Document, isDefault bool) {
+	p.record(doc.Value, "", doc.Position, isDefault)
+}
+
+func (p *DataValuesProvenance) record(val interface{}, path string, docPos *filepos.Position, isDefault bool) {
+	dvsMap, isMap := val.(*yamlmeta.Map)
+	if !isMap {
+		return
+	}
+	for _, item := range dvsMap.Items {
+		itemPath := p.itemPath(path, item.Key)
+		anns := template.NewAnnotations(item)
+		if anns.Has(yttoverlay.AnnotationRemove) {
+			continue
+		}
+		_, valueIsMap := item.Value.(*yamlmeta.Map)
+		if !valueIsMap || anns.Has(yttoverlay.AnnotationReplace) {
+			pos := item.Position
+			if !pos.IsKnown() && docPos != nil {
+				pos = docPos
+			}
+			p.seq++
+			p.sources[itemPath] = append(p.sources[itemPath], DataValueSource{
+				Position:  pos,
+				Value:     yamlmeta.NewGoFromAST(item.Value),
+				IsDefault: isDefault,
+				Sensitive: item.Sensitive,
+				seq:       p.seq,
+			})
+		}
+		p.record(item.Value, itemPath, docPos, isDefault)
+	}
+}
+
+// SourcesOf lists the sources that set the data value at `path` (including those that set any of its parents)
+func (p *DataValuesProvenance) SourcesOf(path []interface{}) []DataValueSource {
+	var result []DataValueSource
+	currPath := ""
+	for _, key := range path {
+		currPath = p.itemPath(currPath, key)
+		for _, src := range p.sources[currPath] {
+			// sources of parents are merged in the order they were applied
+			idx := len(result)
+			for idx > 0 && result[idx-1].seq > src.seq {
+				idx--
+			}
+			result = append(result[:idx], append([]DataValueSource{src}, result[idx:]...)...)
+		}
+	}
+	return result
+}
+
+// AsDocument reports, for every leaf (i.e. non-map value) in `dataValues`, its final value and the sources that set it.
+// Values of sensitive data values are redacted.
+func (p *DataValuesProvenance) AsDocument(dataValues *yamlmeta.Document) *yamlmeta.Document {
+	report := &yamlmeta.Map{}
+	p.reportLeaves(dataValues.Value, nil, false, report)
+	return &yamlmeta.Document{Value: report}
+}
+
+func (p *DataValuesProvenance) reportLeaves(val interface{}, path []interface{}, sensitive bool, report *yamlmeta.Map) {
+	dvsMap, isMap := val.(*yamlmeta.Map)
+	if isMap && (len(dvsMap.Items) > 0 || len(path) == 0) {
+		for _, item := range dvsMap.Items {
+			itemSensitive := sensitive || item.Sensitive
+			p.reportLeaves(item.Value, append(append([]interface{}{}, path...), item.Key), itemSensitive, report)
+		}
+		return
+	}
+
+	sources := &yamlmeta.Array{}
+	for _, src := range p.SourcesOf(path) {
+		from := src.Position.AsCompactString()
+		if src.IsDefault {
+			from += " (schema default)"
+		}
+		var srcVal interface{} = yamlmeta.NewASTFromInterface(src.Value)
+		if sensitive || src.Sensitive {
+			srcVal = filepos.RedactedValue
+		}
+		sources.Items = append(sources.Items, &yamlmeta.ArrayItem{Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: "from", Value: from},
+			{Key: "value", Value: srcVal},
+		}}})
+	}
+
+	pathStr := ""
+	for _, key := range path {
+		pathStr = p.itemPath(pathStr, key)
+	}
+	var finalVal interface{} = yamlmeta.NewASTFromInterface(yamlmeta.NewGoFromAST(val))
+	if sensitive {
+		finalVal = filepos.RedactedValue
+	}
+	report.Items = append(report.Items, &yamlmeta.MapItem{Key: pathStr, Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+		{Key: "value", Value: finalVal},
+		{Key: "sources", Value: sources},
+	}}})
+}
+
+func (p *DataValuesProvenance) itemPath(path string, key interface{}) string {
+	if path == "" {
+		return fmt.Sprintf("%v", key)
+	}
+	return fmt.Sprintf("%s.%v", path, key)
+}
@@ -12,8 +12,9 @@ import (
 )
 
 const (
-	AnnotationDataValues       template.AnnotationName = "data/values"
-	AnnotationDataValuesSchema template.AnnotationName = "data/values-schema"
+	AnnotationDataValues         template.AnnotationName = "data/values"
+	AnnotationDataValuesSchema   template.AnnotationName = "data/values-schema"
+	AnnotationDataValuesComputed template.AnnotationName = "data/values-computed"
 )
 
 type DocExtractor struct {
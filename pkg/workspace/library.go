@@ -96,6 +96,21 @@ func (l *Library) findPrivateLibrary() (*Library, bool) {
 	return nil, false
 }
 
+// ListPrivateLibraries lists the names of the libraries directly within this library's private library directory (i.e. _ytt_lib)
+func (l *Library) ListPrivateLibraries() []string {
+	privateLib, found := l.findPrivateLibrary()
+	if !found {
+		return nil
+	}
+	var names []string
+	for _, lib := range privateLib.children {
+		if !lib.private {
+			names = append(names, lib.name)
+		}
+	}
+	return names
+}
+
 func (l *Library) FindLibrary(path string) (*Library, error) {
 	dirPieces, namePiece := files.SplitPath(path)
 
@@ -12,6 +12,7 @@ import (
 	"github.com/k14s/ytt/pkg/files"
 	"github.com/k14s/ytt/pkg/schema"
 	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/workspace/ref"
 	"github.com/k14s/ytt/pkg/yamlmeta"
 	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
 )
@@ -27,6 +28,8 @@ type EvalResult struct {
 	Files   []files.OutputFile
 	DocSet  *yamlmeta.DocumentSet
 	Exports []EvalExport
+	// OverlayTrace is only recorded when enabled (see TemplateLoaderOpts.OverlayTrace)
+	OverlayTrace *OverlayTrace
 }
 
 type EvalExport struct {
@@ -87,6 +90,143 @@ func (ll *LibraryExecution) Schemas(schemaOverlays []*schema.DocumentSchemaEnvel
 	return schema.NewNullSchema(), childLibrarySchemas, nil
 }
 
+// LibrarySchema is the schema of a library within the private library directory (i.e. _ytt_lib) of another.
+type LibrarySchema struct {
+	Ref    string
+	Schema Schema
+}
+
+// PrivateLibrarySchemas determines the schema of each library in the current library's private library directory,
+// including the schemas given by the current library (i.e. annotated with @library/ref).
+// Libraries without any schema are omitted.
+func (ll *LibraryExecution) PrivateLibrarySchemas(librarySchemas []*schema.DocumentSchemaEnvelope) ([]LibrarySchema, error) {
+	var result []LibrarySchema
+	for _, libPath := range ll.libraryCtx.Current.ListPrivateLibraries() {
+		foundLib, err := ll.libraryCtx.Current.FindAccessibleLibrary(libPath)
+		if err != nil {
+			return nil, err
+		}
+
+		var schemasForLib []*schema.DocumentSchemaEnvelope
+		for _, docSchema := range librarySchemas {
+			matchingSchema, usedInLib := docSchema.UsedInLibrary(ref.LibraryRef{Path: libPath})
+			if usedInLib {
+				schemasForLib = append(schemasForLib, matchingSchema)
+			}
+		}
+
+		libExecution := ll.libraryExecFactory.New(LibraryExecutionContext{Current: foundLib, Root: foundLib})
+		libSchema, _, err := libExecution.Schemas(schemasForLib)
+		if err != nil {
+			return nil, fmt.Errorf("Determining schema of library '@%s': %s", libPath, err)
+		}
+		if libSchema.DefaultDataValues() == nil {
+			continue
+		}
+		result = append(result, LibrarySchema{Ref: "@" + libPath, Schema: libSchema})
+	}
+	return result, nil
+}
+
+// PrivateLibrary is a library within the private library directory of another (possibly nested, e.g. "@lib@nested"),
+// along with the schema (and data values) that its parent library would give it via library.get().
+type PrivateLibrary struct {
+	Execution *LibraryExecution
+	Schema    Schema
+	// LibrarySchemas are the schemas given by this library to its own private libraries
+	LibrarySchemas []*schema.DocumentSchemaEnvelope
+	// Values are only determined by PrivateLibraryValues()
+	Values *DataValues
+}
+
+// PrivateLibrarySchema determines the schema of the private library referred to by `libRefStr` (e.g. "@lib",
+// "@lib~alias", "@lib@nested"), given the schemas that the current library gives its private libraries.
+func (ll *LibraryExecution) PrivateLibrarySchema(libRefStr string, librarySchemas []*schema.DocumentSchemaEnvelope) (*PrivateLibrary, error) {
+	return ll.privateLibrary(libRefStr, librarySchemas, nil, false)
+}
+
+// PrivateLibraryValues determines the schema and data values of the private library referred to by `libRefStr`,
+// given the schemas and data values that the current library gives its private libraries.
+// (Data values given via library.get(...).with_data_values() are only known once templates are evaluated:
+// see PrivateLibraryEvaluations.)
+func (ll *LibraryExecution) PrivateLibraryValues(libRefStr string, librarySchemas []*schema.DocumentSchemaEnvelope, libraryValues []*DataValues) (*PrivateLibrary, error) {
+	return ll.privateLibrary(libRefStr, librarySchemas, libraryValues, true)
+}
+
+// PrivateLibraryEvaluations evaluates the templates of the current library (given `values` and what it gives its
+// private libraries) to determine the data values given to the private library referred to by `libRefStr` each time
+// it is evaluated, including those given via library.get(...).with_data_values().
+func (ll *LibraryExecution) PrivateLibraryEvaluations(libRefStr string, values *DataValues, libraryValues []*DataValues,
+	librarySchemas []*schema.DocumentSchemaEnvelope) ([]LibraryEvaluation, error) {
+
+	libRefs, err := ref.LibraryRefExtractor{}.FromStr(libRefStr)
+	if err != nil {
+		return nil, err
+	}
+
+	lib := ll.libraryCtx.Current
+	for _, libRef := range libRefs {
+		if len(libRef.Path) == 0 {
+			return nil, fmt.Errorf("Expected library ref '%s' to include path of each library", libRefStr)
+		}
+		lib, err = lib.FindAccessibleLibrary(libRef.Path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// only templates are evaluated: their results are of no interest (nor is whether all library data values are used)
+	factory, evaluations := ll.libraryExecFactory.withEvaluationsRecordedFor(lib, libRefs[len(libRefs)-1].Alias)
+	_, _, _, err = factory.New(ll.libraryCtx).eval(values, libraryValues, librarySchemas)
+	if err != nil {
+		return nil, fmt.Errorf("Evaluating templates to determine data values given to library '%s': %s", libRefStr, err)
+	}
+	return evaluations.result, nil
+}
+
+func (ll *LibraryExecution) privateLibrary(libRefStr string, librarySchemas []*schema.DocumentSchemaEnvelope,
+	libraryValues []*DataValues, withValues bool) (*PrivateLibrary, error) {
+
+	libRefs, err := ref.LibraryRefExtractor{}.FromStr(libRefStr)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PrivateLibrary{}
+	currLib := ll.libraryCtx.Current
+
+	for _, libRef := range libRefs {
+		if len(libRef.Path) == 0 {
+			return nil, fmt.Errorf("Expected library ref '%s' to include path of each library", libRefStr)
+		}
+		foundLib, err := currLib.FindAccessibleLibrary(libRef.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		// as if retrieved via library.get() by the templates of the parent library
+		libVal := &libraryValue{libRef.Path, libRef.Alias, libraryValues, librarySchemas,
+			LibraryExecutionContext{Current: foundLib, Root: foundLib}, ll.libraryExecFactory}
+		result.Execution = ll.libraryExecFactory.New(libVal.libraryCtx)
+
+		result.Schema, librarySchemas, err = libVal.librarySchemas(result.Execution)
+		if err != nil {
+			return nil, fmt.Errorf("Determining schema of library '@%s': %s", libRef.AsString(), err)
+		}
+		result.LibrarySchemas = librarySchemas
+
+		if withValues {
+			result.Values, libraryValues, err = libVal.libraryValues(result.Execution, result.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("Determining data values of library '@%s': %s", libRef.AsString(), err)
+			}
+		}
+		currLib = foundLib
+	}
+
+	return result, nil
+}
+
 func collectSchemaDocs(schemaFiles []*FileInLibrary, loader *TemplateLoader) ([]*schema.DocumentSchemaEnvelope, error) {
 	var documentSchemas []*schema.DocumentSchemaEnvelope
 	for _, file := range schemaFiles {
@@ -137,24 +277,88 @@ func (ll *LibraryExecution) Values(valuesOverlays []*DataValues, schema Schema)
 		return nil, nil, err
 	}
 
+	computedValuesFiles, err := ll.computedValuesFiles(loader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, computedFile := range computedValuesFiles {
+		for _, valuesFile := range valuesFiles {
+			if computedFile.File == valuesFile.File {
+				return nil, nil, fmt.Errorf("Expected file '%s' to have either data values or computed data values documents, but had both",
+					computedFile.File.RelativePath())
+			}
+		}
+	}
+
 	dvpp := DataValuesPreProcessing{
 		valuesFiles:           valuesFiles,
+		computedValuesFiles:   computedValuesFiles,
 		valuesOverlays:        valuesOverlays,
 		schema:                schema,
 		loader:                loader,
+		ui:                    ll.ui,
 		IgnoreUnknownComments: ll.templateLoaderOpts.IgnoreUnknownComments,
+
+		deprecatedAsErrors: ll.templateLoaderOpts.DeprecatedDataValuesAsErrors,
+		valueResolvers:     ll.templateLoaderOpts.DataValueResolvers,
+		profiles:           ll.templateLoaderOpts.DataValuesProfiles,
 	}
 
 	return dvpp.Apply()
 }
 
+// DeclaredProfiles lists the data values profiles declared by this library and its private libraries
+// (including nested ones), whether or not those libraries are used.
+func (ll *LibraryExecution) DeclaredProfiles() ([]string, error) {
+	loader := NewTemplateLoader(NewEmptyDataValues(), nil, nil, ll.templateLoaderOpts, ll.libraryExecFactory, ll.ui)
+
+	valuesFiles, err := ll.valuesFiles(loader)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []string
+	for _, fileInLib := range valuesFiles {
+		docs, err := DataValuesPreProcessing{loader: loader}.extractDataValueDocs(fileInLib, loader, AnnotationDataValues)
+		if err != nil {
+			return nil, fmt.Errorf("Templating file '%s': %s", fileInLib.File.RelativePath(), err)
+		}
+		for _, doc := range docs {
+			dv, err := NewDataValues(doc)
+			if err != nil {
+				return nil, err
+			}
+			if len(dv.Profile) > 0 {
+				profiles = append(profiles, dv.Profile)
+			}
+		}
+	}
+
+	for _, libPath := range ll.libraryCtx.Current.ListPrivateLibraries() {
+		foundLib, err := ll.libraryCtx.Current.FindAccessibleLibrary(libPath)
+		if err != nil {
+			return nil, err
+		}
+		libProfiles, err := ll.libraryExecFactory.New(LibraryExecutionContext{Current: foundLib, Root: foundLib}).DeclaredProfiles()
+		if err != nil {
+			return nil, fmt.Errorf("Determining data values profiles of library '@%s': %s", libPath, err)
+		}
+		profiles = append(profiles, libProfiles...)
+	}
+	return profiles, nil
+}
+
 func (ll *LibraryExecution) schemaFiles(loader *TemplateLoader) ([]*FileInLibrary, error) {
 	return ll.filesByAnnotation(AnnotationDataValuesSchema, loader)
 }
 
 func (ll *LibraryExecution) valuesFiles(loader *TemplateLoader) ([]*FileInLibrary, error) {
 	return ll.filesByAnnotation(AnnotationDataValues, loader)
+}
 
+func (ll *LibraryExecution) computedValuesFiles(loader *TemplateLoader) ([]*FileInLibrary, error) {
+	return ll.filesByAnnotation(AnnotationDataValuesComputed, loader)
 }
 
 func (ll *LibraryExecution) filesByAnnotation(annName template.AnnotationName, loader *TemplateLoader) ([]*FileInLibrary, error) {
@@ -187,16 +391,26 @@ func (ll *LibraryExecution) Eval(values *DataValues, libraryValues []*DataValues
 	if err != nil {
 		return nil, err
 	}
+	err = ll.checkUnusedDVsOrSchemas(libraryValues, librarySchemas)
+	if err != nil {
+		return nil, err
+	}
 
-	docSets, err = (&OverlayPostProcessing{docSets: docSets}).Apply()
+	postProcessing := &OverlayPostProcessing{docSets: docSets}
+	if ll.templateLoaderOpts.OverlayTrace {
+		postProcessing.trace = &OverlayTrace{}
+	}
+
+	docSets, err = postProcessing.Apply()
 	if err != nil {
 		return nil, err
 	}
 
 	result := &EvalResult{
-		Files:   outputFiles,
-		DocSet:  &yamlmeta.DocumentSet{},
-		Exports: exports,
+		Files:        outputFiles,
+		DocSet:       &yamlmeta.DocumentSet{},
+		Exports:      exports,
+		OverlayTrace: postProcessing.trace,
 	}
 
 	for _, fileInLib := range ll.sortedOutputDocSets(docSets) {
@@ -208,7 +422,7 @@ func (ll *LibraryExecution) Eval(values *DataValues, libraryValues []*DataValues
 			return nil, fmt.Errorf("Marshaling template result: %s", err)
 		}
 
-		ll.ui.Debugf("### %s result\n%s", fileInLib.RelativePath(), resultDocBytes)
+		ll.ui.Debugf("### %s result\n%s", fileInLib.RelativePath(), values.Redactor().Redact(string(resultDocBytes)))
 		result.Files = append(result.Files, files.NewOutputFile(fileInLib.RelativePath(), resultDocBytes, fileInLib.File.Type()))
 	}
 
@@ -227,6 +441,20 @@ func (ll *LibraryExecution) eval(values *DataValues, libraryValues []*DataValues
 		libraryCtx := LibraryExecutionContext{Current: fileInLib.Library, Root: ll.libraryCtx.Root}
 
 		switch {
+		case fileInLib.File.PatchType() != files.PatchTypeNone:
+			docSet, err := loader.EvalPlainYAML(fileInLib.File)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			overlays, err := Patch{fileInLib.File, docSet}.AsOverlays()
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("Converting patch file '%s' to overlays: %s", fileInLib.File.RelativePath(), err)
+			}
+
+			// applied (in file order) along with other overlays during post processing
+			docSets[fileInLib] = &yamlmeta.DocumentSet{Items: overlays, Position: docSet.Position}
+
 		case fileInLib.File.IsForOutput():
 			// Do not collect globals produced by templates
 			switch fileInLib.File.Type() {
@@ -246,7 +474,7 @@ func (ll *LibraryExecution) eval(values *DataValues, libraryValues []*DataValues
 
 				resultStr := resultVal.AsString()
 
-				ll.ui.Debugf("### %s result\n%s", fileInLib.RelativePath(), resultStr)
+				ll.ui.Debugf("### %s result\n%s", fileInLib.RelativePath(), values.Redactor().Redact(resultStr))
 				outputFiles = append(outputFiles, files.NewOutputFile(fileInLib.RelativePath(), []byte(resultStr), fileInLib.File.Type()))
 
 			default:
@@ -292,7 +520,7 @@ func (ll *LibraryExecution) eval(values *DataValues, libraryValues []*DataValues
 		}
 	}
 
-	return exports, docSets, outputFiles, ll.checkUnusedDVsOrSchemas(libraryValues, librarySchemas)
+	return exports, docSets, outputFiles, nil
 }
 
 func (*LibraryExecution) sortedOutputDocSets(outputDocSets map[*FileInLibrary]*yamlmeta.DocumentSet) []*FileInLibrary {
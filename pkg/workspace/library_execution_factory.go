@@ -5,6 +5,7 @@ package workspace
 
 import (
 	"github.com/k14s/ytt/pkg/cmd/ui"
+	"github.com/k14s/ytt/pkg/filepos"
 )
 
 type LibraryExecutionContext struct {
@@ -15,14 +16,40 @@ type LibraryExecutionContext struct {
 type LibraryExecutionFactory struct {
 	ui                 ui.UI
 	templateLoaderOpts TemplateLoaderOpts
+	evaluations        *libraryEvaluations
+}
+
+// LibraryEvaluation is an evaluation of a private library by templates (i.e. via library.get()),
+// along with the data values it was given.
+type LibraryEvaluation struct {
+	Position *filepos.Position // where templates evaluated the library
+	Values   *DataValues
+}
+
+// libraryEvaluations collects the evaluations of one library (as referred to by alias, if any)
+type libraryEvaluations struct {
+	library *Library
+	alias   string
+	result  []LibraryEvaluation
 }
 
 func NewLibraryExecutionFactory(ui ui.UI, templateLoaderOpts TemplateLoaderOpts) *LibraryExecutionFactory {
-	return &LibraryExecutionFactory{ui, templateLoaderOpts}
+	return &LibraryExecutionFactory{ui, templateLoaderOpts, nil}
 }
 
 func (f *LibraryExecutionFactory) WithTemplateLoaderOptsOverrides(overrides TemplateLoaderOptsOverrides) *LibraryExecutionFactory {
-	return NewLibraryExecutionFactory(f.ui, f.templateLoaderOpts.Merge(overrides))
+	return &LibraryExecutionFactory{f.ui, f.templateLoaderOpts.Merge(overrides), f.evaluations}
+}
+
+func (f *LibraryExecutionFactory) withEvaluationsRecordedFor(library *Library, alias string) (*LibraryExecutionFactory, *libraryEvaluations) {
+	evaluations := &libraryEvaluations{library: library, alias: alias}
+	return &LibraryExecutionFactory{f.ui, f.templateLoaderOpts, evaluations}, evaluations
+}
+
+func (f *LibraryExecutionFactory) recordEvaluation(library *Library, alias string, eval LibraryEvaluation) {
+	if f.evaluations != nil && f.evaluations.library == library && f.evaluations.alias == alias {
+		f.evaluations.result = append(f.evaluations.result, eval)
+	}
 }
 
 func (f *LibraryExecutionFactory) New(ctx LibraryExecutionContext) *LibraryExecution {
@@ -166,8 +166,9 @@ func (l *libraryValue) WithDataValues(thread *starlark.Thread, f *starlark.Built
 	}
 
 	valsYAML, err := NewDataValues(&yamlmeta.Document{
-		Value:    yamlmeta.NewASTFromInterfaceWithNoPosition(dataValues),
-		Position: filepos.NewUnknownPosition(),
+		Value: yamlmeta.NewASTFromInterfaceWithNoPosition(dataValues),
+		// so that these data values can be told apart from those given elsewhere (e.g. in provenance)
+		Position: callerPosition(thread),
 	})
 	if err != nil {
 		return starlark.None, err
@@ -227,6 +228,7 @@ func (l *libraryValue) Eval(thread *starlark.Thread, f *starlark.Builtin,
 	if err != nil {
 		return starlark.None, err
 	}
+	l.recordEvaluation(thread, astValues)
 
 	result, err := libraryExecution.Eval(astValues, libValues, librarySchemas)
 	if err != nil {
@@ -253,6 +255,7 @@ func (l *libraryValue) DataValues(thread *starlark.Thread, f *starlark.Builtin,
 	if err != nil {
 		return starlark.None, err
 	}
+	l.recordEvaluation(thread, astValues)
 
 	val := core.NewGoValueWithOpts(astValues.Doc.AsInterface(), core.GoValueOpts{MapIsStruct: true})
 	return val.AsStarlarkValue(), nil
@@ -281,6 +284,7 @@ func (l *libraryValue) Export(thread *starlark.Thread, f *starlark.Builtin,
 	if err != nil {
 		return starlark.None, err
 	}
+	l.recordEvaluation(thread, astValues)
 
 	result, err := libraryExecution.Eval(astValues, libValues, librarySchemas)
 	if err != nil {
@@ -369,6 +373,10 @@ func (l *libraryValue) librarySchemas(ll *LibraryExecution) (Schema, []*schema.D
 	return schema, foundChildSchemas, nil
 }
 
+func (l *libraryValue) recordEvaluation(thread *starlark.Thread, values *DataValues) {
+	l.libraryExecutionFactory.recordEvaluation(l.libraryCtx.Current, l.alias, LibraryEvaluation{callerPosition(thread), values})
+}
+
 func (l *libraryValue) libraryValues(ll *LibraryExecution, schema Schema) (*DataValues, []*DataValues, error) {
 	var dvss, afterLibModDVss, childDVss []*DataValues
 	for _, dv := range l.dataValuess {
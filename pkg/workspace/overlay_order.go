@@ -0,0 +1,117 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package workspace
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	"github.com/k14s/ytt/pkg/yamltemplate"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
+)
+
+// OrderedOverlay is an overlay document along with the file it is from and its priority (see @overlay/priority)
+type OrderedOverlay struct {
+	File     *FileInLibrary
+	Doc      *yamlmeta.Document
+	Priority int64
+	// PriorityExpr is the (unevaluated) priority when it is not a literal, as is only found when inspecting;
+	// such overlays are ordered as if their priority was 0
+	PriorityExpr string
+}
+
+// SortOverlays orders overlays by priority (lowest first). Overlays with the same priority retain their order
+// (i.e. file order, then order within a file).
+func SortOverlays(overlays []OrderedOverlay) {
+	sort.SliceStable(overlays, func(i, j int) bool {
+		return overlays[i].Priority < overlays[j].Priority
+	})
+}
+
+// InspectOverlayOrder finds overlay documents in `filesInLib` without evaluating templates (so only literal
+// priorities can be determined), in the order they would be applied. Also indicates whether any set a priority.
+// Files that cannot be parsed are skipped: they are only reported once templates are evaluated.
+func InspectOverlayOrder(filesInLib []*FileInLibrary) ([]OrderedOverlay, bool, error) {
+	sortedFiles := append([]*FileInLibrary{}, filesInLib...)
+	SortFilesInLibrary(sortedFiles)
+
+	var overlays []OrderedOverlay
+	var hasPriorities bool
+
+	for _, fileInLib := range sortedFiles {
+		file := fileInLib.File
+		isTemplate := file.Type() == files.TypeYAML && file.IsTemplate() && file.IsForOutput()
+		isPatch := file.PatchType() != files.PatchTypeNone
+		if !isTemplate && !isPatch {
+			continue
+		}
+
+		fileBs, err := file.Bytes()
+		if err != nil {
+			return nil, false, err
+		}
+		docSet, err := yamlmeta.NewDocumentSetFromBytes(fileBs, yamlmeta.DocSetOpts{AssociatedName: file.RelativePath()})
+		if err != nil {
+			continue
+		}
+
+		for _, doc := range docSet.Items {
+			if isPatch {
+				if !doc.IsEmpty() {
+					overlays = append(overlays, OrderedOverlay{File: fileInLib, Doc: doc})
+				}
+				continue
+			}
+
+			anns, err := inspectDocAnnotations(doc)
+			if err != nil {
+				continue
+			}
+			if _, isOverlay := anns[yttoverlay.AnnotationMatch]; !isOverlay || isDataValuesDoc(anns) {
+				continue
+			}
+
+			overlay := OrderedOverlay{File: fileInLib, Doc: doc}
+			if priorityArg, found := anns[yttoverlay.AnnotationPriority]; found {
+				overlay.Priority, err = strconv.ParseInt(strings.TrimSpace(priorityArg), 10, 64)
+				if err != nil {
+					overlay.PriorityExpr = strings.TrimSpace(priorityArg)
+				}
+				hasPriorities = true
+			}
+			overlays = append(overlays, overlay)
+		}
+	}
+
+	SortOverlays(overlays)
+	return overlays, hasPriorities, nil
+}
+
+// inspectDocAnnotations provides (unevaluated) contents of annotations on `doc` by their name
+func inspectDocAnnotations(doc *yamlmeta.Document) (map[template.AnnotationName]string, error) {
+	result := map[template.AnnotationName]string{}
+	for _, comment := range doc.GetComments() {
+		meta, err := yamltemplate.NewTemplateMetaFromYAMLComment(comment, yamltemplate.MetasOpts{IgnoreUnknown: true})
+		if err != nil {
+			return nil, err
+		}
+		for _, ann := range meta.Annotations {
+			result[ann.Name] = ann.Content
+		}
+	}
+	return result, nil
+}
+
+func isDataValuesDoc(anns map[template.AnnotationName]string) bool {
+	for _, annName := range []template.AnnotationName{AnnotationDataValues, AnnotationDataValuesSchema, AnnotationDataValuesComputed} {
+		if _, found := anns[annName]; found {
+			return true
+		}
+	}
+	return false
+}
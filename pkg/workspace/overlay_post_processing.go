@@ -15,6 +15,8 @@ import (
 
 type OverlayPostProcessing struct {
 	docSets map[*FileInLibrary]*yamlmeta.DocumentSet
+	// trace (if any) records how each overlay was applied
+	trace *OverlayTrace
 }
 
 func (o OverlayPostProcessing) Apply() (map[*FileInLibrary]*yamlmeta.DocumentSet, error) {
@@ -50,24 +52,43 @@ func (o OverlayPostProcessing) Apply() (map[*FileInLibrary]*yamlmeta.DocumentSet
 	}
 	SortFilesInLibrary(sortedOverlayFiles)
 
+	// ...unless overlays explicitly ask to be applied earlier or later
+	var overlays []OrderedOverlay
 	for _, file := range sortedOverlayFiles {
 		for _, overlay := range overlayDocSets[file] {
-			op := yttoverlay.Op{
-				// special case: array of docsets so that file association can be preserved
-				Left: docSetsWithoutOverlays,
-				Right: &yamlmeta.DocumentSet{
-					Items: []*yamlmeta.Document{overlay},
-				},
-				Thread: &starlark.Thread{Name: "overlay-post-processing"},
-			}
-			newLeft, err := op.Apply()
+			priorityAnn, err := yttoverlay.NewPriorityAnnotation(overlay)
 			if err != nil {
-				return nil, fmt.Errorf("Overlaying (in following order: %s): %s",
-					o.allFileDescs(sortedOverlayFiles), err)
+				return nil, fmt.Errorf("Document on %s: %s", overlay.Position.AsCompactString(), err)
 			}
-			docSetsWithoutOverlays = newLeft.([]*yamlmeta.DocumentSet)
+			overlays = append(overlays, OrderedOverlay{File: file, Doc: overlay, Priority: priorityAnn.Priority()})
 		}
 	}
+	SortOverlays(overlays)
+
+	for _, overlay := range overlays {
+		var opTrace *yttoverlay.Trace
+		if o.trace != nil {
+			opTrace = &yttoverlay.Trace{}
+		}
+		op := yttoverlay.Op{
+			// special case: array of docsets so that file association can be preserved
+			Left: docSetsWithoutOverlays,
+			Right: &yamlmeta.DocumentSet{
+				Items: []*yamlmeta.Document{overlay.Doc},
+			},
+			Thread: &starlark.Thread{Name: "overlay-post-processing"},
+			Trace:  opTrace,
+		}
+		newLeft, err := op.Apply()
+		if err != nil {
+			return nil, fmt.Errorf("Overlaying (in following order: %s): %s",
+				o.allOverlayDescs(overlays), err)
+		}
+		if o.trace != nil {
+			o.trace.record(opTrace, docSetsWithoutOverlays, docSetToFilesMapping)
+		}
+		docSetsWithoutOverlays = newLeft.([]*yamlmeta.DocumentSet)
+	}
 
 	result := map[*FileInLibrary]*yamlmeta.DocumentSet{}
 
@@ -82,10 +103,18 @@ func (o OverlayPostProcessing) Apply() (map[*FileInLibrary]*yamlmeta.DocumentSet
 	return result, nil
 }
 
-func (o OverlayPostProcessing) allFileDescs(files []*FileInLibrary) string {
+// allOverlayDescs lists files (and priorities, if any) in the order that their overlays were applied
+func (o OverlayPostProcessing) allOverlayDescs(overlays []OrderedOverlay) string {
 	var result []string
-	for _, fileInLib := range files {
-		result = append(result, fileInLib.File.RelativePath())
+	for i, overlay := range overlays {
+		if i > 0 && overlays[i-1].File == overlay.File && overlays[i-1].Priority == overlay.Priority {
+			continue
+		}
+		desc := overlay.File.File.RelativePath()
+		if overlay.Priority != 0 {
+			desc += fmt.Sprintf(" (priority %d)", overlay.Priority)
+		}
+		result = append(result, desc)
 	}
 	return strings.Join(result, ", ")
 }
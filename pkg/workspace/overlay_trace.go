@@ -0,0 +1,154 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package workspace
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
+)
+
+// OverlayTrace reports, for each overlay document (in the order they were applied), the documents it matched and
+// the operations it applied to nodes within them.
+type OverlayTrace struct {
+	Overlays []OverlayTraceEntry
+}
+
+// OverlayTraceEntry records the application of a single overlay document
+type OverlayTraceEntry struct {
+	Position string
+	Op       string
+	Matches  []OverlayTraceMatch
+}
+
+// OverlayTraceMatch identifies a matched document by its file, its index amongst that file's documents and
+// (if it is a Kubernetes resource) its kind, name and namespace.
+type OverlayTraceMatch struct {
+	File      string
+	Index     int
+	Kind      string
+	Name      string
+	Namespace string
+	Nodes     []OverlayTraceNode
+}
+
+// OverlayTraceNode records an operation applied at a path (e.g. "spec.containers[0].image") within a matched document
+type OverlayTraceNode struct {
+	Path     string
+	Op       string
+	Position string
+}
+
+func (t *OverlayTrace) record(trace *yttoverlay.Trace, leftDocSets []*yamlmeta.DocumentSet,
+	docSetToFilesMapping map[*yamlmeta.DocumentSet]*FileInLibrary) {
+
+	for _, overlay := range trace.Overlays {
+		entry := OverlayTraceEntry{Position: overlay.Position.AsCompactString(), Op: overlay.Op}
+		for _, match := range overlay.Matches {
+			traceMatch := OverlayTraceMatch{
+				Index:     match.DocIdx,
+				Kind:      t.docField(match.Doc, "kind"),
+				Name:      t.docField(match.Doc, "metadata", "name"),
+				Namespace: t.docField(match.Doc, "metadata", "namespace"),
+			}
+			if file, found := docSetToFilesMapping[leftDocSets[match.DocSetIdx]]; found {
+				traceMatch.File = file.File.RelativePath()
+			}
+			for _, node := range match.Nodes {
+				traceMatch.Nodes = append(traceMatch.Nodes, OverlayTraceNode{
+					Path:     node.Path,
+					Op:       node.Op,
+					Position: node.Position.AsCompactString(),
+				})
+			}
+			entry.Matches = append(entry.Matches, traceMatch)
+		}
+		t.Overlays = append(t.Overlays, entry)
+	}
+}
+
+// docField provides the string at `path` in `doc` (empty if there is none)
+func (t *OverlayTrace) docField(doc *yamlmeta.Document, path ...string) string {
+	var val interface{} = doc.Value
+	for _, key := range path {
+		docMap, isMap := val.(*yamlmeta.Map)
+		if !isMap {
+			return ""
+		}
+		val = nil
+		for _, item := range docMap.Items {
+			if item.Key == key {
+				val = item.Value
+				break
+			}
+		}
+	}
+	strVal, _ := val.(string)
+	return strVal
+}
+
+// AsDocument renders the trace as a YAML document (e.g. to be output as YAML or JSON)
+func (t *OverlayTrace) AsDocument() *yamlmeta.Document {
+	overlays := &yamlmeta.Array{}
+	for _, entry := range t.Overlays {
+		matches := &yamlmeta.Array{}
+		for _, match := range entry.Matches {
+			matchMap := &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+				{Key: "file", Value: match.File},
+				{Key: "index", Value: match.Index},
+			}}
+			for _, field := range [][2]string{{"kind", match.Kind}, {"name", match.Name}, {"namespace", match.Namespace}} {
+				if len(field[1]) > 0 {
+					matchMap.Items = append(matchMap.Items, &yamlmeta.MapItem{Key: field[0], Value: field[1]})
+				}
+			}
+			nodes := &yamlmeta.Array{}
+			for _, node := range match.Nodes {
+				nodes.Items = append(nodes.Items, &yamlmeta.ArrayItem{Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+					{Key: "path", Value: node.Path},
+					{Key: "op", Value: node.Op},
+					{Key: "overlay", Value: node.Position},
+				}}})
+			}
+			matchMap.Items = append(matchMap.Items, &yamlmeta.MapItem{Key: "nodes", Value: nodes})
+			matches.Items = append(matches.Items, &yamlmeta.ArrayItem{Value: matchMap})
+		}
+		overlays.Items = append(overlays.Items, &yamlmeta.ArrayItem{Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: "overlay", Value: entry.Position},
+			{Key: "op", Value: entry.Op},
+			{Key: "matches", Value: matches},
+		}}})
+	}
+	return &yamlmeta.Document{Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{{Key: "overlays", Value: overlays}}}}
+}
+
+// AsText renders the trace for humans to read
+func (t *OverlayTrace) AsText() string {
+	var result strings.Builder
+	for _, entry := range t.Overlays {
+		fmt.Fprintf(&result, "Overlay %s (%s)\n", entry.Position, entry.Op)
+		if len(entry.Matches) == 0 {
+			result.WriteString("  matched nothing\n")
+		}
+		for _, match := range entry.Matches {
+			fmt.Fprintf(&result, "  matched %s[%d]", match.File, match.Index)
+			var desc []string
+			for _, field := range [][2]string{{"kind", match.Kind}, {"name", match.Name}, {"namespace", match.Namespace}} {
+				if len(field[1]) > 0 {
+					desc = append(desc, field[0]+": "+field[1])
+				}
+			}
+			if len(desc) > 0 {
+				fmt.Fprintf(&result, " (%s)", strings.Join(desc, ", "))
+			}
+			result.WriteString("\n")
+			for _, node := range match.Nodes {
+				fmt.Fprintf(&result, "    %s: %s (%s)\n", node.Path, node.Op, node.Position)
+			}
+		}
+	}
+	return result.String()
+}
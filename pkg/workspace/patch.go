@@ -0,0 +1,458 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package workspace
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/k14s/starlark-go/starlark"
+	"github.com/k14s/starlark-go/starlarkstruct"
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/template/core"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
+)
+
+const (
+	smpDirectivePatch           = "$patch"
+	smpDirectiveSetElementOrder = "$setElementOrder/"
+
+	jsonPatchAppendIdx = "-"
+)
+
+// smpMergeKeys are the keys by which items of well-known (Kubernetes) lists are merged by strategic merge patches.
+// Other lists are replaced.
+var smpMergeKeys = map[string][]string{
+	"containers":          {"name"},
+	"initContainers":      {"name"},
+	"ephemeralContainers": {"name"},
+	"env":                 {"name"},
+	"volumes":             {"name"},
+	"imagePullSecrets":    {"name"},
+	"volumeMounts":        {"mountPath"},
+	"volumeDevices":       {"devicePath"},
+	"ports":               {"containerPort", "port"},
+	"hostAliases":         {"ip"},
+}
+
+// Patch is a file of patches in a format other than overlays (i.e. RFC 6902 JSON Patch or strategic merge patch),
+// to be applied to other documents as overlays.
+type Patch struct {
+	file   *files.File
+	docSet *yamlmeta.DocumentSet
+}
+
+// AsOverlays converts patches into overlay documents, positioned at the patches they were converted from.
+func (p Patch) AsOverlays() ([]*yamlmeta.Document, error) {
+	target, err := p.target()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*yamlmeta.Document
+	for _, doc := range p.docSet.Items {
+		if doc.IsEmpty() {
+			continue
+		}
+		switch p.file.PatchType() {
+		case files.PatchTypeJSON:
+			if target == nil {
+				return nil, fmt.Errorf("Expected JSON patch file '%s' to be given a target (e.g. --file-mark '%s:patch-target=kind=Deployment,name=web')",
+					p.file.RelativePath(), p.file.OriginalRelativePath())
+			}
+			ops, isArray := doc.Value.(*yamlmeta.Array)
+			if !isArray {
+				return nil, fmt.Errorf("Expected JSON patch (%s) to be an array of operations", doc.Position.AsCompactString())
+			}
+			for _, item := range ops.Items {
+				overlay, err := p.jsonPatchOp(item, target)
+				if err != nil {
+					return nil, fmt.Errorf("JSON patch operation on %s: %s", item.Position.AsCompactString(), err)
+				}
+				result = append(result, overlay)
+			}
+
+		case files.PatchTypeStrategicMerge:
+			overlay, err := p.strategicMergePatch(doc, target)
+			if err != nil {
+				return nil, fmt.Errorf("Strategic merge patch on %s: %s", doc.Position.AsCompactString(), err)
+			}
+			result = append(result, overlay)
+
+		default:
+			panic(fmt.Sprintf("Unknown patch type %d", p.file.PatchType()))
+		}
+	}
+	return result, nil
+}
+
+// target parses the selector given via patch-target (e.g. "kind=Deployment,name=web") into the subset of fields
+// that selected documents have. Nil, if none was given.
+func (p Patch) target() (*orderedmap.Map, error) {
+	if len(p.file.PatchTarget()) == 0 {
+		return nil, nil
+	}
+	target := orderedmap.NewMap()
+	metadata := orderedmap.NewMap()
+	for _, field := range strings.Split(p.file.PatchTarget(), ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 || len(kv[1]) == 0 {
+			return nil, fmt.Errorf("Expected patch target '%s' to be in format key=value,... (e.g. kind=Deployment,name=web)", p.file.PatchTarget())
+		}
+		switch kv[0] {
+		case "apiVersion", "kind":
+			target.Set(kv[0], kv[1])
+		case "name", "namespace":
+			metadata.Set(kv[0], kv[1])
+		default:
+			return nil, fmt.Errorf("Expected patch target '%s' to only select by apiVersion, kind, name or namespace, but was by '%s'",
+				p.file.PatchTarget(), kv[0])
+		}
+	}
+	if metadata.Len() > 0 {
+		target.Set("metadata", metadata)
+	}
+	return target, nil
+}
+
+func (p Patch) jsonPatchOp(item *yamlmeta.ArrayItem, target *orderedmap.Map) (*yamlmeta.Document, error) {
+	opMap, isMap := item.Value.(*yamlmeta.Map)
+	if !isMap {
+		return nil, fmt.Errorf("Expected operation to be a map (e.g. {op: replace, path: /spec/replicas, value: 3})")
+	}
+	var op, path string
+	var value interface{}
+	var hasValue bool
+	pathPos := item.Position
+	for _, opItem := range opMap.Items {
+		switch opItem.Key {
+		case "op":
+			op = fmt.Sprintf("%v", opItem.Value)
+		case "path":
+			path = fmt.Sprintf("%v", opItem.Value)
+			pathPos = opItem.Position
+		case "value":
+			value = opItem.Value
+			hasValue = true
+		}
+	}
+
+	var anns template.NodeAnnotations
+	switch op {
+	case "add":
+		anns = template.NodeAnnotations{yttoverlay.AnnotationReplace: p.orAddAnn()}
+	case "remove":
+		anns = template.NodeAnnotations{yttoverlay.AnnotationRemove: template.NodeAnnotation{}}
+	case "replace":
+		anns = template.NodeAnnotations{yttoverlay.AnnotationReplace: template.NodeAnnotation{}}
+	case "test":
+		anns = template.NodeAnnotations{yttoverlay.AnnotationAssert: template.NodeAnnotation{}}
+	default:
+		return nil, fmt.Errorf("Expected op to be one of add, remove, replace or test (move and copy are not supported), but was '%s'", op)
+	}
+	if op != "remove" && !hasValue {
+		return nil, fmt.Errorf("Expected '%s' operation to have a value", op)
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("Expected path '%s' to be a JSON pointer within the document (e.g. /spec/replicas)", path)
+	}
+	var segments []string
+	for _, segment := range strings.Split(path[1:], "/") {
+		segments = append(segments, strings.NewReplacer("~1", "/", "~0", "~").Replace(segment))
+	}
+
+	docAnns, err := p.targetAnns(target, pathPos)
+	if err != nil {
+		return nil, err
+	}
+
+	// segments that could be array indexes refer to map keys or array items depending on the targeted
+	// document, hence are only resolved once the overlay is applied
+	if p.hasIdxSegment(segments) {
+		pointerOp := jsonPointerOp{op: op, path: path, segments: segments, value: value, position: pathPos}
+		docAnns[yttoverlay.AnnotationReplace] = template.NodeAnnotation{
+			Kwargs: []starlark.Tuple{{starlark.String(yttoverlay.ReplaceAnnotationKwargVia), pointerOp.AsStarlarkValue()}},
+		}
+		doc := &yamlmeta.Document{Position: item.Position}
+		doc.SetAnnotations(docAnns)
+		return doc, nil
+	}
+
+	docValue := p.jsonPatchNode(segments, op, anns, value, pathPos)
+	doc := &yamlmeta.Document{Value: docValue, Position: item.Position}
+	doc.SetAnnotations(docAnns)
+	return doc, nil
+}
+
+func (p Patch) hasIdxSegment(segments []string) bool {
+	for _, segment := range segments {
+		idx, err := strconv.Atoi(segment)
+		if (err == nil && idx >= 0) || segment == jsonPatchAppendIdx {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonPatchNode builds the map that holds the first of `segments` (all being map keys); the last segment
+// is annotated `leafAnns`.
+func (p Patch) jsonPatchNode(segments []string, op string, leafAnns template.NodeAnnotations,
+	value interface{}, pos *filepos.Position) *yamlmeta.Map {
+
+	item := &yamlmeta.MapItem{Key: segments[0], Value: value, Position: pos}
+	if len(segments) > 1 {
+		item.Value = p.jsonPatchNode(segments[1:], op, leafAnns, value, pos)
+	} else {
+		anns := p.copyAnns(leafAnns)
+		if op == "add" {
+			anns[yttoverlay.AnnotationMatch] = p.missingOKAnn()
+		}
+		item.SetAnnotations(anns)
+	}
+	return &yamlmeta.Map{Items: []*yamlmeta.MapItem{item}, Position: pos}
+}
+
+func (p Patch) strategicMergePatch(doc *yamlmeta.Document, target *orderedmap.Map) (*yamlmeta.Document, error) {
+	patchMap, isMap := doc.Value.(*yamlmeta.Map)
+	if !isMap {
+		return nil, fmt.Errorf("Expected patch to be a map")
+	}
+
+	if target == nil {
+		target = orderedmap.NewMap()
+		metadata := orderedmap.NewMap()
+		if kind, found := p.mapValue(patchMap, "kind"); found {
+			target.Set("kind", kind)
+		}
+		if metadataMap, found := p.mapValue(patchMap, "metadata"); found {
+			if typedMetadata, isMap := metadataMap.(*yamlmeta.Map); isMap {
+				for _, key := range []string{"name", "namespace"} {
+					if val, found := p.mapValue(typedMetadata, key); found {
+						metadata.Set(key, val)
+					}
+				}
+			}
+		}
+		if _, found := target.Get("kind"); !found || metadata.Len() == 0 {
+			return nil, fmt.Errorf("Expected patch to identify its target by kind and metadata.name (or to be given a target via patch-target file mark)")
+		}
+		target.Set("metadata", metadata)
+	}
+
+	overlayMap, err := p.smpMap(patchMap)
+	if err != nil {
+		return nil, err
+	}
+	docAnns, err := p.targetAnns(target, doc.Position)
+	if err != nil {
+		return nil, err
+	}
+	overlay := &yamlmeta.Document{Value: overlayMap, Position: doc.Position}
+	overlay.SetAnnotations(docAnns)
+	return overlay, nil
+}
+
+// smpMap converts a map within a strategic merge patch: keys are merged (added, if missing) and removed when null.
+func (p Patch) smpMap(patchMap *yamlmeta.Map) (*yamlmeta.Map, error) {
+	result := &yamlmeta.Map{Position: patchMap.Position}
+	for _, item := range patchMap.Items {
+		key := fmt.Sprintf("%v", item.Key)
+		switch {
+		case key == smpDirectivePatch:
+			continue // handled by the parent
+		case strings.HasPrefix(key, smpDirectiveSetElementOrder):
+			continue // only affects the order of items
+		case strings.HasPrefix(key, "$"):
+			return nil, fmt.Errorf("Map item (key '%s') on %s: Expected directive to be %s (other directives are not supported)",
+				key, item.Position.AsCompactString(), smpDirectivePatch)
+		}
+
+		anns := template.NodeAnnotations{yttoverlay.AnnotationMatch: p.missingOKAnn()}
+		newItem := &yamlmeta.MapItem{Key: item.Key, Position: item.Position}
+
+		switch typedVal := item.Value.(type) {
+		case nil:
+			anns[yttoverlay.AnnotationRemove] = template.NodeAnnotation{}
+
+		case *yamlmeta.Map:
+			directive, err := p.smpDirective(typedVal)
+			if err != nil {
+				return nil, err
+			}
+			switch directive {
+			case "delete":
+				anns[yttoverlay.AnnotationRemove] = template.NodeAnnotation{}
+			case "replace":
+				anns[yttoverlay.AnnotationReplace] = p.orAddAnn()
+				newItem.Value = p.withoutDirectives(typedVal)
+			default:
+				newItem.Value, err = p.smpMap(typedVal)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+		case *yamlmeta.Array:
+			mergeKey := p.smpMergeKey(key, typedVal)
+			if len(mergeKey) == 0 {
+				anns[yttoverlay.AnnotationReplace] = p.orAddAnn()
+				newItem.Value = typedVal.DeepCopy()
+				break
+			}
+			newArray, err := p.smpArray(typedVal, mergeKey)
+			if err != nil {
+				return nil, err
+			}
+			newItem.Value = newArray
+
+		default:
+			newItem.Value = typedVal
+		}
+
+		newItem.SetAnnotations(anns)
+		result.Items = append(result.Items, newItem)
+	}
+	return result, nil
+}
+
+// smpArray converts a list within a strategic merge patch whose items are merged with those that have the same `mergeKey`
+func (p Patch) smpArray(patchArray *yamlmeta.Array, mergeKey string) (*yamlmeta.Array, error) {
+	result := &yamlmeta.Array{Position: patchArray.Position}
+	for _, item := range patchArray.Items {
+		itemMap := item.Value.(*yamlmeta.Map)
+		keyVal, _ := p.mapValue(itemMap, mergeKey)
+
+		matchAnn, err := p.matchAnn("subset", core.NewGoValue(orderedmap.NewMapWithItems(
+			[]orderedmap.MapItem{{Key: mergeKey, Value: keyVal}})).AsStarlarkValue(), p.missingOKAnn().Kwargs)
+		if err != nil {
+			return nil, err
+		}
+		anns := template.NodeAnnotations{yttoverlay.AnnotationMatch: matchAnn}
+		newItem := &yamlmeta.ArrayItem{Position: item.Position}
+
+		directive, err := p.smpDirective(itemMap)
+		if err != nil {
+			return nil, err
+		}
+		switch directive {
+		case "delete":
+			anns[yttoverlay.AnnotationRemove] = template.NodeAnnotation{}
+		case "replace":
+			anns[yttoverlay.AnnotationReplace] = p.orAddAnn()
+			newItem.Value = p.withoutDirectives(itemMap)
+		default:
+			newItem.Value, err = p.smpMap(itemMap)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		newItem.SetAnnotations(anns)
+		result.Items = append(result.Items, newItem)
+	}
+	return result, nil
+}
+
+// smpMergeKey provides the key by which items of list `key` are merged (empty, if the list is to be replaced)
+func (p Patch) smpMergeKey(key string, patchArray *yamlmeta.Array) string {
+	for _, mergeKey := range smpMergeKeys[key] {
+		hasKey := true
+		for _, item := range patchArray.Items {
+			itemMap, isMap := item.Value.(*yamlmeta.Map)
+			if !isMap {
+				return ""
+			}
+			if _, found := p.mapValue(itemMap, mergeKey); !found {
+				hasKey = false
+				break
+			}
+		}
+		if hasKey {
+			return mergeKey
+		}
+	}
+	return ""
+}
+
+func (p Patch) smpDirective(patchMap *yamlmeta.Map) (string, error) {
+	directive, found := p.mapValue(patchMap, smpDirectivePatch)
+	if !found {
+		return "", nil
+	}
+	switch directive {
+	case "delete", "replace", "merge":
+		return directive.(string), nil
+	}
+	return "", fmt.Errorf("Map on %s: Expected %s directive to be one of delete, replace or merge, but was '%v'",
+		patchMap.Position.AsCompactString(), smpDirectivePatch, directive)
+}
+
+func (p Patch) withoutDirectives(patchMap *yamlmeta.Map) *yamlmeta.Map {
+	result := patchMap.DeepCopy()
+	var items []*yamlmeta.MapItem
+	for _, item := range result.Items {
+		if item.Key != smpDirectivePatch {
+			items = append(items, item)
+		}
+	}
+	result.Items = items
+	return result
+}
+
+func (p Patch) mapValue(m *yamlmeta.Map, key string) (interface{}, bool) {
+	for _, item := range m.Items {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// targetAnns selects (at least one of) the documents that have the fields of `target`
+func (p Patch) targetAnns(target *orderedmap.Map, pos *filepos.Position) (template.NodeAnnotations, error) {
+	matchAnn, err := p.matchAnn("subset", core.NewGoValue(target).AsStarlarkValue(),
+		[]starlark.Tuple{{starlark.String(yttoverlay.MatchAnnotationKwargExpects), starlark.String("1+")}})
+	if err != nil {
+		return nil, err
+	}
+	return template.NodeAnnotations{yttoverlay.AnnotationMatch: matchAnn}, nil
+}
+
+// matchAnn builds @overlay/match by=overlay.<matcherName>(matcherArg), along with `kwargs`
+func (p Patch) matchAnn(matcherName string, matcherArg starlark.Value, kwargs []starlark.Tuple) (template.NodeAnnotation, error) {
+	overlayModule := yttoverlay.API["overlay"].(*starlarkstruct.Module)
+	matcher, err := starlark.Call(&starlark.Thread{Name: "patch"}, overlayModule.Members[matcherName], starlark.Tuple{matcherArg}, nil)
+	if err != nil {
+		return template.NodeAnnotation{}, err
+	}
+	return template.NodeAnnotation{
+		Kwargs: append([]starlark.Tuple{{starlark.String(yttoverlay.MatchAnnotationKwargBy), matcher}}, kwargs...),
+	}, nil
+}
+
+func (p Patch) missingOKAnn() template.NodeAnnotation {
+	return template.NodeAnnotation{
+		Kwargs: []starlark.Tuple{{starlark.String(yttoverlay.MatchAnnotationKwargMissingOK), starlark.Bool(true)}},
+	}
+}
+
+func (p Patch) orAddAnn() template.NodeAnnotation {
+	return template.NodeAnnotation{
+		Kwargs: []starlark.Tuple{{starlark.String(yttoverlay.ReplaceAnnotationKwargOrAdd), starlark.Bool(true)}},
+	}
+}
+
+func (p Patch) copyAnns(anns template.NodeAnnotations) template.NodeAnnotations {
+	result := template.NodeAnnotations{}
+	for name, ann := range anns {
+		result[name] = ann
+	}
+	return result
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package workspace
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/k14s/starlark-go/starlark"
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/template/core"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	"github.com/k14s/ytt/pkg/yamltemplate"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
+)
+
+// jsonPointerOp applies a JSON patch operation whose path is resolved against the targeted document: as per
+// RFC 6901, a segment refers to a map key when within a map, or to an array index when within an array.
+type jsonPointerOp struct {
+	op       string
+	path     string
+	segments []string
+	value    interface{}
+	position *filepos.Position
+}
+
+// AsStarlarkValue provides the operation as a function suitable for @overlay/replace via=...
+func (o jsonPointerOp) AsStarlarkValue() starlark.Value {
+	return starlark.NewBuiltin("json_patch_op", core.ErrWrapper(o.apply))
+}
+
+func (o jsonPointerOp) apply(thread *starlark.Thread, f *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if args.Len() != 2 {
+		return starlark.None, fmt.Errorf("expected exactly two arguments")
+	}
+	left, err := core.NewStarlarkValue(args.Index(0)).AsGoValue()
+	if err != nil {
+		return starlark.None, err
+	}
+	result, err := o.applyTo(left, o.segments)
+	if err != nil {
+		return starlark.None, fmt.Errorf("JSON patch operation '%s' on %s: %s", o.op, o.position.AsCompactString(), err)
+	}
+	return yamltemplate.NewGoValueWithYAML(result).AsStarlarkValue(), nil
+}
+
+func (o jsonPointerOp) applyTo(val interface{}, segments []string) (interface{}, error) {
+	segment := segments[0]
+	isLeaf := len(segments) == 1
+
+	switch typedVal := val.(type) {
+	case *yamlmeta.Map:
+		var found *yamlmeta.MapItem
+		var foundIdx int
+		for i, item := range typedVal.Items {
+			if fmt.Sprintf("%v", item.Key) == segment {
+				found, foundIdx = item, i
+				break
+			}
+		}
+		if found == nil {
+			if isLeaf && o.op == "add" {
+				typedVal.Items = append(typedVal.Items, &yamlmeta.MapItem{Key: segment, Value: o.newValue(), Position: o.position})
+				return typedVal, nil
+			}
+			return nil, fmt.Errorf("Expected path '%s' to exist, but map did not have key '%s'", o.path, segment)
+		}
+		if !isLeaf {
+			newVal, err := o.applyTo(found.Value, segments[1:])
+			if err != nil {
+				return nil, err
+			}
+			found.Value = newVal
+			return typedVal, nil
+		}
+
+		switch o.op {
+		case "add", "replace":
+			found.Value = o.newValue()
+		case "remove":
+			typedVal.Items = append(typedVal.Items[:foundIdx], typedVal.Items[foundIdx+1:]...)
+		case "test":
+			return typedVal, o.test(found.Value)
+		}
+		return typedVal, nil
+
+	case *yamlmeta.Array:
+		if segment == jsonPatchAppendIdx {
+			if !isLeaf || o.op != "add" {
+				return nil, fmt.Errorf("Expected '%s' (i.e. past the last array item) to only be used as the last segment of path to add", jsonPatchAppendIdx)
+			}
+			typedVal.Items = append(typedVal.Items, &yamlmeta.ArrayItem{Value: o.newValue(), Position: o.position})
+			return typedVal, nil
+		}
+
+		idx, err := strconv.Atoi(segment)
+		maxIdx := len(typedVal.Items) - 1
+		if isLeaf && o.op == "add" {
+			maxIdx++ // adding directly after the last item
+		}
+		if err != nil || idx < 0 || idx > maxIdx {
+			return nil, fmt.Errorf("Expected path '%s' to exist, but array of %d items did not have index '%s'", o.path, len(typedVal.Items), segment)
+		}
+		if !isLeaf {
+			newVal, err := o.applyTo(typedVal.Items[idx].Value, segments[1:])
+			if err != nil {
+				return nil, err
+			}
+			typedVal.Items[idx].Value = newVal
+			return typedVal, nil
+		}
+
+		switch o.op {
+		case "add":
+			// as per RFC 6902, adding at an index shifts existing items along
+			newItem := &yamlmeta.ArrayItem{Value: o.newValue(), Position: o.position}
+			typedVal.Items = append(typedVal.Items[:idx], append([]*yamlmeta.ArrayItem{newItem}, typedVal.Items[idx:]...)...)
+		case "replace":
+			typedVal.Items[idx].Value = o.newValue()
+		case "remove":
+			typedVal.Items = append(typedVal.Items[:idx], typedVal.Items[idx+1:]...)
+		case "test":
+			return typedVal, o.test(typedVal.Items[idx].Value)
+		}
+		return typedVal, nil
+
+	default:
+		return nil, fmt.Errorf("Expected path '%s' to exist, but value holding '%s' was neither a map nor an array", o.path, segment)
+	}
+}
+
+func (o jsonPointerOp) test(val interface{}) error {
+	if result, explain := (yttoverlay.Comparison{}).Compare(val, o.newValue()); !result {
+		return fmt.Errorf("Expected value at path '%s' to equal the given value, but did not: %s", o.path, explain)
+	}
+	return nil
+}
+
+// newValue ensures that a value added to several documents is not shared between them
+func (o jsonPointerOp) newValue() interface{} {
+	if node, isNode := o.value.(yamlmeta.Node); isNode {
+		return node.DeepCopyAsInterface()
+	}
+	return o.value
+}
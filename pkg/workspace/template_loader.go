@@ -9,6 +9,7 @@ import (
 
 	"github.com/k14s/starlark-go/starlark"
 	"github.com/k14s/ytt/pkg/cmd/ui"
+	"github.com/k14s/ytt/pkg/filepos"
 	"github.com/k14s/ytt/pkg/files"
 	"github.com/k14s/ytt/pkg/schema"
 	"github.com/k14s/ytt/pkg/template"
@@ -33,6 +34,14 @@ type TemplateLoaderOpts struct {
 	ImplicitMapKeyOverrides bool
 	StrictYAML              bool
 	SchemaEnabled           bool
+	// DeprecatedDataValuesAsErrors fails (rather than warns) when deprecated data values are set
+	DeprecatedDataValuesAsErrors bool
+	// DataValueResolvers resolve data values that refer to values held elsewhere (none are resolved, by default)
+	DataValueResolvers ValueResolvers
+	// DataValuesProfiles selects the data values documents of these profiles to be applied (in this order)
+	DataValuesProfiles []string
+	// OverlayTrace records how overlays were applied to the templates of each library (see EvalResult.OverlayTrace)
+	OverlayTrace bool
 }
 
 type TemplateLoaderOptsOverrides struct {
@@ -58,6 +67,11 @@ func NewTemplateLoader(values *DataValues, libraryValuess []*DataValues, library
 	}
 }
 
+// withValues creates a loader that evaluates templates with `values` as data.values (and otherwise as this one does)
+func (l *TemplateLoader) withValues(values *DataValues) *TemplateLoader {
+	return NewTemplateLoader(values, l.libraryValuess, l.librarySchemas, l.opts, l.libraryExecFactory, l.ui)
+}
+
 func (l *TemplateLoader) FindCompiledTemplate(path string) (*template.CompiledTemplate, error) {
 	ct, found := l.compiledTemplates[path]
 	if !found {
@@ -187,7 +201,7 @@ func (l *TemplateLoader) EvalYAML(libraryCtx LibraryExecutionContext, file *file
 
 	globals, resultVal, err := compiledTemplate.Eval(thread, l)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, l.values.Redactor().RedactError(err)
 	}
 
 	return globals, resultVal.(*yamlmeta.DocumentSet), nil
@@ -229,7 +243,7 @@ func (l *TemplateLoader) EvalText(libraryCtx LibraryExecutionContext, file *file
 
 	globals, resultVal, err := compiledTemplate.Eval(thread, l)
 	if err != nil {
-		return nil, nil, fmt.Errorf("Evaluating text template: %s", err)
+		return nil, nil, fmt.Errorf("Evaluating text template: %s", l.values.Redactor().Redact(err.Error()))
 	}
 
 	return globals, resultVal.(*texttemplate.NodeRoot), nil
@@ -259,7 +273,7 @@ func (l *TemplateLoader) EvalStarlark(libraryCtx LibraryExecutionContext, file *
 
 	globals, _, err := compiledTemplate.Eval(thread, l)
 	if err != nil {
-		return nil, fmt.Errorf("Evaluating starlark template: %s", err)
+		return nil, fmt.Errorf("Evaluating starlark template: %s", l.values.Redactor().Redact(err.Error()))
 	}
 
 	return globals, nil
@@ -269,6 +283,7 @@ const (
 	threadCurrentLibraryKey = "ytt.curr_library_key"
 	threadRootLibraryKey    = "ytt.root_library_key"
 	threadYTTLibraryKey     = "ytt.ytt_library_key"
+	threadTemplateLoaderKey = "ytt.template_loader_key"
 )
 
 func (l *TemplateLoader) getCurrentLibrary(thread *starlark.Thread) *Library {
@@ -314,9 +329,30 @@ func (l *TemplateLoader) newThread(libraryCtx LibraryExecutionContext,
 	l.setCurrentLibrary(thread, libraryCtx.Current)
 	l.setRootLibrary(thread, libraryCtx.Root)
 	l.setYTTLibrary(thread, yttLibrary)
+	thread.SetLocal(threadTemplateLoaderKey, l)
 	return thread
 }
 
+// callerPosition provides the position (within a template) of the innermost call being evaluated by `thread`.
+func callerPosition(thread *starlark.Thread) *filepos.Position {
+	l, ok := thread.Local(threadTemplateLoaderKey).(*TemplateLoader)
+	if !ok {
+		return filepos.NewUnknownPosition()
+	}
+	stack := thread.CallStack()
+	for i := len(stack) - 1; i >= 0; i-- {
+		ct, err := l.FindCompiledTemplate(stack[i].Pos.Filename())
+		if err != nil || stack[i].Pos.Line == 0 {
+			continue
+		}
+		line := ct.CodeAtLine(filepos.NewPosition(int(stack[i].Pos.Line)))
+		if line != nil && line.SourceLine != nil {
+			return line.SourceLine.Position
+		}
+	}
+	return filepos.NewUnknownPosition()
+}
+
 func (l *TemplateLoader) addCompiledTemplate(path string, ct *template.CompiledTemplate) {
 	l.compiledTemplates[path] = ct
 }
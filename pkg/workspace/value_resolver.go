@@ -0,0 +1,137 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Data values may refer to a value held elsewhere (e.g. "ref+env://DB_PASSWORD"); such references are resolved
+// (once all data values have been overlaid) only if resolving that kind of reference has been enabled.
+const (
+	valueRefPrefix    = "ref+"
+	valueRefSchemeSep = "://"
+
+	ValueRefSchemeFile = "file"
+	ValueRefSchemeEnv  = "env"
+	ValueRefSchemeExec = "exec"
+)
+
+// ValueResolver retrieves the value held at `location`, for one kind (i.e. scheme) of reference.
+type ValueResolver interface {
+	Resolve(location string) (string, error)
+}
+
+// FileValueResolver resolves "ref+file:///path/to/file" to the contents of that file (less any trailing newline)
+type FileValueResolver struct{}
+
+// EnvValueResolver resolves "ref+env://NAME" to the value of that environment variable
+type EnvValueResolver struct{}
+
+// ExecValueResolver resolves "ref+exec://command arg1 arg2" to the output of that command (less any trailing newline).
+// Arguments are separated by whitespace, and so cannot themselves contain whitespace (nor are quotes removed); such
+// arguments can be given instead as a JSON array of the command and its arguments
+// (e.g. 'ref+exec://["vault", "read", "-field=password", "secret/db prod"]').
+type ExecValueResolver struct{}
+
+// ValueResolvers holds the resolver of each kind of reference that is enabled (by scheme)
+type ValueResolvers map[string]ValueResolver
+
+var _ ValueResolver = FileValueResolver{}
+var _ ValueResolver = EnvValueResolver{}
+var _ ValueResolver = ExecValueResolver{}
+
+// NewValueResolvers enables the built-in resolvers of the given schemes (e.g. "file", "env", "exec")
+func NewValueResolvers(schemes []string) (ValueResolvers, error) {
+	builtin := map[string]ValueResolver{
+		ValueRefSchemeFile: FileValueResolver{},
+		ValueRefSchemeEnv:  EnvValueResolver{},
+		ValueRefSchemeExec: ExecValueResolver{},
+	}
+
+	resolvers := ValueResolvers{}
+	for _, scheme := range schemes {
+		resolver, found := builtin[scheme]
+		if !found {
+			return nil, fmt.Errorf("Unknown kind of data value reference '%s' (expected one of: %s)", scheme, strings.Join(ValueResolvers(builtin).schemes(), ", "))
+		}
+		resolvers[scheme] = resolver
+	}
+	return resolvers, nil
+}
+
+// IsRef indicates whether `val` refers to a value held elsewhere
+func (r ValueResolvers) IsRef(val interface{}) bool {
+	strVal, isString := val.(string)
+	return isString && strings.HasPrefix(strVal, valueRefPrefix) && strings.Contains(strVal, valueRefSchemeSep)
+}
+
+// Resolve retrieves the value referred to by `ref` (e.g. "ref+env://DB_PASSWORD")
+func (r ValueResolvers) Resolve(ref string) (string, error) {
+	pieces := strings.SplitN(strings.TrimPrefix(ref, valueRefPrefix), valueRefSchemeSep, 2)
+	if len(pieces) != 2 {
+		return "", fmt.Errorf("Expected reference to be of the form 'ref+<scheme>://<location>'")
+	}
+	resolver, found := r[pieces[0]]
+	if !found {
+		return "", fmt.Errorf("Resolving references of kind '%s' is not enabled (enabled: %s)", pieces[0], strings.Join(r.schemes(), ", "))
+	}
+	return resolver.Resolve(pieces[1])
+}
+
+func (r ValueResolvers) schemes() []string {
+	var result []string
+	for scheme := range r {
+		result = append(result, scheme)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Resolve reads the file at `location`
+func (FileValueResolver) Resolve(location string) (string, error) {
+	contents, err := ioutil.ReadFile(location)
+	if err != nil {
+		return "", fmt.Errorf("Reading file '%s': %s", location, err)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(contents), "\n"), "\r"), nil
+}
+
+// Resolve looks up the environment variable named `location`
+func (EnvValueResolver) Resolve(location string) (string, error) {
+	val, found := os.LookupEnv(location)
+	if !found {
+		return "", fmt.Errorf("Expected environment variable '%s' to be set", location)
+	}
+	return val, nil
+}
+
+// Resolve runs the command (and its arguments) given in `location`: either separated by whitespace, or as a JSON array
+func (ExecValueResolver) Resolve(location string) (string, error) {
+	args := strings.Fields(location)
+	if strings.HasPrefix(strings.TrimSpace(location), "[") {
+		args = nil
+		err := json.Unmarshal([]byte(location), &args)
+		if err != nil {
+			return "", fmt.Errorf("Expected command given as an array to be a JSON array of strings: %s", err)
+		}
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("Expected command to run")
+	}
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("Running '%s': %s (stderr: %s)", args[0], err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("Running '%s': %s", args[0], err)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(out), "\n"), "\r"), nil
+}
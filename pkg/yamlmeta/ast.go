@@ -66,11 +66,12 @@ type Map struct {
 }
 
 type MapItem struct {
-	Type     Type
-	Comments []*Comment
-	Key      interface{}
-	Value    interface{}
-	Position *filepos.Position
+	Type      Type
+	Comments  []*Comment
+	Key       interface{}
+	Value     interface{}
+	Position  *filepos.Position
+	Sensitive bool // value is secret: redacted wherever it would be displayed
 
 	annotations interface{}
 }
@@ -85,17 +86,19 @@ type Array struct {
 }
 
 type ArrayItem struct {
-	Type     Type
-	Comments []*Comment
-	Value    interface{}
-	Position *filepos.Position
+	Type      Type
+	Comments  []*Comment
+	Value     interface{}
+	Position  *filepos.Position
+	Sensitive bool // value is secret: redacted wherever it would be displayed
 
 	annotations interface{}
 }
 
 type Scalar struct {
-	Position *filepos.Position
-	Value    interface{}
+	Position  *filepos.Position
+	Value     interface{}
+	Sensitive bool
 }
 
 type Comment struct {
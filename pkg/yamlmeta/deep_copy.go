@@ -62,10 +62,11 @@ func (m *Map) DeepCopy() *Map {
 
 func (mi *MapItem) DeepCopy() *MapItem {
 	return &MapItem{
-		Comments: []*Comment(CommentSlice(mi.Comments).DeepCopy()),
-		Key:      mi.Key,
-		Value:    nodeDeepCopy(mi.Value),
-		Position: mi.Position,
+		Comments:  []*Comment(CommentSlice(mi.Comments).DeepCopy()),
+		Key:       mi.Key,
+		Value:     nodeDeepCopy(mi.Value),
+		Position:  mi.Position,
+		Sensitive: mi.Sensitive,
 
 		annotations: annotationsDeepCopy(mi.annotations),
 	}
@@ -88,9 +89,10 @@ func (a *Array) DeepCopy() *Array {
 
 func (ai *ArrayItem) DeepCopy() *ArrayItem {
 	return &ArrayItem{
-		Comments: []*Comment(CommentSlice(ai.Comments).DeepCopy()),
-		Value:    nodeDeepCopy(ai.Value),
-		Position: ai.Position,
+		Comments:  []*Comment(CommentSlice(ai.Comments).DeepCopy()),
+		Value:     nodeDeepCopy(ai.Value),
+		Position:  ai.Position,
+		Sensitive: ai.Sensitive,
 
 		annotations: annotationsDeepCopy(ai.annotations),
 	}
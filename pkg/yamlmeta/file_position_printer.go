@@ -59,7 +59,9 @@ func (p *FilePositionPrinter) print(val interface{}, indent string, writer io.Wr
 
 		for _, item := range typedVal.Items {
 			valStr, isLeaf := p.leafValue(item.Value)
-			if !isLeaf || strings.Contains(valStr, "\n") {
+			if item.Sensitive {
+				fmt.Fprintf(writer, "%s%s%s: %s\n", p.lineStr(item.Position), indent, item.Key, filepos.RedactedValue)
+			} else if !isLeaf || strings.Contains(valStr, "\n") {
 				fmt.Fprintf(writer, "%s%s%s:\n", p.lineStr(item.Position), indent, item.Key)
 				p.print(item.Value, indent+indentLvl, writer)
 			} else {
@@ -76,7 +78,9 @@ func (p *FilePositionPrinter) print(val interface{}, indent string, writer io.Wr
 
 		for i, item := range typedVal.Items {
 			valStr, isLeaf := p.leafValue(item.Value)
-			if !isLeaf || strings.Contains(valStr, "\n") {
+			if item.Sensitive {
+				fmt.Fprintf(writer, "%s%s[%d] %s\n", p.lineStr(item.Position), indent, i, filepos.RedactedValue)
+			} else if !isLeaf || strings.Contains(valStr, "\n") {
 				fmt.Fprintf(writer, "%s%s[%d]\n", p.lineStr(item.Position), indent, i)
 				p.print(item.Value, indent+indentLvl, writer)
 			} else {
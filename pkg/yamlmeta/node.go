@@ -286,7 +286,7 @@ func (mi *MapItem) Check() (chk TypeCheck) {
 		return
 	}
 
-	check = checkCollectionItem(mi.Value, mi.Type.GetValueType(), mi.Position)
+	check = checkCollectionItem(mi.Value, mi.Type.GetValueType(), mi.Position, mi.Sensitive)
 	if check.HasViolations() {
 		chk.Violations = append(chk.Violations, check.Violations...)
 	}
@@ -316,7 +316,7 @@ func (ai *ArrayItem) Check() (chk TypeCheck) {
 		return
 	}
 
-	check := checkCollectionItem(ai.Value, ai.Type.GetValueType(), ai.Position)
+	check := checkCollectionItem(ai.Value, ai.Type.GetValueType(), ai.Position, ai.Sensitive)
 	if check.HasViolations() {
 		chk.Violations = append(chk.Violations, check.Violations...)
 	}
@@ -324,7 +324,7 @@ func (ai *ArrayItem) Check() (chk TypeCheck) {
 }
 
 // is it possible to enter this function with valueType=NullType or AnyType?
-func checkCollectionItem(value interface{}, valueType Type, position *filepos.Position) (chk TypeCheck) {
+func checkCollectionItem(value interface{}, valueType Type, position *filepos.Position, sensitive bool) (chk TypeCheck) {
 	switch typedValue := value.(type) {
 	case *Map:
 		check := typedValue.Check()
@@ -333,7 +333,7 @@ func checkCollectionItem(value interface{}, valueType Type, position *filepos.Po
 		check := typedValue.Check()
 		chk.Violations = append(chk.Violations, check.Violations...)
 	default:
-		chk = valueType.CheckType(&Scalar{Value: value, Position: position})
+		chk = valueType.CheckType(&Scalar{Value: value, Position: position, Sensitive: sensitive})
 	}
 	return chk
 }
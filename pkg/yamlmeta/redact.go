@@ -0,0 +1,190 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package yamlmeta
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/filepos"
+)
+
+// MarkSensitive marks `node` (if it is an item) and all items within it as holding a secret.
+func MarkSensitive(node Node) {
+	switch typedNode := node.(type) {
+	case *MapItem:
+		typedNode.Sensitive = true
+	case *ArrayItem:
+		typedNode.Sensitive = true
+	}
+	for _, val := range node.GetValues() {
+		if childNode, ok := val.(Node); ok {
+			MarkSensitive(childNode)
+		}
+	}
+}
+
+// IsSensitive indicates whether `node` is an item (or the scalar value of one) holding a secret (see MarkSensitive).
+func IsSensitive(node interface{}) bool {
+	switch typedNode := node.(type) {
+	case *MapItem:
+		return typedNode.Sensitive
+	case *ArrayItem:
+		return typedNode.Sensitive
+	case *Scalar:
+		return typedNode.Sensitive
+	}
+	return false
+}
+
+// NewRedactedDocument copies `doc`, replacing the value of each sensitive item.
+func NewRedactedDocument(doc *Document) *Document {
+	result := doc.DeepCopy()
+	redactNode(result)
+	return result
+}
+
+func redactNode(node Node) {
+	switch typedNode := node.(type) {
+	case *MapItem:
+		if typedNode.Sensitive {
+			typedNode.Value = filepos.RedactedValue
+			return
+		}
+	case *ArrayItem:
+		if typedNode.Sensitive {
+			typedNode.Value = filepos.RedactedValue
+			return
+		}
+	}
+	for _, val := range node.GetValues() {
+		if childNode, ok := val.(Node); ok {
+			redactNode(childNode)
+		}
+	}
+}
+
+// Redactor conceals sensitive values within text whose contents are beyond ytt's control (i.e. messages and
+// output formatted by templates). Values are matched as they are rendered (in Starlark or YAML), and only as
+// whole words: an occurrence that is part of a longer word (e.g. "a" within "assert") is not a value.
+type Redactor struct {
+	values []string
+}
+
+// NewRedactor collects the rendered forms of the scalar values held by sensitive items within `node`.
+func NewRedactor(node Node) *Redactor {
+	r := &Redactor{}
+	if node != nil {
+		r.collect(node, false)
+	}
+	// longest first, so that a value containing another is redacted whole
+	sort.SliceStable(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+	return r
+}
+
+func (r *Redactor) collect(node Node, sensitive bool) {
+	sensitive = sensitive || IsSensitive(node)
+	for _, val := range node.GetValues() {
+		if childNode, ok := val.(Node); ok {
+			r.collect(childNode, sensitive)
+			continue
+		}
+		if sensitive {
+			r.add(renderedForms(val)...)
+		}
+	}
+}
+
+func (r *Redactor) add(values ...string) {
+	for _, val := range values {
+		if val == "" {
+			continue
+		}
+		found := false
+		for _, existing := range r.values {
+			if existing == val {
+				found = true
+				break
+			}
+		}
+		if !found {
+			r.values = append(r.values, val)
+		}
+	}
+}
+
+// renderedForms provides the ways `val` is displayed: as a Starlark value (e.g. in an error message
+// formatted by a template) and as YAML.
+func renderedForms(val interface{}) []string {
+	switch typedVal := val.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{typedVal}
+	case bool:
+		if typedVal {
+			return []string{"true", "True"}
+		}
+		return []string{"false", "False"}
+	case float64:
+		return []string{strconv.FormatFloat(typedVal, 'g', -1, 64), strconv.FormatFloat(typedVal, 'g', 6, 64)}
+	default:
+		return []string{fmt.Sprintf("%v", typedVal)}
+	}
+}
+
+// Redact replaces each occurrence (as a whole word) of a sensitive value in `str`.
+func (r *Redactor) Redact(str string) string {
+	for _, val := range r.values {
+		str = redactWord(str, val)
+	}
+	return str
+}
+
+func redactWord(str, val string) string {
+	var result strings.Builder
+	from := 0 // str[:from] has been written to result
+	for searchFrom := 0; ; {
+		idx := strings.Index(str[searchFrom:], val)
+		if idx < 0 {
+			result.WriteString(str[from:])
+			return result.String()
+		}
+		idx += searchFrom
+		end := idx + len(val)
+		startsWord := idx == 0 || !isWordByte(val[0]) || !isWordByte(str[idx-1])
+		endsWord := end == len(str) || !isWordByte(val[len(val)-1]) || !isWordByte(str[end])
+		if !startsWord || !endsWord {
+			// part of a longer word; an occurrence may still start within it
+			searchFrom = idx + 1
+			continue
+		}
+		result.WriteString(str[from:idx] + filepos.RedactedValue)
+		from, searchFrom = end, end
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// RedactError conceals sensitive values in the message of `err` (returning `err` as is, if there are none).
+func (r *Redactor) RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if redacted := r.Redact(msg); redacted != msg {
+		return redactedError{redacted}
+	}
+	return err
+}
+
+type redactedError struct {
+	msg string
+}
+
+func (e redactedError) Error() string { return e.msg }
@@ -0,0 +1,47 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package yamlmeta_test
+
+import (
+	"testing"
+
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkSensitive(t *testing.T) {
+	t.Run("marks only the given node, not those sharing its position", func(t *testing.T) {
+		original := &yamlmeta.MapItem{Key: "password", Value: "hunter2", Position: filepos.NewPosition(3)}
+		copied := original.DeepCopy()
+
+		yamlmeta.MarkSensitive(copied)
+
+		require.True(t, yamlmeta.IsSensitive(copied))
+		require.False(t, yamlmeta.IsSensitive(original))
+		require.Same(t, original.Position, copied.Position)
+	})
+}
+
+func TestRedactor(t *testing.T) {
+	doc := &yamlmeta.Document{Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+		{Key: "password", Value: "hunter2", Sensitive: true},
+		{Key: "initial", Value: "a", Sensitive: true},
+		{Key: "pin", Value: 1234, Sensitive: true},
+		{Key: "ratio", Value: 0.5, Sensitive: true},
+		{Key: "enabled", Value: true, Sensitive: true},
+		{Key: "user", Value: "admin"},
+	}}}
+	redactor := yamlmeta.NewRedactor(doc)
+
+	t.Run("redacts values of any type as they are rendered", func(t *testing.T) {
+		require.Equal(t, "pin=(redacted) ratio=(redacted) enabled=(redacted) (redacted) user=admin",
+			redactor.Redact("pin=1234 ratio=0.5 enabled=True true user=admin"))
+	})
+	t.Run("redacts only whole words", func(t *testing.T) {
+		require.Equal(t, "assert.fail: fail: 'hunter2x', (redacted)-1, 12345",
+			redactor.Redact("assert.fail: fail: 'hunter2x', hunter2-1, 12345"))
+		require.Equal(t, "xa (redacted) a_", redactor.Redact("xa a a_"))
+	})
+}
@@ -22,6 +22,7 @@ const (
 
 	AnnotationMatch              template.AnnotationName = "overlay/match"
 	AnnotationMatchChildDefaults template.AnnotationName = "overlay/match-child-defaults"
+	AnnotationPriority           template.AnnotationName = "overlay/priority" // documents only
 )
 
 var (
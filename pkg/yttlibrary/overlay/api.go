@@ -6,6 +6,7 @@ package overlay
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/k14s/starlark-go/starlark"
 	"github.com/k14s/starlark-go/starlarkstruct"
@@ -24,6 +25,7 @@ var (
 				"all":     starlark.NewBuiltin("overlay.all", core.ErrWrapper(overlayModule{}.All)),
 				"map_key": overlayModule{}.MapKey(),
 				"subset":  starlark.NewBuiltin("overlay.subset", core.ErrWrapper(overlayModule{}.Subset)),
+				"path":    starlark.NewBuiltin("overlay.path", core.ErrWrapper(overlayModule{}.Path)),
 
 				"and_op": starlark.NewBuiltin("overlay.and_op", core.ErrWrapper(overlayModule{}.AndOp)),
 				"or_op":  starlark.NewBuiltin("overlay.or_op", core.ErrWrapper(overlayModule{}.OrOp)),
@@ -115,13 +117,17 @@ func (b overlayModule) mapKey(
 	thread *starlark.Thread, f *starlark.Builtin,
 	args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 
-	if args.Len() != 1 {
-		return starlark.None, fmt.Errorf("expected exactly one argument")
+	if args.Len() == 0 {
+		return starlark.None, fmt.Errorf("expected at least one argument")
 	}
 
-	keyName, err := core.NewStarlarkValue(args.Index(0)).AsString()
-	if err != nil {
-		return starlark.None, err
+	var keyNames []string
+	for _, arg := range args {
+		keyName, err := core.NewStarlarkValue(arg).AsString()
+		if err != nil {
+			return starlark.None, err
+		}
+		keyNames = append(keyNames, keyName)
 	}
 
 	matchFunc := func(thread *starlark.Thread, f *starlark.Builtin,
@@ -140,15 +146,43 @@ func (b overlayModule) mapKey(
 			return starlark.None, err
 		}
 
-		result, err := b.compareByMapKey(keyName, oldVal, newVal)
-		if err != nil {
-			return nil, err
+		for _, keyName := range keyNames {
+			result, err := b.compareByMapKey(keyName, oldVal, newVal)
+			if err != nil {
+				if len(keyNames) > 1 {
+					return nil, fmt.Errorf("%s (part of composite key %s)", err, b.formatCompositeKey(keyNames))
+				}
+				return nil, err
+			}
+			if !result {
+				return starlark.Bool(false), nil
+			}
 		}
 
-		return starlark.Bool(result), nil
+		return starlark.Bool(true), nil
 	}
 
-	return starlark.NewBuiltin("overlay.map_key_matcher", core.ErrWrapper(matchFunc)), nil
+	return &mapKeyMatcher{starlark.NewBuiltin("overlay.map_key_matcher", core.ErrWrapper(matchFunc)), keyNames}, nil
+}
+
+// mapKeyMatcher is the matcher returned by overlay.map_key(): it is kept along with the keys it matches by,
+// so that match errors can refer to them.
+type mapKeyMatcher struct {
+	*starlark.Builtin
+	keyNames []string
+}
+
+// mapKeyMatcherKeys provides the keys that `matcher` (if returned by overlay.map_key) matches by
+func (b overlayModule) mapKeyMatcherKeys(matcher starlark.Value) []string {
+	if typedMatcher, ok := matcher.(*mapKeyMatcher); ok {
+		return typedMatcher.keyNames
+	}
+	return nil
+}
+
+// formatCompositeKey formats keys (or their values) as a tuple, e.g. (containerPort, protocol)
+func (b overlayModule) formatCompositeKey(keyNames []string) string {
+	return "(" + strings.Join(keyNames, ", ") + ")"
 }
 
 func (b overlayModule) compareByMapKey(keyName string, oldVal, newVal interface{}) (bool, error) {
@@ -227,6 +261,47 @@ func (b overlayModule) Subset(
 	return starlark.NewBuiltin("overlay.subset_matcher", core.ErrWrapper(matchFunc)), nil
 }
 
+func (b overlayModule) Path(
+	thread *starlark.Thread, f *starlark.Builtin,
+	args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	if args.Len() != 1 {
+		return starlark.None, fmt.Errorf("expected exactly one argument")
+	}
+
+	expr, err := core.NewStarlarkValue(args.Index(0)).AsString()
+	if err != nil {
+		return starlark.None, err
+	}
+
+	path, err := NewPath(expr)
+	if err != nil {
+		return starlark.None, err
+	}
+
+	matchFunc := func(thread *starlark.Thread, f *starlark.Builtin,
+		args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+		if args.Len() != 3 {
+			return starlark.None, fmt.Errorf("expected exactly 3 arguments")
+		}
+
+		leftVal, err := core.NewStarlarkValue(args.Index(1)).AsGoValue()
+		if err != nil {
+			return starlark.None, err
+		}
+
+		actualObj := yamlmeta.NewASTFromInterface(leftVal)
+		if doc, ok := actualObj.(*yamlmeta.Document); ok {
+			actualObj = doc.Value
+		}
+
+		return starlark.Bool(len(path.Select(actualObj)) > 0), nil
+	}
+
+	return starlark.NewBuiltin("overlay.path_matcher", core.ErrWrapper(matchFunc)), nil
+}
+
 func (b overlayModule) AndOp(
 	thread *starlark.Thread, f *starlark.Builtin,
 	andArgs starlark.Tuple, andKwargs []starlark.Tuple) (starlark.Value, error) {
@@ -24,30 +24,38 @@ func (o Op) mergeArrayItem(
 	leftIdxs, err := ann.Indexes(leftArray)
 	if err != nil {
 		if err, ok := err.(MatchAnnotationNumMatchError); ok && err.isConditional() {
+			o.Trace.node("", TraceOpNone, newItem.Position)
 			return nil
 		}
 		return err
 	}
 
 	if len(leftIdxs) == 0 {
-		return o.appendArrayItem(leftArray, newItem)
+		o.Trace.node(traceIdxSegment(len(leftArray.Items)), TraceOpAdd, newItem.Position)
+		// No need to traverse further
+		leftArray.Items = append(leftArray.Items, newItem.DeepCopy())
+		return nil
 	}
 
 	for _, leftIdx := range leftIdxs {
 		replace := true
 		if leftArray.Items[leftIdx].Value != nil {
+			o.Trace.enter(traceIdxSegment(leftIdx))
 			replace, err = o.apply(leftArray.Items[leftIdx].Value, newItem.Value, matchChildDefaults)
+			o.Trace.exit()
 			if err != nil {
 				return err
 			}
 		}
 		if replace {
+			o.Trace.node(traceIdxSegment(leftIdx), traceOpName(AnnotationMerge), newItem.Position)
 			// left side type and metas are preserved
 			err := leftArray.Items[leftIdx].SetValue(newItem.Value)
 			if err != nil {
 				return err
 			}
 			leftArray.Items[leftIdx].SetPosition(newItem.Position)
+			leftArray.Items[leftIdx].Sensitive = newItem.Sensitive
 		}
 	}
 
@@ -66,12 +74,18 @@ func (o Op) removeArrayItem(
 	leftIdxs, err := ann.Indexes(leftArray)
 	if err != nil {
 		if err, ok := err.(MatchAnnotationNumMatchError); ok && err.isConditional() {
+			o.Trace.node("", TraceOpNone, newItem.Position)
 			return nil
 		}
 		return err
 	}
 
+	if len(leftIdxs) == 0 {
+		o.Trace.node("", TraceOpNone, newItem.Position)
+	}
+
 	for _, leftIdx := range leftIdxs {
+		o.Trace.node(traceIdxSegment(leftIdx), traceOpName(AnnotationRemove), newItem.Position)
 		leftArray.Items[leftIdx] = nil
 	}
 
@@ -106,12 +120,18 @@ func (o Op) replaceArrayItem(
 	leftIdxs, err := ann.Indexes(leftArray)
 	if err != nil {
 		if err, ok := err.(MatchAnnotationNumMatchError); ok && err.isConditional() {
+			o.Trace.node("", TraceOpNone, newItem.Position)
 			return nil
 		}
 		return err
 	}
 
+	if len(leftIdxs) == 0 && !replaceAnn.OrAdd() {
+		o.Trace.node("", TraceOpNone, newItem.Position)
+	}
+
 	for _, leftIdx := range leftIdxs {
+		o.Trace.node(traceIdxSegment(leftIdx), traceOpName(AnnotationReplace), newItem.Position)
 		newVal, err := replaceAnn.Value(leftArray.Items[leftIdx])
 		if err != nil {
 			return err
@@ -127,6 +147,7 @@ func (o Op) replaceArrayItem(
 	}
 
 	if len(leftIdxs) == 0 && replaceAnn.OrAdd() {
+		o.Trace.node(traceIdxSegment(len(leftArray.Items)), TraceOpAdd, newItem.Position)
 		newVal, err := replaceAnn.Value(nil)
 		if err != nil {
 			return err
@@ -154,6 +175,7 @@ func (o Op) insertArrayItem(
 	leftIdxs, err := ann.Indexes(leftArray)
 	if err != nil {
 		if err, ok := err.(MatchAnnotationNumMatchError); ok && err.isConditional() {
+			o.Trace.node("", TraceOpNone, newItem.Position)
 			return nil
 		}
 		return err
@@ -164,6 +186,13 @@ func (o Op) insertArrayItem(
 		return err
 	}
 
+	if len(leftIdxs) == 0 {
+		o.Trace.node("", TraceOpNone, newItem.Position)
+	}
+	for _, leftIdx := range leftIdxs {
+		o.Trace.node(traceIdxSegment(leftIdx), traceOpName(AnnotationInsert), newItem.Position)
+	}
+
 	updatedItems := []*yamlmeta.ArrayItem{}
 
 	for i, leftItem := range leftArray.Items {
@@ -194,6 +223,7 @@ func (o Op) insertArrayItem(
 func (o Op) appendArrayItem(
 	leftArray *yamlmeta.Array, newItem *yamlmeta.ArrayItem) error {
 
+	o.Trace.node(traceIdxSegment(len(leftArray.Items)), traceOpName(AnnotationAppend), newItem.Position)
 	// No need to traverse further
 	leftArray.Items = append(leftArray.Items, newItem.DeepCopy())
 	return nil
@@ -221,18 +251,26 @@ func (o Op) assertArrayItem(
 	leftIdxs, err := ann.Indexes(leftArray)
 	if err != nil {
 		if err, ok := err.(MatchAnnotationNumMatchError); ok && err.isConditional() {
+			o.Trace.node("", TraceOpNone, newItem.Position)
 			return nil
 		}
 		return err
 	}
 
+	if len(leftIdxs) == 0 {
+		o.Trace.node("", TraceOpNone, newItem.Position)
+	}
+
 	for _, leftIdx := range leftIdxs {
+		o.Trace.node(traceIdxSegment(leftIdx), traceOpName(AnnotationAssert), newItem.Position)
 		err := testAnn.Check(leftArray.Items[leftIdx])
 		if err != nil {
 			return err
 		}
 
+		o.Trace.enter(traceIdxSegment(leftIdx))
 		_, err = o.apply(leftArray.Items[leftIdx].Value, newItem.Value, matchChildDefaults)
+		o.Trace.exit()
 		if err != nil {
 			return err
 		}
@@ -78,7 +78,73 @@ func (a ArrayItemMatchAnnotation) Indexes(leftArray *yamlmeta.Array) ([]int, err
 		return nil, err
 	}
 
-	return idxs, a.expects.Check(matches)
+	err = a.expects.Check(matches)
+	if numMatchErr, ok := err.(MatchAnnotationNumMatchError); ok {
+		return idxs, a.withCompositeKey(numMatchErr)
+	}
+	return idxs, err
+}
+
+// withCompositeKey adds the composite key (and its values) that items were matched by to `err`
+// (e.g. "... for key (containerPort, protocol) = (80, "TCP")")
+func (a ArrayItemMatchAnnotation) withCompositeKey(err MatchAnnotationNumMatchError) MatchAnnotationNumMatchError {
+	matcher, matcherErr := a.mapKeyMatcher()
+	if matcherErr != nil || matcher == nil {
+		return err
+	}
+	keyNames := overlayModule{}.mapKeyMatcherKeys(*matcher)
+	if len(keyNames) < 2 {
+		return err
+	}
+
+	err.message += " for key " + overlayModule{}.formatCompositeKey(keyNames)
+
+	newMap, isMap := a.newItem.Value.(*yamlmeta.Map)
+	if !isMap {
+		return err
+	}
+	var keyVals []string
+	for _, keyName := range keyNames {
+		keyVal, pullErr := overlayModule{}.pullOutMapValue(keyName, newMap)
+		if pullErr != nil {
+			return err
+		}
+		if strVal, isStr := keyVal.(string); isStr {
+			keyVals = append(keyVals, fmt.Sprintf("%q", strVal))
+		} else {
+			keyVals = append(keyVals, fmt.Sprintf("%v", keyVal))
+		}
+	}
+	err.message += " = " + overlayModule{}.formatCompositeKey(keyVals)
+	return err
+}
+
+// mapKeyMatcher converts 'by' given as map key(s) (i.e. string, or tuple or list of strings) into overlay.map_key
+func (a ArrayItemMatchAnnotation) mapKeyMatcher() (*starlark.Value, error) {
+	matcher := a.matcher
+	if matcher == nil {
+		return nil, nil
+	}
+
+	var keyNames starlark.Tuple
+
+	switch typedMatcher := (*matcher).(type) {
+	case starlark.String:
+		keyNames = starlark.Tuple{typedMatcher}
+	case starlark.Tuple, *starlark.List:
+		indexable := typedMatcher.(starlark.Indexable)
+		for i := 0; i < indexable.Len(); i++ {
+			keyNames = append(keyNames, indexable.Index(i))
+		}
+	default:
+		return matcher, nil
+	}
+
+	matcherFunc, err := starlark.Call(a.thread, overlayModule{}.MapKey(), keyNames, []starlark.Tuple{})
+	if err != nil {
+		return nil, err
+	}
+	return &matcherFunc, nil
 }
 
 func (a ArrayItemMatchAnnotation) MatchNodes(leftArray *yamlmeta.Array) ([]int, []*filepos.Position, error) {
@@ -86,21 +152,14 @@ func (a ArrayItemMatchAnnotation) MatchNodes(leftArray *yamlmeta.Array) ([]int,
 		return nil, nil, nil
 	}
 
-	matcher := a.matcher
-
-	if matcher == nil {
+	if a.matcher == nil {
 		return nil, nil, fmt.Errorf("Expected '%s' annotation "+
 			"keyword argument 'by' to be specified", AnnotationMatch)
 	}
 
-	if _, ok := (*matcher).(starlark.String); ok {
-		matcherFunc, err := starlark.Call(a.thread, overlayModule{}.MapKey(),
-			starlark.Tuple{*matcher}, []starlark.Tuple{})
-		if err != nil {
-			return nil, nil, err
-		}
-
-		matcher = &matcherFunc
+	matcher, err := a.mapKeyMatcher()
+	if err != nil {
+		return nil, nil, err
 	}
 
 	switch typedVal := (*matcher).(type) {
@@ -135,6 +194,6 @@ func (a ArrayItemMatchAnnotation) MatchNodes(leftArray *yamlmeta.Array) ([]int,
 
 	default:
 		return nil, nil, fmt.Errorf("Expected '%s' annotation keyword argument 'by' "+
-			"to be either string (for map key) or function, but was %T", AnnotationMatch, typedVal)
+			"to be either string or tuple of strings (for map keys) or function, but was %T", AnnotationMatch, typedVal)
 	}
 }
@@ -4,6 +4,8 @@
 package overlay
 
 import (
+	"fmt"
+
 	"github.com/k14s/ytt/pkg/yamlmeta"
 )
 
@@ -29,16 +31,33 @@ func (o Op) mergeDocument(
 		return err
 	}
 
-	for _, leftIdx := range leftIdxs {
-		replace := true
-		if leftDocSets[leftIdx[0]].Items[leftIdx[1]].Value != nil {
-			replace, err = o.apply(leftDocSets[leftIdx[0]].Items[leftIdx[1]].Value, newDoc.Value, matchChildDefaults)
-			if err != nil {
-				return err
-			}
+	o.Trace.matchDocuments(leftDocSets, leftIdxs)
+
+	for i, leftIdx := range leftIdxs {
+		o.Trace.enterDocument(i)
+
+		targets, err := ann.At(leftDocSets[leftIdx[0]].Items[leftIdx[1]])
+		if err != nil {
+			return err
 		}
-		if replace {
-			leftDocSets[leftIdx[0]].Items[leftIdx[1]].Value = newDoc.Value
+
+		for _, target := range targets {
+			replace := true
+			if target.Node.GetValues()[0] != nil {
+				o.Trace.enterAll(target.Segments)
+				replace, err = o.apply(target.Node.GetValues()[0], newDoc.Value, matchChildDefaults)
+				o.Trace.exitAll(target.Segments)
+				if err != nil {
+					return err
+				}
+			}
+			if replace {
+				o.Trace.nodeAt(target.Segments, traceOpName(AnnotationMerge), newDoc.Position)
+				err := target.Node.SetValue(o.copyValue(newDoc.Value))
+				if err != nil {
+					return err
+				}
+			}
 		}
 	}
 
@@ -62,8 +81,24 @@ func (o Op) removeDocument(
 		return err
 	}
 
-	for _, leftIdx := range leftIdxs {
-		leftDocSets[leftIdx[0]].Items[leftIdx[1]] = nil
+	o.Trace.matchDocuments(leftDocSets, leftIdxs)
+
+	for i, leftIdx := range leftIdxs {
+		o.Trace.enterDocument(i)
+
+		targets, err := ann.At(leftDocSets[leftIdx[0]].Items[leftIdx[1]])
+		if err != nil {
+			return err
+		}
+
+		for _, target := range targets {
+			if target.Parent == nil {
+				leftDocSets[leftIdx[0]].Items[leftIdx[1]] = nil
+				continue
+			}
+			o.Trace.nodeAt(target.Segments, traceOpName(AnnotationRemove), newDoc.Position)
+			o.removeNode(target.Parent, target.Node)
+		}
 	}
 
 	// Prune out all nil documents
@@ -104,16 +139,36 @@ func (o Op) replaceDocument(
 		return err
 	}
 
-	for _, leftIdx := range leftIdxs {
-		newVal, err := replaceAnn.Value(leftDocSets[leftIdx[0]].Items[leftIdx[1]])
+	o.Trace.matchDocuments(leftDocSets, leftIdxs)
+
+	for i, leftIdx := range leftIdxs {
+		o.Trace.enterDocument(i)
+
+		targets, err := ann.At(leftDocSets[leftIdx[0]].Items[leftIdx[1]])
 		if err != nil {
 			return err
 		}
 
-		leftDocSets[leftIdx[0]].Items[leftIdx[1]] = newDoc.DeepCopy()
-		err = leftDocSets[leftIdx[0]].Items[leftIdx[1]].SetValue(newVal)
-		if err != nil {
-			return err
+		for _, target := range targets {
+			newVal, err := replaceAnn.Value(target.Node)
+			if err != nil {
+				return err
+			}
+
+			if target.Parent != nil {
+				o.Trace.nodeAt(target.Segments, traceOpName(AnnotationReplace), newDoc.Position)
+				err = target.Node.SetValue(newVal)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			leftDocSets[leftIdx[0]].Items[leftIdx[1]] = newDoc.DeepCopy()
+			err = leftDocSets[leftIdx[0]].Items[leftIdx[1]].SetValue(newVal)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -154,11 +209,18 @@ func (o Op) insertDocument(
 		return err
 	}
 
+	if ann.at != nil {
+		return fmt.Errorf("Expected '%s' annotation keyword argument '%s' to not be specified "+
+			"on documents with '%s'", AnnotationMatch, MatchAnnotationKwargAt, AnnotationInsert)
+	}
+
 	insertAnn, err := NewInsertAnnotation(newDoc)
 	if err != nil {
 		return err
 	}
 
+	o.Trace.matchDocuments(leftDocSets, leftIdxs)
+
 	for i, leftDocSet := range leftDocSets {
 		updatedDocs := []*yamlmeta.Document{}
 
@@ -223,17 +285,65 @@ func (o Op) assertDocument(
 		return err
 	}
 
-	for _, leftIdx := range leftIdxs {
-		err := testAnn.Check(leftDocSets[leftIdx[0]].Items[leftIdx[1]])
+	o.Trace.matchDocuments(leftDocSets, leftIdxs)
+
+	for i, leftIdx := range leftIdxs {
+		o.Trace.enterDocument(i)
+
+		targets, err := ann.At(leftDocSets[leftIdx[0]].Items[leftIdx[1]])
 		if err != nil {
 			return err
 		}
 
-		_, err = o.apply(leftDocSets[leftIdx[0]].Items[leftIdx[1]].Value, newDoc.Value, matchChildDefaults)
-		if err != nil {
-			return err
+		for _, target := range targets {
+			o.Trace.nodeAt(target.Segments, traceOpName(AnnotationAssert), newDoc.Position)
+			err := testAnn.Check(target.Node)
+			if err != nil {
+				return err
+			}
+
+			o.Trace.enterAll(target.Segments)
+			_, err = o.apply(target.Node.GetValues()[0], newDoc.Value, matchChildDefaults)
+			o.Trace.exitAll(target.Segments)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
+
+// removeNode removes `node` (i.e. map item or array item) from its `parent` (i.e. map or array)
+func (o Op) removeNode(parent, node yamlmeta.Node) {
+	switch typedParent := parent.(type) {
+	case *yamlmeta.Map:
+		updatedItems := []*yamlmeta.MapItem{}
+		for _, item := range typedParent.Items {
+			if item != node {
+				updatedItems = append(updatedItems, item)
+			}
+		}
+		typedParent.Items = updatedItems
+
+	case *yamlmeta.Array:
+		updatedItems := []*yamlmeta.ArrayItem{}
+		for _, item := range typedParent.Items {
+			if item != node {
+				updatedItems = append(updatedItems, item)
+			}
+		}
+		typedParent.Items = updatedItems
+
+	default:
+		panic(fmt.Sprintf("Unexpected parent %T", parent))
+	}
+}
+
+// copyValue ensures that a value set on several nodes is not shared between them
+func (o Op) copyValue(val interface{}) interface{} {
+	if node, isNode := val.(yamlmeta.Node); isNode {
+		return node.DeepCopyAsInterface()
+	}
+	return val
+}
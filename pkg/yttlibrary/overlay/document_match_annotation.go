@@ -21,6 +21,7 @@ type DocumentMatchAnnotation struct {
 
 	matcher *starlark.Value
 	expects MatchAnnotationExpectsKwarg
+	at      *Path
 }
 
 func NewDocumentMatchAnnotation(newDoc *yamlmeta.Document,
@@ -52,6 +53,17 @@ func NewDocumentMatchAnnotation(newDoc *yamlmeta.Document,
 			annotation.expects.missingOK = &kwarg[1]
 		case MatchAnnotationKwargWhen:
 			annotation.expects.when = &kwarg[1]
+		case MatchAnnotationKwargAt:
+			expr, err := tplcore.NewStarlarkValue(kwarg[1]).AsString()
+			if err != nil {
+				return annotation, fmt.Errorf("Expected '%s' annotation keyword argument '%s' "+
+					"to be a path expression: %s", AnnotationMatch, kwargName, err)
+			}
+			path, err := NewPath(expr)
+			if err != nil {
+				return annotation, err
+			}
+			annotation.at = &path
 		default:
 			return annotation, fmt.Errorf(
 				"Unknown '%s' annotation keyword argument '%s'", AnnotationMatch, kwargName)
@@ -72,6 +84,20 @@ func (a DocumentMatchAnnotation) IndexTuples(leftDocSets []*yamlmeta.DocumentSet
 	return idxs, a.expects.Check(matches)
 }
 
+// At selects nodes within matched document `leftDoc` that the overlay applies to
+// (the document's value itself, if keyword argument 'at' was not specified)
+func (a DocumentMatchAnnotation) At(leftDoc *yamlmeta.Document) ([]PathMatch, error) {
+	if a.at == nil {
+		return []PathMatch{{Node: leftDoc}}, nil
+	}
+	matches := a.at.Select(leftDoc.Value)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("Expected path '%s' (keyword argument '%s') to select at least one node "+
+			"within document on %s, but selected none", a.at, MatchAnnotationKwargAt, leftDoc.Position.AsCompactString())
+	}
+	return matches, nil
+}
+
 func (a DocumentMatchAnnotation) MatchNodes(leftDocSets []*yamlmeta.DocumentSet) ([][]int, []*filepos.Position, error) {
 	if a.exact {
 		if len(leftDocSets) != 1 && len(leftDocSets[0].Items) != 1 {
@@ -23,12 +23,14 @@ func (o Op) mergeMapItem(leftMap *yamlmeta.Map, newItem *yamlmeta.MapItem,
 	leftIdxs, err := ann.Indexes(leftMap)
 	if err != nil {
 		if err, ok := err.(MatchAnnotationNumMatchError); ok && err.isConditional() {
+			o.Trace.node(traceKeySegment(newItem.Key), TraceOpNone, newItem.Position)
 			return nil
 		}
 		return err
 	}
 
 	if len(leftIdxs) == 0 {
+		o.Trace.node(traceKeySegment(newItem.Key), TraceOpAdd, newItem.Position)
 		// No need to traverse further
 		leftMap.Items = append(leftMap.Items, newItem)
 		return nil
@@ -37,18 +39,22 @@ func (o Op) mergeMapItem(leftMap *yamlmeta.Map, newItem *yamlmeta.MapItem,
 	for _, leftIdx := range leftIdxs {
 		replace := true
 		if leftMap.Items[leftIdx].Value != nil {
+			o.Trace.enter(traceKeySegment(leftMap.Items[leftIdx].Key))
 			replace, err = o.apply(leftMap.Items[leftIdx].Value, newItem.Value, matchChildDefaults)
+			o.Trace.exit()
 			if err != nil {
 				return err
 			}
 		}
 		if replace {
+			o.Trace.node(traceKeySegment(leftMap.Items[leftIdx].Key), traceOpName(AnnotationMerge), newItem.Position)
 			// left side type and metas are preserved
 			err := leftMap.Items[leftIdx].SetValue(newItem.Value)
 			if err != nil {
 				return err
 			}
 			leftMap.Items[leftIdx].SetPosition(newItem.Position)
+			leftMap.Items[leftIdx].Sensitive = newItem.Sensitive
 		}
 	}
 
@@ -66,12 +72,18 @@ func (o Op) removeMapItem(leftMap *yamlmeta.Map, newItem *yamlmeta.MapItem,
 	leftIdxs, err := ann.Indexes(leftMap)
 	if err != nil {
 		if err, ok := err.(MatchAnnotationNumMatchError); ok && err.isConditional() {
+			o.Trace.node(traceKeySegment(newItem.Key), TraceOpNone, newItem.Position)
 			return nil
 		}
 		return err
 	}
 
+	if len(leftIdxs) == 0 {
+		o.Trace.node(traceKeySegment(newItem.Key), TraceOpNone, newItem.Position)
+	}
+
 	for _, leftIdx := range leftIdxs {
+		o.Trace.node(traceKeySegment(leftMap.Items[leftIdx].Key), traceOpName(AnnotationRemove), newItem.Position)
 		leftMap.Items[leftIdx] = nil
 	}
 
@@ -105,12 +117,18 @@ func (o Op) replaceMapItem(leftMap *yamlmeta.Map, newItem *yamlmeta.MapItem,
 	leftIdxs, err := ann.Indexes(leftMap)
 	if err != nil {
 		if err, ok := err.(MatchAnnotationNumMatchError); ok && err.isConditional() {
+			o.Trace.node(traceKeySegment(newItem.Key), TraceOpNone, newItem.Position)
 			return nil
 		}
 		return err
 	}
 
+	if len(leftIdxs) == 0 && !replaceAnn.OrAdd() {
+		o.Trace.node(traceKeySegment(newItem.Key), TraceOpNone, newItem.Position)
+	}
+
 	for _, leftIdx := range leftIdxs {
+		o.Trace.node(traceKeySegment(leftMap.Items[leftIdx].Key), traceOpName(AnnotationReplace), newItem.Position)
 		newVal, err := replaceAnn.Value(leftMap.Items[leftIdx])
 		if err != nil {
 			return err
@@ -126,6 +144,7 @@ func (o Op) replaceMapItem(leftMap *yamlmeta.Map, newItem *yamlmeta.MapItem,
 	}
 
 	if len(leftIdxs) == 0 && replaceAnn.OrAdd() {
+		o.Trace.node(traceKeySegment(newItem.Key), TraceOpAdd, newItem.Position)
 		newVal, err := replaceAnn.Value(nil)
 		if err != nil {
 			return err
@@ -162,18 +181,26 @@ func (o Op) assertMapItem(leftMap *yamlmeta.Map, newItem *yamlmeta.MapItem,
 	leftIdxs, err := ann.Indexes(leftMap)
 	if err != nil {
 		if err, ok := err.(MatchAnnotationNumMatchError); ok && err.isConditional() {
+			o.Trace.node(traceKeySegment(newItem.Key), TraceOpNone, newItem.Position)
 			return nil
 		}
 		return err
 	}
 
+	if len(leftIdxs) == 0 {
+		o.Trace.node(traceKeySegment(newItem.Key), TraceOpNone, newItem.Position)
+	}
+
 	for _, leftIdx := range leftIdxs {
+		o.Trace.node(traceKeySegment(leftMap.Items[leftIdx].Key), traceOpName(AnnotationAssert), newItem.Position)
 		err := testAnn.Check(leftMap.Items[leftIdx])
 		if err != nil {
 			return err
 		}
 
+		o.Trace.enter(traceKeySegment(leftMap.Items[leftIdx].Key))
 		_, err = o.apply(leftMap.Items[leftIdx].Value, newItem.Value, matchChildDefaults)
+		o.Trace.exit()
 		if err != nil {
 			return err
 		}
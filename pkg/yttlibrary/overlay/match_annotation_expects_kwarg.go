@@ -18,6 +18,8 @@ const (
 	MatchAnnotationKwargExpects   string = "expects"
 	MatchAnnotationKwargMissingOK string = "missing_ok"
 	MatchAnnotationKwargWhen      string = "when"
+	// MatchAnnotationKwargAt (only on documents) selects nodes within matched documents that the overlay applies to
+	MatchAnnotationKwargAt string = "at"
 )
 
 type MatchAnnotationExpectsKwarg struct {
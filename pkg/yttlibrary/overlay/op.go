@@ -18,6 +18,9 @@ type Op struct {
 	Thread *starlark.Thread
 
 	ExactMatch bool
+
+	// Trace (if any) records the documents and nodes that each overlay document was applied to
+	Trace *Trace
 }
 
 func (o Op) Apply() (interface{}, error) {
@@ -151,6 +154,7 @@ func (o Op) applyDocSet(
 
 		op, err := whichOp(doc)
 		if err == nil {
+			o.Trace.beginOverlay(doc, op)
 			switch op {
 			case AnnotationMerge:
 				err = o.mergeDocument(typedLeft, doc, parentMatchChildDefaults)
@@ -0,0 +1,296 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package overlay
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// Path is a path expression selecting nodes within a YAML value, e.g.
+// "spec.template.spec.containers[*].env[?name=='FOO']". Expressions are made up of:
+//   - map keys, separated by dots (e.g. "metadata.name"), or quoted within brackets (e.g. "data['app.yml']")
+//   - "*" or "[*]" for all items of a map or array
+//   - "[N]" for the Nth item of an array
+//   - "[?key]", "[?key==value]" and "[?key!=value]" for array items (that are maps) filtered by one of their keys
+//     (value being a quoted string, a number, true, false or null)
+type Path struct {
+	expr      string
+	selectors []pathSelector
+}
+
+// PathMatch is a node (i.e. map item or array item) selected by a Path
+type PathMatch struct {
+	// Parent is the map or array holding Node
+	Parent yamlmeta.Node
+	Node   yamlmeta.Node
+	// Segments lead to Node from where the path was evaluated (e.g. ["spec", "containers", "[0]"])
+	Segments []string
+}
+
+type pathSelectorType int
+
+const (
+	pathSelectorKey pathSelectorType = iota
+	pathSelectorAll
+	pathSelectorIndex
+	pathSelectorFilter
+)
+
+type pathSelector struct {
+	typ   pathSelectorType
+	key   string
+	index int
+
+	filterOp    string
+	filterValue interface{}
+}
+
+// NewPath parses path expression `expr` (see Path)
+func NewPath(expr string) (Path, error) {
+	path := Path{expr: expr}
+	if len(strings.TrimSpace(expr)) == 0 {
+		return path, fmt.Errorf("Expected path to be non-empty")
+	}
+
+	rest := expr
+	for len(rest) > 0 {
+		var selector pathSelector
+		var err error
+
+		switch {
+		case strings.HasPrefix(rest, "["):
+			end := path.bracketEnd(rest)
+			if end < 0 {
+				return path, fmt.Errorf("Expected path '%s' to close bracket opened at '%s'", expr, rest)
+			}
+			selector, err = path.parseBracket(rest[1:end])
+			if err != nil {
+				return path, err
+			}
+			rest = rest[end+1:]
+			if len(rest) > 0 && !strings.HasPrefix(rest, ".") && !strings.HasPrefix(rest, "[") {
+				return path, fmt.Errorf("Expected path '%s' to have '.' or '[' after ']', but had '%s'", expr, rest)
+			}
+
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if len(key) == 0 {
+				return path, fmt.Errorf("Expected path '%s' to not have empty keys", expr)
+			}
+			selector = pathSelector{typ: pathSelectorKey, key: key}
+			if key == "*" {
+				selector = pathSelector{typ: pathSelectorAll}
+			}
+			rest = rest[end:]
+		}
+
+		path.selectors = append(path.selectors, selector)
+
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if len(rest) == 0 || strings.HasPrefix(rest, "[") {
+				return path, fmt.Errorf("Expected path '%s' to have a key after '.'", expr)
+			}
+		}
+	}
+
+	return path, nil
+}
+
+// bracketEnd finds the bracket closing the one that `expr` starts with (ignoring brackets within quotes)
+func (p Path) bracketEnd(expr string) int {
+	var quote rune
+	for i, ch := range expr {
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func (p Path) parseBracket(contents string) (pathSelector, error) {
+	contents = strings.TrimSpace(contents)
+
+	switch {
+	case contents == "*":
+		return pathSelector{typ: pathSelectorAll}, nil
+
+	case strings.HasPrefix(contents, "?"):
+		return p.parseFilter(strings.TrimSpace(contents[1:]))
+
+	case strings.HasPrefix(contents, "'") || strings.HasPrefix(contents, "\""):
+		key, err := p.parseQuoted(contents)
+		if err != nil {
+			return pathSelector{}, err
+		}
+		return pathSelector{typ: pathSelectorKey, key: key}, nil
+	}
+
+	idx, err := strconv.Atoi(contents)
+	if err != nil || idx < 0 {
+		return pathSelector{}, fmt.Errorf("Expected path '%s' to only have brackets with "+
+			"*, an index, a quoted key or a filter (e.g. [?name=='foo']), but had '[%s]'", p.expr, contents)
+	}
+	return pathSelector{typ: pathSelectorIndex, index: idx}, nil
+}
+
+func (p Path) parseFilter(filter string) (pathSelector, error) {
+	selector := pathSelector{typ: pathSelectorFilter}
+
+	for _, op := range []string{"==", "!="} {
+		pieces := strings.SplitN(filter, op, 2)
+		if len(pieces) != 2 {
+			continue
+		}
+		val, err := p.parseFilterValue(strings.TrimSpace(pieces[1]))
+		if err != nil {
+			return selector, err
+		}
+		selector.key = strings.TrimSpace(pieces[0])
+		selector.filterOp = op
+		selector.filterValue = val
+		break
+	}
+	if len(selector.filterOp) == 0 {
+		selector.key = filter
+	}
+	if len(selector.key) == 0 {
+		return selector, fmt.Errorf("Expected path '%s' filter to name a key (e.g. [?name=='foo'])", p.expr)
+	}
+	return selector, nil
+}
+
+func (p Path) parseFilterValue(val string) (interface{}, error) {
+	switch val {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if strings.HasPrefix(val, "'") || strings.HasPrefix(val, "\"") {
+		return p.parseQuoted(val)
+	}
+	if intVal, err := strconv.Atoi(val); err == nil {
+		return intVal, nil
+	}
+	if floatVal, err := strconv.ParseFloat(val, 64); err == nil {
+		return floatVal, nil
+	}
+	return nil, fmt.Errorf("Expected path '%s' filter value to be a quoted string, "+
+		"a number, true, false or null, but was '%s'", p.expr, val)
+}
+
+func (p Path) parseQuoted(val string) (string, error) {
+	if len(val) < 2 || val[0] != val[len(val)-1] {
+		return "", fmt.Errorf("Expected path '%s' to close quote in '%s'", p.expr, val)
+	}
+	return val[1 : len(val)-1], nil
+}
+
+// String returns the expression the path was parsed from
+func (p Path) String() string { return p.expr }
+
+// Select finds nodes within `val` (i.e. map or array) that the path leads to
+func (p Path) Select(val interface{}) []PathMatch {
+	// root is the only "match" without a node
+	matches := []PathMatch{{}}
+
+	for _, selector := range p.selectors {
+		var nextMatches []PathMatch
+		for _, match := range matches {
+			currVal := val
+			if match.Node != nil {
+				currVal = match.Node.GetValues()[0]
+			}
+			nextMatches = append(nextMatches, selector.Select(currVal, match.Segments)...)
+		}
+		matches = nextMatches
+	}
+
+	return matches
+}
+
+func (s pathSelector) Select(val interface{}, segments []string) []PathMatch {
+	var result []PathMatch
+	add := func(parent, node yamlmeta.Node, segment string) {
+		result = append(result, PathMatch{
+			Parent:   parent,
+			Node:     node,
+			Segments: append(append([]string{}, segments...), segment),
+		})
+	}
+
+	switch typedVal := val.(type) {
+	case *yamlmeta.Map:
+		for _, item := range typedVal.Items {
+			switch s.typ {
+			case pathSelectorAll:
+				add(typedVal, item, traceKeySegment(item.Key))
+			case pathSelectorKey:
+				if item.Key == s.key {
+					add(typedVal, item, traceKeySegment(item.Key))
+				}
+			}
+		}
+
+	case *yamlmeta.Array:
+		for i, item := range typedVal.Items {
+			switch s.typ {
+			case pathSelectorAll:
+				add(typedVal, item, traceIdxSegment(i))
+			case pathSelectorIndex:
+				if i == s.index {
+					add(typedVal, item, traceIdxSegment(i))
+				}
+			case pathSelectorFilter:
+				if s.filterMatches(item.Value) {
+					add(typedVal, item, traceIdxSegment(i))
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+func (s pathSelector) filterMatches(val interface{}) bool {
+	typedMap, isMap := val.(*yamlmeta.Map)
+	if !isMap {
+		return false
+	}
+	for _, item := range typedMap.Items {
+		if item.Key != s.key {
+			continue
+		}
+		switch s.filterOp {
+		case "==":
+			result, _ := Comparison{}.CompareLeafs(item.Value, s.filterValue)
+			return result
+		case "!=":
+			result, _ := Comparison{}.CompareLeafs(item.Value, s.filterValue)
+			return !result
+		default:
+			return item.Value != nil
+		}
+	}
+	// missing keys are not equal to any value
+	return s.filterOp == "!="
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package overlay
+
+import (
+	"fmt"
+
+	"github.com/k14s/ytt/pkg/template"
+	tplcore "github.com/k14s/ytt/pkg/template/core"
+)
+
+// PriorityAnnotation orders overlay documents (across files): those with a higher priority are applied later.
+// Overlays without one have priority 0.
+type PriorityAnnotation struct {
+	priority int64
+}
+
+// NewPriorityAnnotation reads the @overlay/priority annotation (if any) on `newDoc`.
+func NewPriorityAnnotation(newDoc template.EvaluationNode) (PriorityAnnotation, error) {
+	annotation := PriorityAnnotation{}
+	anns := template.NewAnnotations(newDoc)
+
+	if !anns.Has(AnnotationPriority) {
+		return annotation, nil
+	}
+
+	args := anns.Args(AnnotationPriority)
+	if args.Len() != 1 {
+		return annotation, fmt.Errorf("Expected '%s' annotation to have exactly one argument", AnnotationPriority)
+	}
+
+	priority, err := tplcore.NewStarlarkValue(args[0]).AsInt64()
+	if err != nil {
+		return annotation, fmt.Errorf("Expected '%s' annotation argument to be an integer: %s", AnnotationPriority, err)
+	}
+	annotation.priority = priority
+
+	return annotation, nil
+}
+
+// Priority is the priority given to the overlay (0, if not annotated).
+func (a PriorityAnnotation) Priority() int64 { return a.priority }
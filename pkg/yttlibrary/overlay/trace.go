@@ -0,0 +1,143 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package overlay
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// Operations recorded in addition to overlay operations (i.e. those named by the annotations, without namespace)
+const (
+	// TraceOpAdd is recorded when a node is added as nothing was matched (e.g. by a merge, or replace with or_add=True)
+	TraceOpAdd = "add"
+	// TraceOpNone is recorded when nothing was matched, and so nothing was done (e.g. missing_ok=True)
+	TraceOpNone = "none"
+)
+
+// Trace records, for each overlay document applied, the documents its matcher selected and
+// the operations applied to nodes within each. (Nothing is recorded via a nil *Trace.)
+type Trace struct {
+	Overlays []*OverlayTrace
+
+	currMatch *MatchTrace
+	path      []string
+}
+
+// OverlayTrace records the application of a single overlay document
+type OverlayTrace struct {
+	Position *filepos.Position
+	Op       string
+	Matches  []*MatchTrace
+}
+
+// MatchTrace records a document selected by an overlay document
+type MatchTrace struct {
+	DocSetIdx int
+	DocIdx    int
+	// Doc is a copy of the document as it was when matched
+	Doc   *yamlmeta.Document
+	Nodes []NodeTrace
+}
+
+// NodeTrace records an operation applied at a path (e.g. "spec.containers[0].image") within a matched document
+type NodeTrace struct {
+	Path     string
+	Op       string
+	Position *filepos.Position
+}
+
+func (t *Trace) beginOverlay(doc *yamlmeta.Document, op template.AnnotationName) {
+	if t == nil {
+		return
+	}
+	t.Overlays = append(t.Overlays, &OverlayTrace{Position: doc.Position, Op: traceOpName(op)})
+	t.currMatch = nil
+	t.path = nil
+}
+
+func (t *Trace) matchDocuments(leftDocSets []*yamlmeta.DocumentSet, leftIdxs [][]int) {
+	if t == nil || len(t.Overlays) == 0 {
+		return
+	}
+	overlay := t.Overlays[len(t.Overlays)-1]
+	for _, leftIdx := range leftIdxs {
+		overlay.Matches = append(overlay.Matches, &MatchTrace{
+			DocSetIdx: leftIdx[0],
+			DocIdx:    leftIdx[1],
+			Doc:       leftDocSets[leftIdx[0]].Items[leftIdx[1]].DeepCopy(),
+		})
+	}
+}
+
+// enterDocument directs the nodes that follow to be recorded against the `i`th matched document
+func (t *Trace) enterDocument(i int) {
+	if t == nil || len(t.Overlays) == 0 {
+		return
+	}
+	t.currMatch = t.Overlays[len(t.Overlays)-1].Matches[i]
+	t.path = nil
+}
+
+func (t *Trace) enter(segment string) {
+	if t == nil {
+		return
+	}
+	t.path = append(t.path, segment)
+}
+
+func (t *Trace) exit() {
+	if t == nil {
+		return
+	}
+	t.path = t.path[:len(t.path)-1]
+}
+
+func (t *Trace) node(segment string, op string, pos *filepos.Position) {
+	if t == nil || t.currMatch == nil {
+		return
+	}
+	path := ""
+	for _, seg := range append(append([]string{}, t.path...), segment) {
+		if len(path) > 0 && len(seg) > 0 && !strings.HasPrefix(seg, "[") {
+			path += "."
+		}
+		path += seg
+	}
+	t.currMatch.Nodes = append(t.currMatch.Nodes, NodeTrace{Path: path, Op: op, Position: pos})
+}
+
+// enterAll directs the nodes that follow to be recorded within the node at path `segments`
+func (t *Trace) enterAll(segments []string) {
+	for _, segment := range segments {
+		t.enter(segment)
+	}
+}
+
+func (t *Trace) exitAll(segments []string) {
+	for range segments {
+		t.exit()
+	}
+}
+
+// nodeAt records an operation at path `segments` (nothing, if the path is empty, i.e. the document itself)
+func (t *Trace) nodeAt(segments []string, op string, pos *filepos.Position) {
+	if len(segments) == 0 {
+		return
+	}
+	t.enterAll(segments[:len(segments)-1])
+	t.node(segments[len(segments)-1], op, pos)
+	t.exitAll(segments[:len(segments)-1])
+}
+
+func traceOpName(op template.AnnotationName) string {
+	return strings.TrimPrefix(string(op), string(AnnotationNs)+"/")
+}
+
+func traceKeySegment(key interface{}) string { return fmt.Sprintf("%v", key) }
+func traceIdxSegment(idx int) string         { return fmt.Sprintf("[%d]", idx) }